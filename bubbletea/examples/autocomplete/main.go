@@ -1,11 +1,14 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
-	"io"
 	"log"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/key"
@@ -15,117 +18,296 @@ import (
 )
 
 func main() {
-	p := tea.NewProgram(initialModel())
-	if _, err := p.Run(); err != nil {
-		log.Fatal(err)
-	}
-}
-
-type gotReposSuccessMsg []repo
-type gotReposErrMsg error
-
-type repo struct {
-	Name string `json:"name"`
-}
-
-const reposURL = "https://api.github.com/orgs/charmbracelet/repos"
+	var (
+		file      = flag.String("file", "", "read candidates from a file, one per line, with an optional tab-separated description")
+		command   = flag.String("cmd", "", "read candidates from a shell command's output; it's rerun as you type if it uses $QUERY")
+		apiURL    = flag.String("url", "", "fetch candidates from a JSON API; {query} in the URL is replaced with what's typed")
+		items     = flag.String("items", "", "path to the list of candidates in the JSON response, like items")
+		nameField = flag.String("name-field", "name", "path to the name in each JSON item")
+		descField = flag.String("desc-field", "description", "path to the description in each JSON item")
+		cacheDir  = flag.String("cache", defaultCacheDir(), "directory to cache JSON responses in, or empty for none")
+		maxAge    = flag.Duration("max-age", time.Hour, "how long to use a cached response before fetching it again")
+		offline   = flag.Bool("offline", false, "only use cached responses")
+		limit     = flag.Int("n", 8, "number of candidates to show")
+		debounce  = flag.Duration("debounce", 250*time.Millisecond, "how long to wait after typing before refreshing")
+	)
+	flag.Parse()
 
-func getRepos() tea.Msg {
-	req, err := http.NewRequest(http.MethodGet, reposURL, nil)
-	if err != nil {
-		return gotReposErrMsg(err)
+	// Without a source, pick a Charm repo, like we always have.
+	var src Source
+	switch {
+	case *file != "":
+		src = fileSource{*file}
+	case *command != "":
+		src = commandSource{*command}
+	default:
+		s := httpSource{
+			url:       *apiURL,
+			items:     *items,
+			nameField: *nameField,
+			descField: *descField,
+			cacheDir:  *cacheDir,
+			maxAge:    *maxAge,
+			offline:   *offline,
+		}
+		if s.url == "" {
+			s.url = reposURL
+			s.header = http.Header{"X-Github-Api-Version": {"2022-11-28"}}
+		}
+		src = s
 	}
 
-	req.Header.Add("Accept", "application/vnd.github+json")
-	req.Header.Add("X-GitHub-Api-Version", "2022-11-28")
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return gotReposErrMsg(err)
+	m := initialModel(src, *limit, *debounce)
+	if *file != "" || *command != "" || *apiURL != "" {
+		m.title = "Pick one:"
+		m.textInput.Prompt = "> "
+		m.textInput.Placeholder = "start typing"
 	}
-	defer resp.Body.Close() // nolint: errcheck
 
-	data, err := io.ReadAll(resp.Body)
+	p := tea.NewProgram(m)
+	res, err := p.Run()
 	if err != nil {
-		return gotReposErrMsg(err)
+		log.Fatal(err)
 	}
+	if chosen := res.(model).chosen; chosen != "" {
+		fmt.Println(chosen)
+	}
+}
 
-	var repos []repo
+const reposURL = "https://api.github.com/orgs/charmbracelet/repos?per_page=100"
 
-	err = json.Unmarshal(data, &repos)
-	if err != nil {
-		return gotReposErrMsg(err)
-	}
+var (
+	accentStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("63"))
+	nameStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("252"))
+	selectedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("212")).Bold(true)
+	matchStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("214")).Underline(true)
+	descStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	errStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("203"))
+)
 
-	return gotReposSuccessMsg(repos)
-}
+type (
+	// refreshMsg asks the source for candidates, if nothing's been typed
+	// since it was sent.
+	refreshMsg struct{ seq int }
+
+	gotCandidatesMsg struct {
+		seq   int
+		cands []Candidate
+		err   error
+	}
+)
 
 type model struct {
 	textInput textinput.Model
 	help      help.Model
 	keymap    keymap
+	title     string
+
+	source   Source
+	limit    int
+	debounce time.Duration
+
+	cands    []Candidate
+	matches  []match
+	selected int
+	err      error
+	width    int
+	chosen   string
+
+	// seq counts the changes to the query. fetched and loaded are the seq of
+	// the last query we asked the source about and the last one it answered,
+	// so answers to old queries don't replace newer ones.
+	seq, fetched, loaded int
 }
 
-type keymap struct{}
+type keymap struct {
+	complete, next, prev, choose, quit key.Binding
+}
 
 func (k keymap) ShortHelp() []key.Binding {
-	return []key.Binding{
-		key.NewBinding(key.WithKeys("tab"), key.WithHelp("tab", "complete")),
-		key.NewBinding(key.WithKeys("ctrl+n"), key.WithHelp("ctrl+n", "next")),
-		key.NewBinding(key.WithKeys("ctrl+p"), key.WithHelp("ctrl+p", "prev")),
-		key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "quit")),
-	}
+	return []key.Binding{k.complete, k.next, k.prev, k.choose, k.quit}
 }
+
 func (k keymap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{k.ShortHelp()}
 }
 
-func initialModel() model {
+func initialModel(src Source, limit int, debounce time.Duration) model {
 	ti := textinput.New()
 	ti.Placeholder = "repository"
 	ti.Prompt = "charmbracelet/"
-	ti.PromptStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("63"))
-	ti.Cursor.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("63"))
+	ti.PromptStyle = accentStyle
+	ti.Cursor.Style = accentStyle
 	ti.Focus()
-	ti.CharLimit = 50
-	ti.Width = 20
-	ti.ShowSuggestions = true
+	ti.CharLimit = 100
+	ti.Width = 40
 
 	h := help.New()
 
-	km := keymap{}
+	km := keymap{
+		complete: key.NewBinding(key.WithKeys("tab"), key.WithHelp("tab", "complete")),
+		next:     key.NewBinding(key.WithKeys("ctrl+n", "down"), key.WithHelp("↓/ctrl+n", "next")),
+		prev:     key.NewBinding(key.WithKeys("ctrl+p", "up"), key.WithHelp("↑/ctrl+p", "prev")),
+		choose:   key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "choose")),
+		quit:     key.NewBinding(key.WithKeys("esc", "ctrl+c"), key.WithHelp("esc", "quit")),
+	}
+	return model{
+		textInput: ti,
+		help:      h,
+		keymap:    km,
+		title:     "Pick a Charm™ repo:",
+		source:    src,
+		limit:     max(1, limit),
+		debounce:  debounce,
+		width:     80,
+		loaded:    -1,
+	}
+}
+
+// fetch asks the source for candidates for the query as it is now.
+func (m *model) fetch() tea.Cmd {
+	m.fetched = m.seq
+	src, query, seq := m.source, m.textInput.Value(), m.seq
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cancel()
+		cands, err := src.Candidates(ctx, query)
+		return gotCandidatesMsg{seq, cands, err}
+	}
+}
+
+func (m *model) rank() {
+	m.matches = rank(m.cands, m.textInput.Value(), m.limit)
+	m.selected = min(m.selected, max(0, len(m.matches)-1))
+}
 
-	return model{textInput: ti, help: h, keymap: km}
+func (m model) loading() bool {
+	return m.fetched > m.loaded
 }
 
 func (m model) Init() tea.Cmd {
-	return tea.Batch(getRepos, textinput.Blink)
+	return tea.Batch(func() tea.Msg { return refreshMsg{0} }, textinput.Blink)
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.help.Width = msg.Width
+		return m, nil
+
 	case tea.KeyMsg:
-		switch msg.Type {
-		case tea.KeyEnter, tea.KeyCtrlC, tea.KeyEsc:
+		switch {
+		case key.Matches(msg, m.keymap.quit):
+			return m, tea.Quit
+		case key.Matches(msg, m.keymap.choose):
+			if len(m.matches) > 0 {
+				m.chosen = m.matches[m.selected].Name
+			} else {
+				m.chosen = m.textInput.Value()
+			}
 			return m, tea.Quit
+		case key.Matches(msg, m.keymap.next):
+			if len(m.matches) > 0 {
+				m.selected = (m.selected + 1) % len(m.matches)
+			}
+			return m, nil
+		case key.Matches(msg, m.keymap.prev):
+			if len(m.matches) > 0 {
+				m.selected = (m.selected + len(m.matches) - 1) % len(m.matches)
+			}
+			return m, nil
+		case key.Matches(msg, m.keymap.complete):
+			if len(m.matches) == 0 {
+				return m, nil
+			}
+			m.textInput.SetValue(m.matches[m.selected].Name)
+			m.textInput.CursorEnd()
+			return m, m.queryChanged()
 		}
-	case gotReposSuccessMsg:
-		var suggestions []string
-		for _, r := range msg {
-			suggestions = append(suggestions, r.Name)
+
+	case refreshMsg:
+		if msg.seq != m.seq || (msg.seq > 0 && !m.source.Dynamic()) {
+			return m, nil
+		}
+		if m.source.Dynamic() && m.textInput.Value() == "" {
+			// Nothing to search for yet.
+			m.fetched, m.loaded = m.seq, m.seq
+			m.cands, m.matches, m.err = nil, nil, nil
+			return m, nil
 		}
-		m.textInput.SetSuggestions(suggestions)
+		return m, m.fetch()
+
+	case gotCandidatesMsg:
+		if msg.seq < m.loaded {
+			return m, nil
+		}
+		m.loaded = msg.seq
+		m.err = msg.err
+		var stale *staleError
+		if msg.err == nil || errors.As(msg.err, &stale) {
+			m.cands = msg.cands
+			m.rank()
+		}
+		return m, nil
 	}
 
+	before := m.textInput.Value()
 	var cmd tea.Cmd
 	m.textInput, cmd = m.textInput.Update(msg)
+	if m.textInput.Value() != before {
+		cmd = tea.Batch(cmd, m.queryChanged())
+	}
 	return m, cmd
 }
 
+// queryChanged reranks what we have right away, and asks the source again
+// once the user stops typing for a moment.
+func (m *model) queryChanged() tea.Cmd {
+	m.seq++
+	m.selected = 0
+	m.rank()
+	if !m.source.Dynamic() {
+		return nil
+	}
+	seq := m.seq
+	return tea.Tick(m.debounce, func(time.Time) tea.Msg {
+		return refreshMsg{seq}
+	})
+}
+
 func (m model) View() string {
-	return fmt.Sprintf(
-		"Pick a Charm™ repo:\n\n  %s\n\n%s\n\n",
-		m.textInput.View(),
-		m.help.View(m.keymap),
-	)
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n\n  %s\n\n", m.title, m.textInput.View())
+
+	for i, mt := range m.matches {
+		cursor, base := "  ", nameStyle
+		if i == m.selected {
+			cursor, base = accentStyle.Render("› "), selectedStyle
+		}
+		line := "  " + cursor + highlight(mt.Name, mt.matched, base, matchStyle)
+		if mt.Desc != "" {
+			room := m.width - lipgloss.Width(line) - 2
+			if desc := truncate(mt.Desc, room); desc != "" {
+				line += "  " + descStyle.Render(desc)
+			}
+		}
+		b.WriteString(line + "\n")
+	}
+
+	var status string
+	switch {
+	case m.loading():
+		status = descStyle.Render("Loading from " + m.source.Name() + "…")
+	case m.err != nil:
+		status = errStyle.Render(m.err.Error())
+	case m.source.Dynamic() && m.textInput.Value() == "":
+		status = descStyle.Render("Type to search " + m.source.Name())
+	case len(m.matches) == 0:
+		status = descStyle.Render("No matches")
+	default:
+		status = descStyle.Render(fmt.Sprintf("%d of %d", len(m.matches), len(m.cands)))
+	}
+	fmt.Fprintf(&b, "\n  %s\n\n%s\n\n", status, m.help.View(m.keymap))
+	return b.String()
 }
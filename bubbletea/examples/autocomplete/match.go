@@ -0,0 +1,66 @@
+package main
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/sahilm/fuzzy"
+)
+
+// match is a candidate that matches the query, and which bytes of its name
+// matched.
+type match struct {
+	Candidate
+	matched []int
+}
+
+type candidates []Candidate
+
+func (c candidates) String(i int) string { return c[i].Name }
+func (c candidates) Len() int            { return len(c) }
+
+// rank returns the best n candidates for the query, best first. With no
+// query, that's the first n.
+func rank(cands []Candidate, query string, n int) []match {
+	var matches []match
+	if query == "" {
+		for _, c := range cands[:min(n, len(cands))] {
+			matches = append(matches, match{Candidate: c})
+		}
+		return matches
+	}
+	for _, m := range fuzzy.FindFrom(query, candidates(cands)) {
+		if len(matches) == n {
+			break
+		}
+		matches = append(matches, match{cands[m.Index], m.MatchedIndexes})
+	}
+	return matches
+}
+
+// highlight renders the matched bytes of s in style, and the rest in base.
+func highlight(s string, matched []int, base, style lipgloss.Style) string {
+	var b strings.Builder
+	for i, r := range s {
+		if slices.Contains(matched, i) {
+			b.WriteString(style.Render(string(r)))
+		} else {
+			b.WriteString(base.Render(string(r)))
+		}
+	}
+	return b.String()
+}
+
+// truncate cuts s down to n runes, ending it with an ellipsis if it was
+// longer.
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	if n < 1 {
+		return ""
+	}
+	return string(r[:n-1]) + "…"
+}
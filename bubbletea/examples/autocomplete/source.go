@@ -0,0 +1,276 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Candidate is something the user can pick.
+type Candidate struct {
+	Name string
+	Desc string
+}
+
+// Source is where candidates come from.
+type Source interface {
+	// Name says where the candidates come from, for the status line.
+	Name() string
+
+	// Candidates returns the candidates for what's been typed so far. A
+	// source can return candidates along with an error, when it had to fall
+	// back to something stale.
+	Candidates(ctx context.Context, query string) ([]Candidate, error)
+
+	// Dynamic reports whether the candidates depend on the query. Dynamic
+	// sources are asked again as the user types; the rest only once.
+	Dynamic() bool
+}
+
+// parseLines reads one candidate per line, with an optional description
+// after a tab.
+func parseLines(r io.Reader) ([]Candidate, error) {
+	var cands []Candidate
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		name, desc, _ := strings.Cut(s.Text(), "\t")
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		cands = append(cands, Candidate{name, strings.TrimSpace(desc)})
+	}
+	return cands, s.Err()
+}
+
+// fileSource reads candidates from a file, one per line.
+type fileSource struct {
+	path string
+}
+
+func (s fileSource) Name() string  { return s.path }
+func (s fileSource) Dynamic() bool { return false }
+
+func (s fileSource) Candidates(context.Context, string) ([]Candidate, error) {
+	f, err := os.Open(s.path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() //nolint:errcheck
+	return parseLines(f)
+}
+
+// commandSource runs a shell command and reads candidates from its output,
+// one per line. The command gets the query in $QUERY, and if it uses it,
+// it's run again as the user types.
+type commandSource struct {
+	command string
+}
+
+func (s commandSource) Name() string  { return s.command }
+func (s commandSource) Dynamic() bool { return strings.Contains(s.command, "QUERY") }
+
+func (s commandSource) Candidates(ctx context.Context, query string) ([]Candidate, error) {
+	cmd := exec.CommandContext(ctx, "sh", "-c", s.command) //nolint:gosec
+	cmd.Env = append(os.Environ(), "QUERY="+query)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, err
+	}
+	return parseLines(bytes.NewReader(out))
+}
+
+// queryPlaceholder is replaced with the query in an httpSource's URL.
+const queryPlaceholder = "{query}"
+
+// httpSource fetches candidates from a JSON API and keeps the responses on
+// disk, so it keeps working offline.
+type httpSource struct {
+	url    string      // may contain {query}
+	header http.Header // extra request headers
+	client *http.Client
+
+	// items is the path to the list of candidates in the response, like
+	// "items", or empty if the response is the list. nameField and
+	// descField are paths into each item, like "owner.login".
+	items, nameField, descField string
+
+	cacheDir string
+	maxAge   time.Duration // how long a cached response is used as is
+	offline  bool          // only ever use the cache
+}
+
+func (s httpSource) Name() string  { return s.url }
+func (s httpSource) Dynamic() bool { return strings.Contains(s.url, queryPlaceholder) }
+
+// staleError says candidates came from the cache because fetching fresh ones
+// failed.
+type staleError struct {
+	saved time.Time
+	err   error
+}
+
+func (e *staleError) Error() string {
+	return fmt.Sprintf("offline, using results from %s: %v", e.saved.Format(time.DateTime), e.err)
+}
+
+func (e *staleError) Unwrap() error { return e.err }
+
+func (s httpSource) Candidates(ctx context.Context, query string) ([]Candidate, error) {
+	u := strings.ReplaceAll(s.url, queryPlaceholder, url.QueryEscape(query))
+	path := s.cachePath(u)
+
+	cached, saved, cacheErr := readCache(path)
+	if cacheErr == nil && (s.offline || time.Since(saved) < s.maxAge) {
+		return s.parse(cached)
+	}
+	if s.offline {
+		return nil, fmt.Errorf("offline, and nothing cached for %s", u)
+	}
+
+	body, err := s.fetch(ctx, u)
+	if err != nil {
+		if cacheErr != nil {
+			return nil, err
+		}
+		cands, perr := s.parse(cached)
+		if perr != nil {
+			return nil, err
+		}
+		return cands, &staleError{saved, err}
+	}
+	cands, err := s.parse(body)
+	if err != nil {
+		return nil, err
+	}
+	if path != "" {
+		// Not being able to cache isn't worth failing over.
+		_ = writeCache(path, body)
+	}
+	return cands, nil
+}
+
+func (s httpSource) fetch(ctx context.Context, u string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	for k, v := range s.header {
+		req.Header[k] = v
+	}
+	client := s.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", u, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+func (s httpSource) parse(body []byte) ([]Candidate, error) {
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		return nil, err
+	}
+	items, ok := lookup(v, s.items).([]any)
+	if !ok {
+		if s.items == "" {
+			return nil, errors.New("response isn't a list")
+		}
+		return nil, fmt.Errorf("%q in the response isn't a list", s.items)
+	}
+	cands := make([]Candidate, 0, len(items))
+	for _, item := range items {
+		var c Candidate
+		if name, ok := item.(string); ok {
+			c.Name = name
+		} else {
+			c.Name, _ = lookup(item, s.nameField).(string)
+			c.Desc, _ = lookup(item, s.descField).(string)
+		}
+		if c.Name != "" {
+			cands = append(cands, c)
+		}
+	}
+	return cands, nil
+}
+
+// lookup follows a dotted path, like "owner.login", through decoded JSON.
+func lookup(v any, path string) any {
+	if path == "" {
+		return v
+	}
+	for _, k := range strings.Split(path, ".") {
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return nil
+		}
+		v = obj[k]
+	}
+	return v
+}
+
+// cachePath is where the response for a URL is cached, or empty without a
+// cache.
+func (s httpSource) cachePath(u string) string {
+	if s.cacheDir == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(u))
+	return filepath.Join(s.cacheDir, hex.EncodeToString(sum[:8])+".json")
+}
+
+// readCache reads a cached response and when it was saved.
+func readCache(path string) ([]byte, time.Time, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	b, err := os.ReadFile(path)
+	return b, fi.ModTime(), err
+}
+
+func writeCache(path string, body []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil { //nolint:gosec
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, body, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// defaultCacheDir is where responses are cached unless -cache says
+// otherwise.
+func defaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), "bubbletea-autocomplete")
+	}
+	return filepath.Join(dir, "bubbletea-autocomplete")
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestFileSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "repos.txt")
+	if err := os.WriteFile(path, []byte("bubbletea\tA TUI framework\n\n  glow \nlipgloss\tStyle definitions\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	got, err := fileSource{path}.Candidates(t.Context(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Candidate{{"bubbletea", "A TUI framework"}, {"glow", ""}, {"lipgloss", "Style definitions"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestCommandSource(t *testing.T) {
+	s := commandSource{`printf '%s-one\n%s-two\n' "$QUERY" "$QUERY"`}
+	if !s.Dynamic() {
+		t.Error("a command that uses $QUERY isn't dynamic")
+	}
+	got, err := s.Candidates(t.Context(), "x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []Candidate{{"x-one", ""}, {"x-two", ""}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	_, err = commandSource{"echo oops >&2; exit 3"}.Candidates(t.Context(), "")
+	if err == nil || !strings.Contains(err.Error(), "oops") {
+		t.Errorf("error = %v, want one with the command's stderr", err)
+	}
+}
+
+func TestHTTPSource(t *testing.T) {
+	var requests atomic.Int32
+	var down atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.URL.Query().Get("q") != "tea time" {
+			t.Errorf("query = %q", r.URL.Query().Get("q"))
+		}
+		_, _ = w.Write([]byte(`{"items": [
+			{"full_name": "charmbracelet/bubbletea", "owner": {"login": "charmbracelet"}},
+			{"full_name": "charmbracelet/soft-serve", "owner": {"login": "charmbracelet"}},
+			{"owner": {"login": "nameless"}}
+		]}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	s := httpSource{
+		url:       srv.URL + "/search?q={query}",
+		client:    srv.Client(),
+		items:     "items",
+		nameField: "full_name",
+		descField: "owner.login",
+		cacheDir:  t.TempDir(),
+		maxAge:    time.Hour,
+	}
+	want := []Candidate{{"charmbracelet/bubbletea", "charmbracelet"}, {"charmbracelet/soft-serve", "charmbracelet"}}
+	fetch := func(s httpSource) ([]Candidate, error) {
+		t.Helper()
+		return s.Candidates(t.Context(), "tea time")
+	}
+
+	got, err := fetch(s)
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, %v, want %v", got, err, want)
+	}
+
+	// A fresh cache saves a request.
+	if got, err := fetch(s); err != nil || !reflect.DeepEqual(got, want) || requests.Load() != 1 {
+		t.Errorf("cached: got %v, %v after %d requests", got, err, requests.Load())
+	}
+
+	// When the cache is old, fetch again, and fall back to the cache if
+	// that fails.
+	down.Store(true)
+	s.maxAge = 0
+	got, err = fetch(s)
+	var stale *staleError
+	if !errors.As(err, &stale) || !reflect.DeepEqual(got, want) || requests.Load() != 2 {
+		t.Errorf("stale: got %v, %v after %d requests", got, err, requests.Load())
+	}
+
+	// Offline, only the cache is used.
+	s.offline = true
+	if got, err := fetch(s); err != nil || !reflect.DeepEqual(got, want) || requests.Load() != 2 {
+		t.Errorf("offline: got %v, %v after %d requests", got, err, requests.Load())
+	}
+	if _, err := s.Candidates(t.Context(), "never searched"); err == nil {
+		t.Error("offline without a cache succeeded")
+	}
+}
+
+func TestRank(t *testing.T) {
+	cands := []Candidate{{Name: "bubbles"}, {Name: "bubbletea"}, {Name: "glow"}, {Name: "lipgloss"}}
+	if got := rank(cands, "", 2); len(got) != 2 || got[0].Name != "bubbles" {
+		t.Errorf("no query: %v", got)
+	}
+
+	got := rank(cands, "btea", 5)
+	if len(got) != 1 || got[0].Name != "bubbletea" {
+		t.Fatalf("btea: %v", got)
+	}
+	if want := []int{0, 6, 7, 8}; !reflect.DeepEqual(got[0].matched, want) {
+		t.Errorf("matched %v, want %v", got[0].matched, want)
+	}
+
+	if got := rank(cands, "gl", 5); len(got) != 2 || got[0].Name != "glow" {
+		t.Errorf("gl: %v, want glow first", got)
+	}
+}
+
+func TestDebounce(t *testing.T) {
+	m := initialModel(commandSource{`echo "$QUERY-result"`}, 5, time.Millisecond)
+	update := func(msg tea.Msg) tea.Cmd {
+		t.Helper()
+		next, cmd := m.Update(msg)
+		m = next.(model)
+		return cmd
+	}
+
+	// Nothing's fetched for an empty query.
+	if cmd := update(refreshMsg{0}); cmd != nil || m.loading() {
+		t.Fatal("fetched with an empty query")
+	}
+
+	// Typing twice quickly only fetches for the second one.
+	update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'a'}})
+	update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'b'}})
+	if cmd := update(refreshMsg{1}); cmd != nil {
+		t.Error("refreshed for a query that's since changed")
+	}
+	cmd := update(refreshMsg{2})
+	if cmd == nil || !m.loading() {
+		t.Fatal("didn't refresh after typing stopped")
+	}
+	update(cmd())
+	if m.loading() || len(m.matches) != 1 || m.matches[0].Name != "ab-result" {
+		t.Errorf("matches = %v", m.matches)
+	}
+
+	// An answer to an older query is dropped.
+	update(gotCandidatesMsg{seq: 1, cands: []Candidate{{Name: "a-result"}}})
+	if len(m.matches) != 1 || m.matches[0].Name != "ab-result" {
+		t.Errorf("an old answer replaced a newer one: %v", m.matches)
+	}
+
+	update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.chosen != "ab-result" {
+		t.Errorf("chose %q", m.chosen)
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"math"
+	"slices"
+	"unicode/utf8"
+)
+
+// plotter is something to draw on, a pixel at a time. A cellbuffer has a
+// pixel to a cell, and braille on top of one has 2x4.
+type plotter interface {
+	set(x, y int)
+	width() int
+	height() int
+}
+
+type point struct {
+	x, y int
+}
+
+// drawLine draws a line between two points with Bresenham's algorithm.
+func drawLine(p plotter, x0, y0, x1, y1 int) {
+	dx, sx := abs(x1-x0), 1
+	if x0 > x1 {
+		sx = -1
+	}
+	dy, sy := -abs(y1-y0), 1
+	if y0 > y1 {
+		sy = -1
+	}
+
+	err := dx + dy
+	for {
+		p.set(x0, y0)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y0 += sy
+		}
+	}
+}
+
+// drawRect draws the outline of a rectangle.
+func drawRect(p plotter, x, y, w, h int) {
+	if w <= 0 || h <= 0 {
+		return
+	}
+	drawPolygon(p, []point{{x, y}, {x + w - 1, y}, {x + w - 1, y + h - 1}, {x, y + h - 1}})
+}
+
+// drawPolygon draws the outline of a polygon.
+func drawPolygon(p plotter, pts []point) {
+	for i, a := range pts {
+		b := pts[(i+1)%len(pts)]
+		drawLine(p, a.x, a.y, b.x, b.y)
+	}
+}
+
+// fillPolygon fills in a polygon, using the even-odd rule. A pixel's filled
+// if its middle is inside the polygon, so polygons that share an edge don't
+// overlap.
+func fillPolygon(p plotter, pts []point) {
+	if len(pts) < 3 {
+		return
+	}
+	top, bottom := pts[0].y, pts[0].y
+	for _, pt := range pts {
+		top, bottom = min(top, pt.y), max(bottom, pt.y)
+	}
+
+	var xs []float64
+	for y := max(top, 0); y < min(bottom, p.height()); y++ {
+		// Find where the middle of this row crosses the edges, and fill
+		// between pairs of crossings.
+		mid := float64(y) + 0.5
+		xs = xs[:0]
+		for i, a := range pts {
+			b := pts[(i+1)%len(pts)]
+			if (float64(a.y) <= mid) == (float64(b.y) <= mid) {
+				continue
+			}
+			t := (mid - float64(a.y)) / float64(b.y-a.y)
+			xs = append(xs, float64(a.x)+t*float64(b.x-a.x))
+		}
+		slices.Sort(xs)
+		for i := 0; i+1 < len(xs); i += 2 {
+			from := int(math.Ceil(xs[i] - 0.5))
+			to := int(math.Ceil(xs[i+1] - 0.5))
+			for x := max(from, 0); x < min(to, p.width()); x++ {
+				p.set(x, y)
+			}
+		}
+	}
+}
+
+// drawText writes text into the cells, starting at x, y. Text always goes a
+// rune to a cell, whatever it's drawn next to.
+func drawText(c cellbuffer, x, y int, s string) {
+	for _, r := range s {
+		c.put(x, y, string(r))
+		x++
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// braille draws on a cellbuffer with braille patterns, which have 2x4 dots
+// to a cell.
+type braille struct {
+	cells cellbuffer
+}
+
+// brailleDots are the bits for each dot in a braille pattern, by row and
+// column.
+var brailleDots = [4][2]rune{
+	{0x01, 0x08},
+	{0x02, 0x10},
+	{0x04, 0x20},
+	{0x40, 0x80},
+}
+
+const brailleBlank = 0x2800
+
+func (b braille) set(x, y int) {
+	if x < 0 || y < 0 || x >= b.width() || y >= b.height() {
+		return
+	}
+	cx, cy := x/2, y/4
+	r, _ := utf8.DecodeRuneInString(b.cells.get(cx, cy))
+	if !isBraille(r) {
+		r = brailleBlank
+	}
+	b.cells.put(cx, cy, string(r|brailleDots[y%4][x%2]))
+}
+
+func (b braille) width() int {
+	return b.cells.width() * 2
+}
+
+func (b braille) height() int {
+	return b.cells.height() * 4
+}
+
+func isBraille(r rune) bool {
+	return r >= brailleBlank && r <= brailleBlank+0xff
+}
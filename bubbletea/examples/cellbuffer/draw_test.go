@@ -0,0 +1,129 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func newCells(w, h int) cellbuffer {
+	var c cellbuffer
+	c.init(w, h)
+	return c
+}
+
+func text(t *testing.T, c cellbuffer) string {
+	t.Helper()
+	var b strings.Builder
+	if err := c.writeText(&b); err != nil {
+		t.Fatal(err)
+	}
+	return b.String()
+}
+
+func TestShapes(t *testing.T) {
+	tests := []struct {
+		name string
+		draw func(c cellbuffer)
+		want string
+	}{
+		{"line", func(c cellbuffer) { drawLine(c, 0, 0, 7, 2) }, "" +
+			"**\n" +
+			"  ****\n" +
+			"      **\n"},
+		{"backwards line", func(c cellbuffer) { drawLine(c, 7, 2, 0, 0) }, "" +
+			"**\n" +
+			"  ****\n" +
+			"      **\n"},
+		{"steep line", func(c cellbuffer) { drawLine(c, 1, 0, 0, 2) }, "" +
+			" *\n" +
+			"*\n" +
+			"*\n"},
+		{"rectangle", func(c cellbuffer) { drawRect(c, 1, 0, 4, 3) }, "" +
+			" ****\n" +
+			" *  *\n" +
+			" ****\n"},
+		{"clipped", func(c cellbuffer) { drawRect(c, -2, 1, 20, 5) }, "" +
+			"\n" +
+			"********\n" +
+			"\n"},
+		{"triangle", func(c cellbuffer) { fillPolygon(c, []point{{0, 0}, {6, 3}, {0, 3}}) }, "" +
+			"*\n" +
+			"***\n" +
+			"*****\n"},
+		{"text", func(c cellbuffer) { drawText(c, 6, 1, "hello") }, "" +
+			"\n" +
+			"      he\n" +
+			"\n"},
+	}
+	for _, tt := range tests {
+		c := newCells(8, 3)
+		tt.draw(c)
+		if got := text(t, c); got != tt.want {
+			t.Errorf("%s:\n%s\nwant:\n%s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFillPolygonEvenOdd(t *testing.T) {
+	// A square with a square hole in it, drawn as one polygon.
+	c := newCells(6, 6)
+	fillPolygon(c, []point{
+		{0, 0}, {6, 0}, {6, 6}, {0, 6}, {0, 0},
+		{2, 2}, {2, 4}, {4, 4}, {4, 2}, {2, 2},
+	})
+	want := "" +
+		"******\n" +
+		"******\n" +
+		"**  **\n" +
+		"**  **\n" +
+		"******\n" +
+		"******\n"
+	if got := text(t, c); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestBraille(t *testing.T) {
+	c := newCells(2, 1)
+	b := braille{c}
+	if b.width() != 4 || b.height() != 4 {
+		t.Fatalf("braille is %dx%d", b.width(), b.height())
+	}
+	drawLine(b, 0, 0, 3, 3)
+	if got := text(t, c); got != "⠑⢄\n" {
+		t.Errorf("got %q", got)
+	}
+
+	// Drawing over text starts a fresh pattern.
+	drawText(c, 0, 0, "x")
+	b.set(1, 3)
+	if got := text(t, c); got != "⢀⢄\n" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestSVG(t *testing.T) {
+	c := newCells(3, 1)
+	drawText(c, 0, 0, "<")
+	braille{c}.set(2, 0)
+	braille{c}.set(5, 3)
+
+	var b strings.Builder
+	if err := c.writeSVG(&b); err != nil {
+		t.Fatal(err)
+	}
+	svg := b.String()
+	for _, want := range []string{
+		`width="24" height="16"`,
+		`<text x="0" y="12">&lt;</text>`,
+		`<circle cx="10" cy="2" r="1.5"/>`,
+		`<circle cx="22" cy="14" r="1.5"/>`,
+	} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("missing %s in:\n%s", want, svg)
+		}
+	}
+	if n := strings.Count(svg, "<circle"); n != 2 {
+		t.Errorf("%d dots, want 2", n)
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// The size of a cell in exported SVGs, in pixels.
+const (
+	svgCellWidth  = 8
+	svgCellHeight = 16
+)
+
+// writeText writes a frame out as plain text, without trailing spaces.
+func (c cellbuffer) writeText(w io.Writer) error {
+	var b strings.Builder
+	for y := range c.height() {
+		var line strings.Builder
+		for x := range c.width() {
+			line.WriteString(c.get(x, y))
+		}
+		b.WriteString(strings.TrimRight(line.String(), " "))
+		b.WriteByte('\n')
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeSVG writes a frame out as an SVG image. Braille dots are drawn as
+// dots, and everything else as text.
+func (c cellbuffer) writeSVG(w io.Writer) error {
+	var b strings.Builder
+	width, height := c.width()*svgCellWidth, c.height()*svgCellHeight
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n",
+		width, height, width, height)
+	b.WriteString(`<rect width="100%" height="100%" fill="white"/>` + "\n")
+	b.WriteString(`<g fill="black" font-family="monospace" font-size="13">` + "\n")
+
+	dotWidth, dotHeight := svgCellWidth/2, svgCellHeight/4
+	for y := range c.height() {
+		for x := range c.width() {
+			s := c.get(x, y)
+			if strings.TrimSpace(s) == "" {
+				continue
+			}
+			r, _ := utf8.DecodeRuneInString(s)
+			if !isBraille(r) {
+				fmt.Fprintf(&b, `<text x="%d" y="%d">`, x*svgCellWidth, y*svgCellHeight+svgCellHeight*3/4)
+				xml.EscapeText(&b, []byte(s)) //nolint:errcheck
+				b.WriteString("</text>\n")
+				continue
+			}
+			for row, dots := range brailleDots {
+				for col, dot := range dots {
+					if r&dot == 0 {
+						continue
+					}
+					fmt.Fprintf(&b, `<circle cx="%d" cy="%d" r="1.5"/>`+"\n",
+						x*svgCellWidth+col*dotWidth+dotWidth/2,
+						y*svgCellHeight+row*dotHeight+dotHeight/2)
+				}
+			}
+		}
+	}
+
+	b.WriteString("</g>\n</svg>\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
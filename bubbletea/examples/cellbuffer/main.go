@@ -3,8 +3,12 @@ package main
 // A simple example demonstrating how to draw and animate on a cellular grid.
 // Note that the cellbuffer implementation in this example does not support
 // double-width runes.
+//
+// Press b to draw with braille, which has 2x4 dots to a cell, and s to save
+// the frame as text and SVG.
 
 import (
+	"flag"
 	"fmt"
 	"os"
 	"strings"
@@ -21,7 +25,7 @@ const (
 	asterisk  = "*"
 )
 
-func drawEllipse(cb *cellbuffer, xc, yc, rx, ry float64) {
+func drawEllipse(cb plotter, xc, yc, rx, ry float64) {
 	var (
 		dx, dy, d1, d2 float64
 		x              float64
@@ -86,11 +90,23 @@ func (c *cellbuffer) init(w, h int) {
 }
 
 func (c cellbuffer) set(x, y int) {
+	c.put(x, y, asterisk)
+}
+
+func (c cellbuffer) put(x, y int, s string) {
 	i := y*c.stride + x
 	if i > len(c.cells)-1 || x < 0 || y < 0 || x >= c.width() || y >= c.height() {
 		return
 	}
-	c.cells[i] = asterisk
+	c.cells[i] = s
+}
+
+func (c cellbuffer) get(x, y int) string {
+	i := y*c.stride + x
+	if i > len(c.cells)-1 || x < 0 || y < 0 || x >= c.width() || y >= c.height() {
+		return ""
+	}
+	return c.cells[i]
 }
 
 func (c *cellbuffer) wipe() {
@@ -140,6 +156,10 @@ type model struct {
 	targetX, targetY     float64
 	x, y                 float64
 	xVelocity, yVelocity float64
+
+	braille bool
+	out     string // where to save frames, without the extension
+	status  string
 }
 
 func (m model) Init() tea.Cmd {
@@ -149,6 +169,14 @@ func (m model) Init() tea.Cmd {
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		switch msg.String() {
+		case "b":
+			m.braille = !m.braille
+			return m, nil
+		case "s":
+			m.status = m.save()
+			return m, nil
+		}
 		return m, tea.Quit
 	case tea.WindowSizeMsg:
 		if !m.cells.ready() {
@@ -168,23 +196,77 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 
-		m.cells.wipe()
 		m.x, m.xVelocity = m.spring.Update(m.x, m.xVelocity, m.targetX)
 		m.y, m.yVelocity = m.spring.Update(m.y, m.yVelocity, m.targetY)
-		drawEllipse(&m.cells, m.x, m.y, 16, 8)
+		m.draw()
 		return m, animate()
 	default:
 		return m, nil
 	}
 }
 
+// draw draws a frame: the ellipse, a line to where it's heading with a
+// diamond there, and a border with some help.
+func (m model) draw() {
+	m.cells.wipe()
+
+	// Braille has more pixels, so we scale everything up to match.
+	var p plotter = m.cells
+	sx, sy := 1.0, 1.0
+	if m.braille {
+		p = braille{m.cells}
+		sx, sy = 2, 4
+	}
+
+	drawRect(p, 0, 0, p.width(), p.height())
+	drawEllipse(p, m.x*sx, m.y*sy, 16*sx, 8*sy)
+	x, y := int(m.x*sx), int(m.y*sy)
+	tx, ty := int(m.targetX*sx), int(m.targetY*sy)
+	drawLine(p, x, y, tx, ty)
+	dx, dy := int(3*sx), int(2*sy)
+	fillPolygon(p, []point{{tx, ty - dy}, {tx + dx, ty}, {tx, ty + dy}, {tx - dx, ty}})
+
+	drawText(m.cells, 2, 0, " b: braille • s: save • any other key: quit ")
+	if m.status != "" {
+		drawText(m.cells, 2, m.cells.height()-1, " "+m.status+" ")
+	}
+}
+
+// save writes the frame out as text and SVG.
+func (m model) save() string {
+	for _, f := range []struct {
+		ext   string
+		write func(*os.File) error
+	}{
+		{".txt", func(f *os.File) error { return m.cells.writeText(f) }},
+		{".svg", func(f *os.File) error { return m.cells.writeSVG(f) }},
+	} {
+		file, err := os.Create(m.out + f.ext)
+		if err != nil {
+			return err.Error()
+		}
+		err = f.write(file)
+		if cerr := file.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return err.Error()
+		}
+	}
+	return fmt.Sprintf("Saved %[1]s.txt and %[1]s.svg", m.out)
+}
+
 func (m model) View() string {
 	return m.cells.String()
 }
 
 func main() {
+	out := flag.String("out", "frame", "where to save frames, without the extension")
+	flag.Parse()
+
 	m := model{
 		spring: harmonica.NewSpring(harmonica.FPS(fps), frequency, damping),
+		out:    *out,
 	}
 
 	p := tea.NewProgram(m, tea.WithAltScreen(), tea.WithMouseCellMotion())
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// brand is a card network, and how its cards look.
+type brand struct {
+	name     string
+	prefixes []prefixRange
+	lengths  []int // valid numbers of digits
+	groups   []int // how the digits are grouped for display
+	cvvLen   int
+}
+
+// prefixRange is a range of card number prefixes, like 51-55 or 2221-2720.
+type prefixRange struct {
+	lo, hi int
+}
+
+func (r prefixRange) digits() int {
+	return len(strconv.Itoa(r.lo))
+}
+
+var (
+	visa = brand{
+		name:     "Visa",
+		prefixes: []prefixRange{{4, 4}},
+		lengths:  []int{13, 16, 19},
+		groups:   []int{4, 4, 4, 4, 3},
+		cvvLen:   3,
+	}
+	mastercard = brand{
+		name:     "Mastercard",
+		prefixes: []prefixRange{{51, 55}, {2221, 2720}},
+		lengths:  []int{16},
+		groups:   []int{4, 4, 4, 4},
+		cvvLen:   3,
+	}
+	amex = brand{
+		name:     "American Express",
+		prefixes: []prefixRange{{34, 34}, {37, 37}},
+		lengths:  []int{15},
+		groups:   []int{4, 6, 5},
+		cvvLen:   4,
+	}
+	discover = brand{
+		name:     "Discover",
+		prefixes: []prefixRange{{6011, 6011}, {644, 649}, {65, 65}},
+		lengths:  []int{16, 19},
+		groups:   []int{4, 4, 4, 4, 3},
+		cvvLen:   3,
+	}
+	diners = brand{
+		name:     "Diners Club",
+		prefixes: []prefixRange{{300, 305}, {36, 36}, {38, 39}},
+		lengths:  []int{14},
+		groups:   []int{4, 6, 4},
+		cvvLen:   3,
+	}
+	jcb = brand{
+		name:     "JCB",
+		prefixes: []prefixRange{{3528, 3589}},
+		lengths:  []int{16},
+		groups:   []int{4, 4, 4, 4},
+		cvvLen:   3,
+	}
+
+	// unknown is for numbers we can't place yet.
+	unknown = brand{
+		lengths: []int{19},
+		groups:  []int{4, 4, 4, 4, 3},
+		cvvLen:  3,
+	}
+
+	brands = []brand{visa, mastercard, amex, discover, diners, jcb}
+)
+
+// detectBrand works out the brand from the first few digits of a number.
+func detectBrand(digits string) brand {
+	for _, b := range brands {
+		for _, r := range b.prefixes {
+			n := r.digits()
+			if len(digits) < n {
+				continue
+			}
+			p, _ := strconv.Atoi(digits[:n])
+			if p >= r.lo && p <= r.hi {
+				return b
+			}
+		}
+	}
+	return unknown
+}
+
+func (b brand) maxLen() int {
+	return slices.Max(b.lengths)
+}
+
+// luhn checks a card number's check digit.
+func luhn(digits string) bool {
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// onlyDigits drops everything but digits.
+func onlyDigits(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// group splits digits up into the brand's groups, separated by spaces.
+func (b brand) group(digits string) string {
+	rest := []rune(digits)
+	var parts []string
+	for _, n := range b.groups {
+		if len(rest) == 0 {
+			break
+		}
+		n = min(n, len(rest))
+		parts = append(parts, string(rest[:n]))
+		rest = rest[n:]
+	}
+	if len(rest) > 0 {
+		parts = append(parts, string(rest))
+	}
+	return strings.Join(parts, " ")
+}
+
+// mask hides all but the last four digits, keeping the grouping.
+func (b brand) mask(digits string) string {
+	keep := max(0, len(digits)-4)
+	return b.group(strings.Repeat("•", keep) + digits[keep:])
+}
+
+func validateNumber(digits string) error {
+	if digits == "" {
+		return errors.New("enter the card number")
+	}
+	b := detectBrand(digits)
+	if b.name == "" {
+		return errors.New("we don't take this kind of card")
+	}
+	if !slices.Contains(b.lengths, len(digits)) {
+		return fmt.Errorf("%s numbers have %s digits", b.name, orList(b.lengths))
+	}
+	if !luhn(digits) {
+		return errors.New("this card number isn't valid, check for typos")
+	}
+	return nil
+}
+
+// parseExpiry reads an MM/YY expiry date.
+func parseExpiry(s string) (month time.Month, year int, err error) {
+	mm, yy, ok := strings.Cut(s, "/")
+	if !ok || len(mm) != 2 || len(yy) != 2 {
+		return 0, 0, errors.New("enter the expiry date as MM/YY")
+	}
+	m, err := strconv.Atoi(mm)
+	if err != nil || m < 1 || m > 12 {
+		return 0, 0, errors.New("the month has to be 01 to 12")
+	}
+	y, err := strconv.Atoi(yy)
+	if err != nil {
+		return 0, 0, errors.New("enter the expiry date as MM/YY")
+	}
+	return time.Month(m), 2000 + y, nil
+}
+
+// maxCardLife is how far in the future an expiry date can be.
+const maxCardLife = 20
+
+// validateExpiry checks that a card hasn't expired. Cards are good through
+// the end of the month they expire in.
+func validateExpiry(s string, now time.Time) error {
+	month, year, err := parseExpiry(s)
+	if err != nil {
+		return err
+	}
+	end := time.Date(year, month+1, 1, 0, 0, 0, 0, now.Location())
+	switch {
+	case !now.Before(end):
+		return errors.New("this card has expired")
+	case year > now.Year()+maxCardLife:
+		return errors.New("that's too far in the future")
+	}
+	return nil
+}
+
+func validateCVV(s string, b brand) error {
+	if len(s) != b.cvvLen {
+		return fmt.Errorf("the security code has %d digits", b.cvvLen)
+	}
+	return nil
+}
+
+// orList writes numbers like "13, 16 or 19".
+func orList(ns []int) string {
+	s := make([]string, len(ns))
+	for i, n := range ns {
+		s[i] = strconv.Itoa(n)
+	}
+	if len(s) == 1 {
+		return s[0]
+	}
+	return strings.Join(s[:len(s)-1], ", ") + " or " + s[len(s)-1]
+}
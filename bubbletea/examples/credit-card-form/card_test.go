@@ -0,0 +1,167 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestValidateNumber(t *testing.T) {
+	tests := []struct {
+		number string
+		brand  string
+		ok     bool
+	}{
+		{"4111111111111111", "Visa", true},
+		{"4111111111111112", "Visa", false}, // bad check digit
+		{"411111111111111", "Visa", false},  // too short
+		{"5555555555554444", "Mastercard", true},
+		{"2223000048400011", "Mastercard", true},
+		{"378282246310005", "American Express", true},
+		{"6011111111111117", "Discover", true},
+		{"30569309025904", "Diners Club", true},
+		{"3530111333300000", "JCB", true},
+		{"9111111111111111", "", false},
+	}
+	for _, tt := range tests {
+		if got := detectBrand(tt.number).name; got != tt.brand {
+			t.Errorf("detectBrand(%s) = %q, want %q", tt.number, got, tt.brand)
+		}
+		if err := validateNumber(tt.number); (err == nil) != tt.ok {
+			t.Errorf("validateNumber(%s) = %v", tt.number, err)
+		}
+	}
+}
+
+func TestGroup(t *testing.T) {
+	tests := []struct {
+		digits, want string
+	}{
+		{"4111", "4111"},
+		{"41111", "4111 1"},
+		{"4111111111111111", "4111 1111 1111 1111"},
+		{"378282246310005", "3782 822463 10005"},
+		{"30569309025904", "3056 930902 5904"},
+	}
+	for _, tt := range tests {
+		if got := detectBrand(tt.digits).group(tt.digits); got != tt.want {
+			t.Errorf("group(%s) = %q, want %q", tt.digits, got, tt.want)
+		}
+	}
+	if got := amex.mask("378282246310005"); got != "•••• •••••• •0005" {
+		t.Errorf("mask = %q", got)
+	}
+}
+
+func TestValidateExpiry(t *testing.T) {
+	now := time.Date(2025, time.March, 31, 23, 59, 0, 0, time.UTC)
+	tests := []struct {
+		exp string
+		ok  bool
+	}{
+		{"03/25", true}, // good through the end of the month
+		{"02/25", false},
+		{"04/25", true},
+		{"12/45", true},
+		{"01/46", false}, // too far off
+		{"13/25", false},
+		{"0325", false},
+		{"3/25", false},
+	}
+	for _, tt := range tests {
+		if err := validateExpiry(tt.exp, now); (err == nil) != tt.ok {
+			t.Errorf("validateExpiry(%s) = %v", tt.exp, err)
+		}
+	}
+	if err := validateExpiry("03/25", now.Add(time.Minute)); err == nil {
+		t.Error("the card didn't expire at the end of the month")
+	}
+}
+
+func typeIn(m model, s string) model {
+	for _, r := range s {
+		next, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		m = next.(model)
+	}
+	return m
+}
+
+func press(m model, k tea.KeyType) (model, tea.Cmd) {
+	next, cmd := m.Update(tea.KeyMsg{Type: k})
+	return next.(model), cmd
+}
+
+func TestForm(t *testing.T) {
+	m := initialModel(func() time.Time {
+		return time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)
+	})
+
+	// Anything that isn't a digit is dropped, and the number's grouped for
+	// its brand.
+	m = typeIn(m, "3782-8224-6310-0059")
+	if got := m.inputs[ccn].Value(); got != "3782 822463 10005" || m.brand.name != "American Express" {
+		t.Fatalf("number = %q, brand %q", got, m.brand.name)
+	}
+	if m.inputs[cvv].CharLimit != 4 {
+		t.Errorf("Amex security codes are 4 digits, allowed %d", m.inputs[cvv].CharLimit)
+	}
+
+	// Editing in the middle keeps the cursor on the same digit.
+	m.inputs[ccn].SetCursor(4)
+	m, _ = press(m, tea.KeyBackspace)
+	if got := m.inputs[ccn].Value(); got != "3788 224631 0005" || m.inputs[ccn].Position() != 3 {
+		t.Errorf("after backspace: %q, cursor at %d", got, m.inputs[ccn].Position())
+	}
+	m = typeIn(m, "2")
+	if got := m.inputs[ccn].Value(); got != "3782 822463 10005" || m.inputs[ccn].Position() != 4 {
+		t.Errorf("after typing: %q, cursor at %d", got, m.inputs[ccn].Position())
+	}
+
+	// Leaving the field masks it.
+	m, _ = press(m, tea.KeyTab)
+	if v := m.View(); !strings.Contains(v, "•••• •••••• •0005") || strings.Contains(v, "822463") {
+		t.Errorf("the number wasn't masked:\n%s", v)
+	}
+
+	m = typeIn(m, "0224")
+	if got := m.inputs[exp].Value(); got != "02/24" {
+		t.Errorf("expiry = %q", got)
+	}
+	m, _ = press(m, tea.KeyTab)
+	m = typeIn(m, "12345")
+	if got := m.inputs[cvv].Value(); got != "1234" {
+		t.Errorf("cvv = %q", got)
+	}
+
+	// The card's expired, so we go back to the expiry date.
+	m, _ = press(m, tea.KeyEnter)
+	if m.confirming || m.focused != exp {
+		t.Fatalf("confirming %v, focused %d", m.confirming, m.focused)
+	}
+	if v := m.View(); strings.Contains(v, "expired") {
+		t.Errorf("showed an error for the field being edited:\n%s", v)
+	}
+	for range 5 {
+		m, _ = press(m, tea.KeyBackspace)
+	}
+	m = typeIn(m, "0327")
+	m, _ = press(m, tea.KeyShiftTab)
+	if v := m.View(); strings.Contains(v, "✗") {
+		t.Errorf("unexpected errors:\n%s", v)
+	}
+
+	m.focused = cvv
+	m, _ = press(m, tea.KeyEnter)
+	if !m.confirming || !strings.Contains(m.View(), "Pay with American Express") {
+		t.Fatalf("didn't get to the summary:\n%s", m.View())
+	}
+	m, cmd := press(m, tea.KeyEnter)
+	if !m.paid || cmd == nil {
+		t.Error("didn't pay")
+	}
+	if v := m.View(); !strings.Contains(v, "ending in 0005") {
+		t.Errorf("after paying:\n%s", v)
+	}
+}
@@ -3,8 +3,8 @@ package main
 import (
 	"fmt"
 	"log"
-	"strconv"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
@@ -12,7 +12,7 @@ import (
 )
 
 func main() {
-	p := tea.NewProgram(initialModel())
+	p := tea.NewProgram(initialModel(time.Now))
 
 	if _, err := p.Run(); err != nil {
 		log.Fatal(err)
@@ -32,97 +32,63 @@ const (
 const (
 	hotPink  = lipgloss.Color("#FF06B7")
 	darkGray = lipgloss.Color("#767676")
+	red      = lipgloss.Color("#FF5F87")
 )
 
+const total = "$21.50"
+
 var (
 	inputStyle    = lipgloss.NewStyle().Foreground(hotPink)
 	continueStyle = lipgloss.NewStyle().Foreground(darkGray)
+	errorStyle    = lipgloss.NewStyle().Foreground(red)
+	brandStyle    = lipgloss.NewStyle().Foreground(darkGray).Italic(true)
 )
 
 type model struct {
 	inputs  []textinput.Model
 	focused int
 	err     error
-}
-
-// Validator functions to ensure valid input
-func ccnValidator(s string) error {
-	// Credit Card Number should a string less than 20 digits
-	// It should include 16 integers and 3 spaces
-	if len(s) > 16+3 {
-		return fmt.Errorf("CCN is too long")
-	}
-
-	if len(s) == 0 || len(s)%5 != 0 && (s[len(s)-1] < '0' || s[len(s)-1] > '9') {
-		return fmt.Errorf("CCN is invalid")
-	}
-
-	// The last digit should be a number unless it is a multiple of 4 in which
-	// case it should be a space
-	if len(s)%5 == 0 && s[len(s)-1] != ' ' {
-		return fmt.Errorf("CCN must separate groups with spaces")
-	}
-
-	// The remaining digits should be integers
-	c := strings.ReplaceAll(s, " ", "")
-	_, err := strconv.ParseInt(c, 10, 64)
 
-	return err
+	// now tells the time, for checking expiry dates. Tests set it.
+	now func() time.Time
+	// brand is the brand of the card number typed so far.
+	brand brand
+	// touched is the fields the user has been in and left, which show
+	// their errors.
+	touched []bool
+	// confirming is set on the summary screen, before paying.
+	confirming bool
+	paid       bool
 }
 
-func expValidator(s string) error {
-	// The 3 character should be a slash (/)
-	// The rest should be numbers
-	e := strings.ReplaceAll(s, "/", "")
-	_, err := strconv.ParseInt(e, 10, 64)
-	if err != nil {
-		return fmt.Errorf("EXP is invalid")
-	}
-
-	// There should be only one slash and it should be in the 2nd index (3rd character)
-	if len(s) >= 3 && (strings.Index(s, "/") != 2 || strings.LastIndex(s, "/") != 2) {
-		return fmt.Errorf("EXP is invalid")
-	}
-
-	return nil
-}
-
-func cvvValidator(s string) error {
-	// The CVV should be a number of 3 digits
-	// Since the input will already ensure that the CVV is a string of length 3,
-	// All we need to do is check that it is a number
-	_, err := strconv.ParseInt(s, 10, 64)
-	return err
-}
-
-func initialModel() model {
+func initialModel(now func() time.Time) model {
 	var inputs []textinput.Model = make([]textinput.Model, 3)
 	inputs[ccn] = textinput.New()
 	inputs[ccn].Placeholder = "4505 **** **** 1234"
 	inputs[ccn].Focus()
-	inputs[ccn].CharLimit = 20
+	inputs[ccn].CharLimit = 24
 	inputs[ccn].Width = 30
 	inputs[ccn].Prompt = ""
-	inputs[ccn].Validate = ccnValidator
 
 	inputs[exp] = textinput.New()
 	inputs[exp].Placeholder = "MM/YY "
 	inputs[exp].CharLimit = 5
 	inputs[exp].Width = 5
 	inputs[exp].Prompt = ""
-	inputs[exp].Validate = expValidator
 
 	inputs[cvv] = textinput.New()
 	inputs[cvv].Placeholder = "XXX"
 	inputs[cvv].CharLimit = 3
 	inputs[cvv].Width = 5
 	inputs[cvv].Prompt = ""
-	inputs[cvv].Validate = cvvValidator
 
 	return model{
 		inputs:  inputs,
 		focused: 0,
 		err:     nil,
+		now:     now,
+		brand:   unknown,
+		touched: make([]bool, len(inputs)),
 	}
 }
 
@@ -135,13 +101,21 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if msg.Type == tea.KeyCtrlC {
+			return m, tea.Quit
+		}
+		if m.confirming {
+			return m.updateSummary(msg)
+		}
+
 		switch msg.Type {
 		case tea.KeyEnter:
 			if m.focused == len(m.inputs)-1 {
-				return m, tea.Quit
+				m.submit()
+				break
 			}
 			m.nextInput()
-		case tea.KeyCtrlC, tea.KeyEsc:
+		case tea.KeyEsc:
 			return m, tea.Quit
 		case tea.KeyShiftTab, tea.KeyCtrlP:
 			m.prevInput()
@@ -162,38 +136,185 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	for i := range m.inputs {
 		m.inputs[i], cmds[i] = m.inputs[i].Update(msg)
 	}
+	m.normalize()
 	return m, tea.Batch(cmds...)
 }
 
+func (m model) updateSummary(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEnter:
+		m.paid = true
+		return m, tea.Quit
+	case tea.KeyEsc, tea.KeyBackspace:
+		m.confirming = false
+	}
+	return m, nil
+}
+
+// submit moves on to the summary if everything checks out, or else to the
+// first field that doesn't.
+func (m *model) submit() {
+	for i := range m.inputs {
+		m.touched[i] = true
+	}
+	for i := range m.inputs {
+		if m.fieldErr(i) != nil {
+			m.focused = i
+			return
+		}
+	}
+	m.confirming = true
+}
+
+// normalize keeps each field to digits, grouped the way the card's brand
+// groups them, and the expiry date as MM/YY.
+func (m *model) normalize() {
+	digits := onlyDigits(m.inputs[ccn].Value())
+	m.brand = detectBrand(digits)
+	digits = digits[:min(len(digits), m.brand.maxLen())]
+	reformat(&m.inputs[ccn], m.brand.group(digits))
+
+	digits = onlyDigits(m.inputs[exp].Value())
+	digits = digits[:min(len(digits), 4)]
+	if len(digits) > 2 {
+		digits = digits[:2] + "/" + digits[2:]
+	}
+	reformat(&m.inputs[exp], digits)
+
+	m.inputs[cvv].CharLimit = m.brand.cvvLen
+	m.inputs[cvv].Placeholder = strings.Repeat("X", m.brand.cvvLen)
+	digits = onlyDigits(m.inputs[cvv].Value())
+	digits = digits[:min(len(digits), m.brand.cvvLen)]
+	reformat(&m.inputs[cvv], digits)
+}
+
+// reformat sets an input's value, keeping the cursor after the same digit.
+func reformat(ti *textinput.Model, formatted string) {
+	value := []rune(ti.Value())
+	if string(value) == formatted {
+		return
+	}
+	before := len(onlyDigits(string(value[:min(ti.Position(), len(value))])))
+	ti.SetValue(formatted)
+
+	pos := 0
+	for i, r := range []rune(formatted) {
+		if before == 0 {
+			break
+		}
+		if r >= '0' && r <= '9' {
+			before--
+		}
+		pos = i + 1
+	}
+	ti.SetCursor(pos)
+}
+
+// fieldErr checks a field.
+func (m model) fieldErr(i int) error {
+	value := m.inputs[i].Value()
+	switch i {
+	case ccn:
+		return validateNumber(onlyDigits(value))
+	case exp:
+		return validateExpiry(value, m.now())
+	default:
+		return validateCVV(value, m.brand)
+	}
+}
+
+// fieldView shows a field, with the card number masked when we're not in
+// it.
+func (m model) fieldView(i int) string {
+	if i == ccn && !m.inputs[i].Focused() && m.inputs[i].Value() != "" {
+		return m.brand.mask(onlyDigits(m.inputs[i].Value()))
+	}
+	return m.inputs[i].View()
+}
+
+// errView shows what's wrong with the fields the user has left.
+func (m model) errView(fields ...int) string {
+	var errs []string
+	for _, i := range fields {
+		if !m.touched[i] || m.inputs[i].Focused() {
+			continue
+		}
+		if err := m.fieldErr(i); err != nil {
+			errs = append(errs, errorStyle.Render("✗ "+err.Error()))
+		}
+	}
+	return strings.Join(errs, "\n ")
+}
+
 func (m model) View() string {
+	if m.paid {
+		return fmt.Sprintf(" Paid %s with %s ending in %s. Thank you!\n",
+			total, m.brand.name, last4(m.inputs[ccn].Value()))
+	}
+	if m.confirming {
+		return m.summaryView()
+	}
+
 	return fmt.Sprintf(
-		` Total: $21.50:
+		` Total: %s:
 
+ %s%s
  %s
  %s
 
  %s  %s
  %s  %s
+ %s
 
  %s
 `,
+		total,
 		inputStyle.Width(30).Render("Card Number"),
-		m.inputs[ccn].View(),
+		brandStyle.Render(m.brand.name),
+		m.fieldView(ccn),
+		m.errView(ccn),
 		inputStyle.Width(6).Render("EXP"),
 		inputStyle.Width(6).Render("CVV"),
-		m.inputs[exp].View(),
-		m.inputs[cvv].View(),
+		m.fieldView(exp),
+		m.fieldView(cvv),
+		m.errView(exp, cvv),
 		continueStyle.Render("Continue ->"),
 	) + "\n"
 }
 
+func (m model) summaryView() string {
+	month, year, _ := parseExpiry(m.inputs[exp].Value())
+	return fmt.Sprintf(
+		` Total: %s
+
+ %s
+ %s
+ %s
+
+ %s
+`,
+		total,
+		inputStyle.Render("Pay with "+m.brand.name),
+		m.brand.mask(onlyDigits(m.inputs[ccn].Value())),
+		continueStyle.Render(fmt.Sprintf("Expires %s %d", month, year)),
+		continueStyle.Render("enter: pay • esc: edit"),
+	) + "\n"
+}
+
+func last4(s string) string {
+	d := onlyDigits(s)
+	return d[max(0, len(d)-4):]
+}
+
 // nextInput focuses the next input field
 func (m *model) nextInput() {
+	m.touched[m.focused] = true
 	m.focused = (m.focused + 1) % len(m.inputs)
 }
 
 // prevInput focuses the previous input field
 func (m *model) prevInput() {
+	m.touched[m.focused] = true
 	m.focused--
 	// Wrap around
 	if m.focused < 0 {
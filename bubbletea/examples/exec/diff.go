@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aymanbagabas/go-udiff"
+	"github.com/aymanbagabas/go-udiff/myers"
+)
+
+type diffOp byte
+
+const (
+	opAdd    diffOp = '+'
+	opRemove diffOp = '-'
+)
+
+// noNewline marks a last line that doesn't end in a newline, so that adding
+// or removing one shows up as a change.
+const noNewline = " (no newline at end)"
+
+type diffLine struct {
+	op   diffOp
+	text string
+}
+
+// diffLines returns the lines that were removed from a and added in b, in
+// order. Unchanged lines are left out.
+func diffLines(a, b string) []diffLine {
+	u, err := udiff.ToUnifiedDiff("a", "b", a, myers.ComputeEdits(a, b), 0)
+	if err != nil {
+		// Can't happen: the edits come from a and b.
+		return nil
+	}
+	var out []diffLine
+	for _, h := range u.Hunks {
+		for _, l := range h.Lines {
+			text, ok := strings.CutSuffix(l.Content, "\n")
+			if !ok {
+				text += noNewline
+			}
+			switch l.Kind {
+			case udiff.Delete:
+				out = append(out, diffLine{opRemove, text})
+			case udiff.Insert:
+				out = append(out, diffLine{opAdd, text})
+			}
+		}
+	}
+	return out
+}
+
+// diffSummary describes a diff in a few words, like "2 lines added, 1
+// removed".
+func diffSummary(diff []diffLine) string {
+	var added, removed int
+	for _, d := range diff {
+		if d.op == opAdd {
+			added++
+		} else {
+			removed++
+		}
+	}
+	switch {
+	case added == 0 && removed == 0:
+		return "no changes"
+	case removed == 0:
+		return fmt.Sprintf("%d %s added", added, plural(added, "line"))
+	case added == 0:
+		return fmt.Sprintf("%d %s removed", removed, plural(removed, "line"))
+	}
+	return fmt.Sprintf("%d %s added, %d removed", added, plural(added, "line"), removed)
+}
+
+func plural(n int, s string) string {
+	if n == 1 {
+		return s
+	}
+	return s + "s"
+}
@@ -1,69 +1,453 @@
 package main
 
+// A small notes manager that shows how to hand the terminal over to another
+// program with tea.ExecProcess. Notes are edited in $VISUAL or $EDITOR, via
+// a temporary file, and we fall back to a textarea when neither is set.
+
 import (
+	"flag"
 	"fmt"
 	"os"
 	"os/exec"
+	"strings"
 
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 )
 
-type editorFinishedMsg struct{ err error }
+const maxDiffLines = 6
 
-func openEditor() tea.Cmd {
-	editor := os.Getenv("EDITOR")
-	if editor == "" {
-		editor = "vim"
+var (
+	titleStyle    = lipgloss.NewStyle().Bold(true)
+	selectedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("212"))
+	dimStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	addStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	removeStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("203"))
+	errStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+)
+
+type (
+	notesMsg struct {
+		notes []note
+		err   error
+	}
+	// editReadyMsg is sent once we've read a note and, if we're using an
+	// external editor, copied it to a temp file.
+	editReadyMsg struct {
+		note     note
+		original string
+		tmp      string // empty for the built-in editor
+		err      error
+	}
+	editorFinishedMsg struct {
+		note     note
+		original string
+		tmp      string
+		err      error
+	}
+	savedMsg struct {
+		note note
+		diff []diffLine
+		err  error
+	}
+)
+
+// editorCommand returns the user's editor, or an empty string if they
+// haven't set one.
+func editorCommand() string {
+	if e := os.Getenv("VISUAL"); e != "" {
+		return e
+	}
+	return os.Getenv("EDITOR")
+}
+
+func loadNotes(dir string) tea.Cmd {
+	return func() tea.Msg {
+		notes, err := listNotes(dir)
+		return notesMsg{notes, err}
+	}
+}
+
+// prepareEdit reads the note and, when useTemp is set, copies it to a temp
+// file for the editor to work on. Editing a copy means that quitting the
+// editor without saving, or it crashing, leaves the note alone.
+func prepareEdit(n note, useTemp bool) tea.Cmd {
+	return func() tea.Msg {
+		bts, err := os.ReadFile(n.path)
+		if err != nil {
+			return editReadyMsg{err: err}
+		}
+		msg := editReadyMsg{note: n, original: string(bts)}
+		if !useTemp {
+			return msg
+		}
+
+		f, err := os.CreateTemp("", n.name+"-*"+noteExt)
+		if err != nil {
+			return editReadyMsg{err: err}
+		}
+		msg.tmp = f.Name()
+		if _, err := f.Write(bts); err != nil {
+			_ = f.Close()
+			_ = os.Remove(msg.tmp)
+			return editReadyMsg{err: err}
+		}
+		if err := f.Close(); err != nil {
+			_ = os.Remove(msg.tmp)
+			return editReadyMsg{err: err}
+		}
+		return msg
 	}
-	c := exec.Command(editor) //nolint:gosec
+}
+
+func openEditor(editor string, msg editReadyMsg) tea.Cmd {
+	// $EDITOR is allowed to have arguments, like "code --wait".
+	args := append(strings.Fields(editor), msg.tmp)
+	c := exec.Command(args[0], args[1:]...) //nolint:gosec
 	return tea.ExecProcess(c, func(err error) tea.Msg {
-		return editorFinishedMsg{err}
+		return editorFinishedMsg{note: msg.note, original: msg.original, tmp: msg.tmp, err: err}
 	})
 }
 
+// finishEdit reads back the temp file the editor worked on and saves it over
+// the note if anything changed.
+func finishEdit(msg editorFinishedMsg) tea.Cmd {
+	return func() tea.Msg {
+		defer os.Remove(msg.tmp) //nolint:errcheck
+		bts, err := os.ReadFile(msg.tmp)
+		if err != nil {
+			return savedMsg{note: msg.note, err: err}
+		}
+		return save(msg.note, msg.original, string(bts))
+	}
+}
+
+func saveEdit(n note, original, content string) tea.Cmd {
+	return func() tea.Msg {
+		return save(n, original, content)
+	}
+}
+
+func save(n note, original, content string) savedMsg {
+	if content == original {
+		return savedMsg{note: n}
+	}
+	return savedMsg{note: n, diff: diffLines(original, content), err: saveNote(n.path, content)}
+}
+
+type mode int
+
+const (
+	listMode mode = iota
+	nameMode
+	deleteMode
+	editMode
+)
+
 type model struct {
+	dir    string
+	notes  []note
+	cursor int
+	mode   mode
+
+	input textinput.Model // for naming new notes
+
+	// The built-in editor, for when there's no $EDITOR.
+	textarea textarea.Model
+	editing  note
+	original string
+	// confirmDiscard is set after esc is pressed with unsaved changes.
+	confirmDiscard bool
+
 	altscreenActive bool
+	width           int
+	status          string
+	diff            []diffLine
 	err             error
 }
 
+func newModel(dir string) model {
+	ti := textinput.New()
+	ti.Placeholder = "name"
+	ti.CharLimit = 64
+
+	ta := textarea.New()
+	ta.ShowLineNumbers = true
+	ta.SetHeight(15)
+
+	return model{dir: dir, input: ti, textarea: ta}
+}
+
 func (m model) Init() tea.Cmd {
-	return nil
+	return loadNotes(m.dir)
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
-	case tea.KeyMsg:
-		switch msg.String() {
-		case "a":
-			m.altscreenActive = !m.altscreenActive
-			cmd := tea.EnterAltScreen
-			if !m.altscreenActive {
-				cmd = tea.ExitAltScreen
-			}
-			return m, cmd
-		case "e":
-			return m, openEditor()
-		case "ctrl+c", "q":
-			return m, tea.Quit
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.textarea.SetWidth(msg.Width)
+		m.textarea.SetHeight(max(5, msg.Height-4))
+		return m, nil
+
+	case notesMsg:
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
 		}
+		m.notes = msg.notes
+		m.cursor = min(m.cursor, max(len(m.notes)-1, 0))
+		return m, nil
+
+	case editReadyMsg:
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+		if msg.tmp != "" {
+			return m, openEditor(editorCommand(), msg)
+		}
+		m.mode = editMode
+		m.editing, m.original = msg.note, msg.original
+		m.confirmDiscard = false
+		m.textarea.SetValue(msg.original)
+		return m, m.textarea.Focus()
+
 	case editorFinishedMsg:
+		if msg.err != nil {
+			// The editor failed, so whatever's in the temp file isn't to be
+			// trusted.
+			_ = os.Remove(msg.tmp)
+			m.err = fmt.Errorf("%s: %w", editorCommand(), msg.err)
+			return m, nil
+		}
+		return m, finishEdit(msg)
+
+	case savedMsg:
+		m.diff = nil
 		if msg.err != nil {
 			m.err = msg.err
+			return m, loadNotes(m.dir)
+		}
+		m.status = msg.note.name + ": " + diffSummary(msg.diff)
+		m.diff = msg.diff
+		if msg.diff != nil {
+			// The note we just changed moves to the top.
+			m.cursor = 0
+		}
+		return m, loadNotes(m.dir)
+
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" {
 			return m, tea.Quit
 		}
+		switch m.mode {
+		case nameMode:
+			return m.updateName(msg)
+		case deleteMode:
+			return m.updateDelete(msg)
+		case editMode:
+			return m.updateEdit(msg)
+		}
+		return m.updateList(msg)
+	}
+
+	if m.mode == editMode {
+		var cmd tea.Cmd
+		m.textarea, cmd = m.textarea.Update(msg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+func (m model) updateList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.err = nil
+	switch msg.String() {
+	case "q":
+		return m, tea.Quit
+	case "up", "k":
+		m.cursor = max(m.cursor-1, 0)
+	case "down", "j":
+		m.cursor = min(m.cursor+1, max(len(m.notes)-1, 0))
+	case "enter", "e", "i":
+		if len(m.notes) == 0 {
+			return m, nil
+		}
+		m.status, m.diff = "", nil
+		// "i" always uses the built-in editor.
+		useTemp := editorCommand() != "" && msg.String() != "i"
+		return m, prepareEdit(m.notes[m.cursor], useTemp)
+	case "n":
+		m.mode = nameMode
+		m.input.Reset()
+		return m, m.input.Focus()
+	case "d":
+		if len(m.notes) > 0 {
+			m.mode = deleteMode
+		}
+	case "a":
+		m.altscreenActive = !m.altscreenActive
+		cmd := tea.EnterAltScreen
+		if !m.altscreenActive {
+			cmd = tea.ExitAltScreen
+		}
+		return m, cmd
 	}
 	return m, nil
 }
 
+func (m model) updateName(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.mode = listMode
+		m.input.Blur()
+		return m, nil
+	case tea.KeyEnter:
+		m.mode = listMode
+		m.input.Blur()
+		path, err := newNote(m.dir, m.input.Value())
+		if err != nil {
+			m.err = err
+			return m, nil
+		}
+		// Jump straight into editing it.
+		n := note{name: strings.TrimSuffix(strings.TrimSpace(m.input.Value()), noteExt), path: path}
+		return m, tea.Batch(loadNotes(m.dir), prepareEdit(n, editorCommand() != ""))
+	}
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+func (m model) updateDelete(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.mode = listMode
+	if msg.String() != "y" {
+		return m, nil
+	}
+	n := m.notes[m.cursor]
+	if err := os.Remove(n.path); err != nil {
+		m.err = err
+		return m, nil
+	}
+	m.status, m.diff = "deleted "+n.name, nil
+	return m, loadNotes(m.dir)
+}
+
+func (m model) updateEdit(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+s":
+		m.mode = listMode
+		m.textarea.Blur()
+		return m, saveEdit(m.editing, m.original, m.textarea.Value())
+	case "esc":
+		if m.textarea.Value() != m.original && !m.confirmDiscard {
+			m.confirmDiscard = true
+			return m, nil
+		}
+		m.mode = listMode
+		m.textarea.Blur()
+		if m.confirmDiscard {
+			m.status = m.editing.name + ": discarded changes"
+		}
+		return m, nil
+	}
+	m.confirmDiscard = false
+	var cmd tea.Cmd
+	m.textarea, cmd = m.textarea.Update(msg)
+	return m, cmd
+}
+
 func (m model) View() string {
+	if m.mode == editMode {
+		help := "ctrl+s save • esc cancel"
+		if m.confirmDiscard {
+			help = errStyle.Render("Unsaved changes! Press esc again to discard them, or ctrl+s to save.")
+		}
+		return titleStyle.Render("Editing "+m.editing.name) + "\n\n" +
+			m.textarea.View() + "\n" + dimStyle.Render(help) + "\n"
+	}
+
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Notes in "+m.dir) + "\n\n")
+	if len(m.notes) == 0 {
+		b.WriteString(dimStyle.Render("No notes yet. Press n to make one.") + "\n")
+	}
+	for i, n := range m.notes {
+		name := fmt.Sprintf("  %-20s", n.name)
+		if i == m.cursor {
+			name = selectedStyle.Render(fmt.Sprintf("> %-20s", n.name))
+		}
+		b.WriteString(name + " " + dimStyle.Render(n.modTime.Format("Jan 2 15:04")+"  "+n.title) + "\n")
+	}
+	b.WriteString("\n")
+
+	switch m.mode {
+	case nameMode:
+		b.WriteString("New note: " + m.input.View() + "\n")
+	case deleteMode:
+		b.WriteString(errStyle.Render(fmt.Sprintf("Delete %s? (y/n)", m.notes[m.cursor].name)) + "\n")
+	}
+
 	if m.err != nil {
-		return "Error: " + m.err.Error() + "\n"
+		b.WriteString(errStyle.Render("Error: "+m.err.Error()) + "\n")
+	} else if m.status != "" {
+		b.WriteString(m.status + "\n")
+		b.WriteString(m.diffView())
 	}
-	return "Press 'e' to open your EDITOR.\nPress 'a' to toggle the altscreen\nPress 'q' to quit.\n"
+
+	editor := editorCommand()
+	if editor == "" {
+		editor = "built-in editor"
+	}
+	b.WriteString("\n" + dimStyle.Render(fmt.Sprintf(
+		"enter edit (%s) • i built-in editor • n new • d delete • a altscreen • q quit", editor)) + "\n")
+	return b.String()
+}
+
+// diffView shows the first few changed lines of the last edit.
+func (m model) diffView() string {
+	var b strings.Builder
+	for i, d := range m.diff {
+		if i == maxDiffLines {
+			b.WriteString(dimStyle.Render(fmt.Sprintf("  … %d more", len(m.diff)-i)) + "\n")
+			break
+		}
+		style := addStyle
+		if d.op == opRemove {
+			style = removeStyle
+		}
+		line := string(d.op) + " " + d.text
+		if m.width > 0 {
+			line = truncate(line, m.width-2)
+		}
+		b.WriteString("  " + style.Render(line) + "\n")
+	}
+	return b.String()
+}
+
+func truncate(s string, width int) string {
+	if width <= 1 || lipgloss.Width(s) <= width {
+		return s
+	}
+	r := []rune(s)
+	for lipgloss.Width(string(r)) > width-1 {
+		r = r[:len(r)-1]
+	}
+	return string(r) + "…"
 }
 
 func main() {
-	m := model{}
+	dir := flag.String("dir", "notes", "directory to keep notes in")
+	flag.Parse()
+
+	if _, err := listNotes(*dir); err != nil {
+		fmt.Println("Error opening notes:", err)
+		os.Exit(1)
+	}
+
+	m := newModel(*dir)
 	if _, err := tea.NewProgram(m).Run(); err != nil {
 		fmt.Println("Error running program:", err)
 		os.Exit(1)
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+const noteExt = ".md"
+
+type note struct {
+	name    string // file name without the extension
+	path    string
+	modTime time.Time
+	title   string // first non-empty line, for the list
+}
+
+// listNotes returns the notes in dir, most recently modified first. It
+// creates dir if it doesn't exist yet.
+func listNotes(dir string) ([]note, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var notes []note
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != noteExt {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		notes = append(notes, note{
+			name:    strings.TrimSuffix(e.Name(), noteExt),
+			path:    path,
+			modTime: info.ModTime(),
+			title:   firstLine(path),
+		})
+	}
+	slices.SortFunc(notes, func(a, b note) int {
+		return b.modTime.Compare(a.modTime)
+	})
+	return notes, nil
+}
+
+func firstLine(path string) string {
+	bts, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(bts), "\n") {
+		if line = strings.TrimSpace(strings.TrimLeft(line, "# ")); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// newNote creates an empty note called name in dir.
+func newNote(dir, name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("%q isn't a valid note name", name)
+	}
+	path := filepath.Join(dir, strings.TrimSuffix(name, noteExt)+noteExt)
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if errors.Is(err, os.ErrExist) {
+		return "", fmt.Errorf("there's already a note called %q", name)
+	}
+	if err != nil {
+		return "", err
+	}
+	return path, f.Close()
+}
+
+// saveNote replaces the contents of the note at path. We write to a
+// temporary file first so that a failed write doesn't leave half a note.
+func saveNote(path, content string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".note-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.WriteString(content); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want []diffLine
+	}{
+		{"a\nb\nc\n", "a\nb\nc\n", nil},
+		{"a\nb\nc\n", "a\nx\nc\n", []diffLine{{opRemove, "b"}, {opAdd, "x"}}},
+		{"a\nc\n", "a\nb\nc\nd\n", []diffLine{{opAdd, "b"}, {opAdd, "d"}}},
+		{"a\nb\nc\n", "c\n", []diffLine{{opRemove, "a"}, {opRemove, "b"}}},
+		{"", "new\n", []diffLine{{opAdd, "new"}}},
+		{"x\na\nb\n", "a\nb\ny\n", []diffLine{{opRemove, "x"}, {opAdd, "y"}}},
+		{"a\nb\n", "a\nb", []diffLine{{opRemove, "b"}, {opAdd, "b" + noNewline}}},
+	}
+	for _, tt := range tests {
+		if got := diffLines(tt.a, tt.b); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("diffLines(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestDiffSummary(t *testing.T) {
+	for want, diff := range map[string][]diffLine{
+		"no changes":               nil,
+		"1 line added":             {{opAdd, "a"}},
+		"2 lines removed":          {{opRemove, "a"}, {opRemove, "b"}},
+		"2 lines added, 1 removed": {{opRemove, "a"}, {opAdd, "b"}, {opAdd, "c"}},
+	} {
+		if got := diffSummary(diff); got != want {
+			t.Errorf("diffSummary(%v) = %q, want %q", diff, got, want)
+		}
+	}
+}
+
+func TestNotes(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "notes")
+
+	notes, err := listNotes(dir)
+	if err != nil || len(notes) != 0 {
+		t.Fatalf("listNotes = %v, %v; want an empty list", notes, err)
+	}
+
+	for _, name := range []string{"", "..", "a/b"} {
+		if _, err := newNote(dir, name); err == nil {
+			t.Errorf("newNote(%q) succeeded", name)
+		}
+	}
+
+	older, err := newNote(dir, "older")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := saveNote(older, "\n# Shopping\n\nmilk\n"); err != nil {
+		t.Fatal(err)
+	}
+	past := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(older, past, past); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newNote(dir, "newer.md"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newNote(dir, "newer"); err == nil {
+		t.Error("creating a note twice succeeded")
+	}
+	if err := os.WriteFile(filepath.Join(dir, "ignored.txt"), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	notes, err = listNotes(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, n := range notes {
+		got = append(got, n.name+":"+n.title)
+	}
+	if want := []string{"newer:", "older:Shopping"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("notes = %q, want %q", got, want)
+	}
+}
+
+// TestEditRoundTrip edits a note the way the program does, with a "editor"
+// that changes the temp file.
+func TestEditRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	path, err := newNote(dir, "todo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := saveNote(path, "one\ntwo\n"); err != nil {
+		t.Fatal(err)
+	}
+	n := note{name: "todo", path: path}
+
+	ready := prepareEdit(n, true)().(editReadyMsg)
+	if ready.err != nil {
+		t.Fatal(ready.err)
+	}
+	if ready.original != "one\ntwo\n" {
+		t.Errorf("original = %q", ready.original)
+	}
+	if err := os.WriteFile(ready.tmp, []byte("one\n2\nthree\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	saved := finishEdit(editorFinishedMsg{note: n, original: ready.original, tmp: ready.tmp})().(savedMsg)
+	if saved.err != nil {
+		t.Fatal(saved.err)
+	}
+	if got := diffSummary(saved.diff); got != "2 lines added, 1 removed" {
+		t.Errorf("summary = %q", got)
+	}
+	if bts, _ := os.ReadFile(path); string(bts) != "one\n2\nthree\n" {
+		t.Errorf("note = %q", bts)
+	}
+	if _, err := os.Stat(ready.tmp); !os.IsNotExist(err) {
+		t.Error("temp file wasn't removed")
+	}
+
+	// Quitting the editor without changing anything leaves the note be.
+	ready = prepareEdit(n, true)().(editReadyMsg)
+	saved = finishEdit(editorFinishedMsg{note: n, original: ready.original, tmp: ready.tmp})().(savedMsg)
+	if saved.err != nil || saved.diff != nil {
+		t.Errorf("unchanged edit = %+v", saved)
+	}
+}
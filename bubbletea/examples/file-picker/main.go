@@ -2,20 +2,57 @@ package main
 
 import (
 	"errors"
+	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/charmbracelet/bubbles/filepicker"
+	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	dirStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("99"))
+	helpStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	titleStyle   = lipgloss.NewStyle().Bold(true)
+	previewStyle = lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("241")).
+			Padding(0, 1)
+	trayStyle = lipgloss.NewStyle().
+			Border(lipgloss.NormalBorder(), true, false, false, false).
+			BorderForeground(lipgloss.Color("241"))
+)
+
+const (
+	headerHeight = 3
+	trayHeight   = 5
+)
+
+var (
+	toggleKey = key.NewBinding(key.WithKeys(" "), key.WithHelp("space", "select"))
+	clearKey  = key.NewBinding(key.WithKeys("c"), key.WithHelp("c", "clear selection"))
+	doneKey   = key.NewBinding(key.WithKeys("q"), key.WithHelp("q", "done"))
+	abortKey  = key.NewBinding(key.WithKeys("ctrl+c"), key.WithHelp("ctrl+c", "abort"))
 )
 
 type model struct {
-	filepicker   filepicker.Model
-	selectedFile string
-	quitting     bool
-	err          error
+	filepicker filepicker.Model
+	current    string // the path under the file picker's cursor
+
+	selected    []string
+	preview     string
+	previewPath string            // the path preview is for
+	previews    map[string]string // rendered previews by path
+	width       int
+	height      int
+	quitting    bool
+	aborted     bool
+	err         error
 }
 
 type clearErrorMsg struct{}
@@ -30,37 +67,152 @@ func (m model) Init() tea.Cmd {
 	return m.filepicker.Init()
 }
 
+// probe is only ever sent to a copy of the file picker, to find out what's
+// highlighted.
+var (
+	probe    = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("probe")}
+	probeKey = key.NewBinding(key.WithKeys(probe.String()))
+)
+
+// highlighted returns the path under the file picker's cursor. The file
+// picker doesn't say, but it does set Path when something is selected, so
+// a copy of it that lets anything be selected is asked to select with the
+// probe key. Directories are opened in the copy, which doesn't matter.
+func highlighted(fp filepicker.Model) string {
+	fp.KeyMap.Open, fp.KeyMap.Select = probeKey, probeKey
+	fp.FileAllowed, fp.DirAllowed = true, true
+	fp.Path = ""
+	fp, _ = fp.Update(probe)
+	return fp.Path
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+func (m model) isSelected(path string) bool {
+	for _, p := range m.selected {
+		if p == path {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *model) toggle(path string) {
+	for i, p := range m.selected {
+		if p == path {
+			m.selected = append(m.selected[:i:i], m.selected[i+1:]...)
+			return
+		}
+	}
+	m.selected = append(m.selected, path)
+}
+
+func (m model) previewWidth() int {
+	return max(20, m.width/2-previewStyle.GetHorizontalFrameSize())
+}
+
+// updatePreview loads the preview for the highlighted path if we don't
+// already have it.
+func (m *model) updatePreview() tea.Cmd {
+	path := m.current
+	m.previewPath = path
+	if path == "" {
+		m.preview = ""
+		return nil
+	}
+	if p, ok := m.previews[path]; ok {
+		m.preview = p
+		return nil
+	}
+	m.preview = helpStyle.Render("Loading...")
+	return loadPreview(path, m.previewWidth())
+}
+
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.filepicker.SetHeight(max(1, msg.Height-headerHeight-trayHeight-1))
+		// Previews are wrapped to the old width, so start over.
+		m.previews = map[string]string{}
+		return m, m.updatePreview()
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c", "q":
+		switch {
+		case key.Matches(msg, abortKey):
+			m.quitting, m.aborted = true, true
+			return m, tea.Quit
+		case key.Matches(msg, doneKey):
 			m.quitting = true
 			return m, tea.Quit
+		case key.Matches(msg, toggleKey):
+			path := m.current
+			if path == "" {
+				return m, nil
+			}
+			if !isDir(path) && !m.canSelect(path) {
+				m.err = errors.New(path + " is not valid.")
+				return m, clearErrorAfter(2 * time.Second)
+			}
+			m.toggle(path)
+			return m, nil
+		case key.Matches(msg, clearKey):
+			m.selected = nil
+			return m, nil
 		}
 	case clearErrorMsg:
 		m.err = nil
+	case previewMsg:
+		if msg.width == m.previewWidth() {
+			m.previews[msg.path] = msg.content
+		}
+		if msg.path == m.current {
+			m.preview = msg.content
+		}
+		return m, nil
 	}
 
 	var cmd tea.Cmd
 	m.filepicker, cmd = m.filepicker.Update(msg)
+	cmds := []tea.Cmd{cmd}
+
+	// Keys move the cursor, and directory listings arriving put it on
+	// something new.
+	if m.current = highlighted(m.filepicker); m.current != m.previewPath {
+		cmds = append(cmds, m.updatePreview())
+	}
 
 	// Did the user select a file?
 	if didSelect, path := m.filepicker.DidSelectFile(msg); didSelect {
-		// Get the path of the selected file.
-		m.selectedFile = path
+		if !m.isSelected(path) {
+			m.selected = append(m.selected, path)
+		}
 	}
 
 	// Did the user select a disabled file?
 	// This is only necessary to display an error to the user.
 	if didSelect, path := m.filepicker.DidSelectDisabledFile(msg); didSelect {
-		// Let's clear the selectedFile and display an error.
+		// Let's display an error.
 		m.err = errors.New(path + " is not valid.")
-		m.selectedFile = ""
-		return m, tea.Batch(cmd, clearErrorAfter(2*time.Second))
+		cmds = append(cmds, clearErrorAfter(2*time.Second))
 	}
 
-	return m, cmd
+	return m, tea.Batch(cmds...)
+}
+
+// canSelect reports whether the file picker would let us pick path.
+func (m model) canSelect(path string) bool {
+	if len(m.filepicker.AllowedTypes) == 0 {
+		return true
+	}
+	for _, ext := range m.filepicker.AllowedTypes {
+		if strings.HasSuffix(path, ext) {
+			return true
+		}
+	}
+	return false
 }
 
 func (m model) View() string {
@@ -71,24 +223,109 @@ func (m model) View() string {
 	s.WriteString("\n  ")
 	if m.err != nil {
 		s.WriteString(m.filepicker.Styles.DisabledFile.Render(m.err.Error()))
-	} else if m.selectedFile == "" {
-		s.WriteString("Pick a file:")
 	} else {
-		s.WriteString("Selected file: " + m.filepicker.Styles.Selected.Render(m.selectedFile))
+		s.WriteString("Pick files: " + helpStyle.Render(m.filepicker.CurrentDirectory))
 	}
-	s.WriteString("\n\n" + m.filepicker.View() + "\n")
+	s.WriteString("\n\n")
+
+	listWidth := m.width - m.previewWidth() - previewStyle.GetHorizontalFrameSize()
+	list := lipgloss.NewStyle().Width(listWidth).MaxWidth(listWidth).Render(m.filepicker.View())
+
+	title := filepath.Base(m.current)
+	if m.isSelected(m.current) {
+		title += " " + m.filepicker.Styles.Selected.Render("✓")
+	}
+	previewHeight := m.filepicker.Height
+	preview := previewStyle.
+		Width(m.previewWidth()).
+		Height(previewHeight).
+		MaxHeight(previewHeight + previewStyle.GetVerticalFrameSize()).
+		Render(titleStyle.Render(title) + "\n" + m.preview)
+
+	s.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, list, preview))
+	s.WriteString("\n" + m.trayView())
 	return s.String()
 }
 
+func (m model) trayView() string {
+	var lines []string
+	if len(m.selected) == 0 {
+		lines = append(lines, helpStyle.Render("Nothing selected yet."))
+	} else {
+		lines = append(lines, fmt.Sprintf("%d selected:", len(m.selected)))
+		for i, p := range m.selected {
+			if i == trayHeight-3 && len(m.selected) > trayHeight-2 {
+				lines = append(lines, helpStyle.Render(fmt.Sprintf("…and %d more", len(m.selected)-i)))
+				break
+			}
+			lines = append(lines, "  "+m.filepicker.Styles.Selected.Render(p))
+		}
+	}
+	help := []string{}
+	for _, k := range []key.Binding{toggleKey, m.filepicker.KeyMap.Select, clearKey, doneKey, abortKey} {
+		help = append(help, k.Help().Key+" "+k.Help().Desc)
+	}
+	lines = append(lines, helpStyle.Render(strings.Join(help, " • ")))
+	return trayStyle.Width(m.width).Render(strings.Join(lines, "\n"))
+}
+
 func main() {
+	var (
+		print0     bool
+		printLines bool
+		dir        string
+	)
+	flag.BoolVar(&print0, "print0", false, "print the selected paths separated by NUL characters")
+	flag.BoolVar(&printLines, "print", false, "print the selected paths one per line")
+	flag.StringVar(&dir, "dir", "", "directory to start in (default: your home directory)")
+	flag.Parse()
+
 	fp := filepicker.New()
 	fp.AllowedTypes = []string{".mod", ".sum", ".go", ".txt", ".md"}
+	fp.AutoHeight = false
 	fp.CurrentDirectory, _ = os.UserHomeDir()
+	if dir != "" {
+		fp.CurrentDirectory = dir
+	}
+
+	if !lipgloss.HasDarkBackground() {
+		glamourStyle = "light"
+	}
 
 	m := model{
 		filepicker: fp,
+		previews:   map[string]string{},
+	}
+
+	// When printing paths for a script, keep the TUI off stdout.
+	var opts []tea.ProgramOption
+	if printLines || print0 {
+		opts = append(opts, tea.WithOutput(os.Stderr))
+	}
+	tm, err := tea.NewProgram(m, opts...).Run()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error running program:", err)
+		os.Exit(1)
 	}
-	tm, _ := tea.NewProgram(&m).Run()
 	mm := tm.(model)
-	fmt.Println("\n  You selected: " + m.filepicker.Styles.Selected.Render(mm.selectedFile) + "\n")
+	if mm.aborted {
+		os.Exit(1)
+	}
+
+	switch {
+	case print0:
+		for _, p := range mm.selected {
+			fmt.Print(p + "\x00")
+		}
+	case printLines:
+		for _, p := range mm.selected {
+			fmt.Println(p)
+		}
+	default:
+		fmt.Println("\n  You selected:")
+		for _, p := range mm.selected {
+			fmt.Println("  " + m.filepicker.Styles.Selected.Render(p))
+		}
+		fmt.Println()
+	}
 }
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/bubbles/filepicker"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// run runs a command and updates the model with what comes of it, and so
+// on. Commands that take a while, like ticks, are left alone.
+func run(m model, cmd tea.Cmd) model {
+	if cmd == nil {
+		return m
+	}
+	done := make(chan tea.Msg, 1)
+	go func() { done <- cmd() }()
+	var msg tea.Msg
+	select {
+	case msg = <-done:
+	case <-time.After(100 * time.Millisecond):
+		return m
+	}
+	if batch, ok := msg.(tea.BatchMsg); ok {
+		for _, c := range batch {
+			m = run(m, c)
+		}
+		return m
+	}
+	if msg == nil {
+		return m
+	}
+	next, cmd := m.Update(msg)
+	return run(next.(model), cmd)
+}
+
+func press(m model, keys ...string) model {
+	for _, k := range keys {
+		msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		switch k {
+		case " ":
+			msg = tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(k)}
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		}
+		next, cmd := m.Update(msg)
+		m = run(next.(model), cmd)
+	}
+	return m
+}
+
+func testModel(t *testing.T) (model, string) {
+	t.Helper()
+	dir := t.TempDir()
+	for _, name := range []string{"a.txt", "b.go", "c.bin", ".hidden.txt", "sub/d.md"} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil { //nolint:gosec
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("# "+name+"\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fp := filepicker.New()
+	fp.AllowedTypes = []string{".go", ".txt", ".md"}
+	fp.AutoHeight = false
+	fp.CurrentDirectory = dir
+	fp.SetHeight(10)
+	m := model{filepicker: fp, previews: map[string]string{}}
+	return run(m, m.Init()), dir
+}
+
+func TestHighlighted(t *testing.T) {
+	m, dir := testModel(t)
+
+	// Directories come first, and hidden files aren't listed.
+	steps := []struct {
+		keys []string
+		want string
+	}{
+		{nil, "sub"},
+		{[]string{"j"}, "a.txt"},
+		{[]string{"G"}, "c.bin"},
+		{[]string{"j"}, "c.bin"},
+		{[]string{"g"}, "sub"},
+		{[]string{"l"}, "sub/d.md"},
+		{[]string{"h"}, "sub"},
+	}
+	for _, s := range steps {
+		m = press(m, s.keys...)
+		if want := filepath.Join(dir, s.want); m.current != want {
+			t.Fatalf("after %q: highlighted %s, want %s", s.keys, m.current, want)
+		}
+	}
+	if !strings.Contains(m.preview, "d.md") {
+		t.Errorf("preview of sub = %q", m.preview)
+	}
+	if m.filepicker.Path != "" {
+		t.Errorf("looking at the highlighted path selected %s", m.filepicker.Path)
+	}
+}
+
+func TestMultiSelect(t *testing.T) {
+	m, dir := testModel(t)
+	path := func(name string) string { return filepath.Join(dir, name) }
+	selected := func() string {
+		var names []string
+		for _, p := range m.selected {
+			rel, _ := filepath.Rel(dir, p)
+			names = append(names, rel)
+		}
+		return strings.Join(names, " ")
+	}
+
+	// Space toggles, enter picks, and directories can be picked with space.
+	m = press(m, " ", "j", " ", "j", "enter")
+	if got := selected(); got != "sub a.txt b.go" {
+		t.Fatalf("selected %q", got)
+	}
+	m = press(m, "enter")
+	if got := selected(); got != "sub a.txt b.go" {
+		t.Errorf("picking b.go twice: %q", got)
+	}
+	m = press(m, "k", " ")
+	if got := selected(); got != "sub b.go" {
+		t.Errorf("after unpicking a.txt: %q", got)
+	}
+	if !m.isSelected(path("b.go")) || m.isSelected(path("a.txt")) {
+		t.Error("isSelected disagrees with the selection")
+	}
+
+	// Files of other types can't be picked.
+	m = press(m, "G", " ")
+	if m.err == nil || !strings.Contains(m.err.Error(), "c.bin is not valid") || selected() != "sub b.go" {
+		t.Errorf("picking c.bin: %v, %q", m.err, selected())
+	}
+
+	m = press(m, "c")
+	if len(m.selected) != 0 {
+		t.Errorf("clearing left %q", selected())
+	}
+}
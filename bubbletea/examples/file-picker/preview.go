@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/alecthomas/chroma/v2/quick"
+	"github.com/charmbracelet/bubbles/filepicker"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glamour"
+)
+
+const (
+	// maxPreviewBytes is how much of a file we read for its preview.
+	maxPreviewBytes = 32 * 1024
+	// hexDumpBytes is how much of a binary file we show.
+	hexDumpBytes = 512
+)
+
+// glamourStyle is picked before the program starts so we don't have to query
+// the terminal's background color while it's running.
+var glamourStyle = "dark"
+
+type previewMsg struct {
+	path    string
+	width   int
+	content string
+}
+
+// loadPreview renders a preview of the file or directory at path.
+func loadPreview(path string, width int) tea.Cmd {
+	return func() tea.Msg {
+		content, err := preview(path, width)
+		if err != nil {
+			content = err.Error()
+		}
+		return previewMsg{path: path, width: width, content: content}
+	}
+}
+
+func preview(path string, width int) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return previewDir(path)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close() //nolint:errcheck
+
+	bts, err := io.ReadAll(io.LimitReader(f, maxPreviewBytes))
+	if err != nil {
+		return "", err
+	}
+	if isBinary(bts) {
+		return hex.Dump(bts[:min(len(bts), hexDumpBytes)]), nil
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".md", ".markdown":
+		r, err := glamour.NewTermRenderer(
+			glamour.WithStandardStyle(glamourStyle),
+			glamour.WithWordWrap(width),
+		)
+		if err != nil {
+			return "", err
+		}
+		return r.Render(string(bts))
+	}
+
+	var b strings.Builder
+	if err := quick.Highlight(&b, string(bts), filepath.Base(path), "terminal256", "monokai"); err != nil {
+		return string(bts), nil //nolint:nilerr
+	}
+	return b.String(), nil
+}
+
+func previewDir(path string) (string, error) {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	var shown int
+	for _, e := range entries {
+		if hidden, _ := filepicker.IsHidden(e.Name()); hidden {
+			continue
+		}
+		name := e.Name()
+		if e.IsDir() {
+			name = dirStyle.Render(name + "/")
+		}
+		b.WriteString(name + "\n")
+		shown++
+	}
+	if shown == 0 {
+		return "(empty directory)", nil
+	}
+	return fmt.Sprintf("%d items\n\n%s", shown, b.String()), nil
+}
+
+// isBinary guesses whether the data is binary the same way most tools do:
+// text doesn't contain NUL bytes and is valid UTF-8.
+func isBinary(bts []byte) bool {
+	if bytes.IndexByte(bts, 0) >= 0 {
+		return true
+	}
+	// We might have cut a multi-byte rune in half at the end.
+	for i := 0; i < utf8.UTFMax && len(bts) > 0; i++ {
+		if utf8.Valid(bts) {
+			return false
+		}
+		bts = bts[:len(bts)-1]
+	}
+	return !utf8.Valid(bts)
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestIsBinary(t *testing.T) {
+	tests := []struct {
+		data string
+		want bool
+	}{
+		{"hello\n", false},
+		{"", false},
+		{"caf\xc3\xa9", false},
+		{"caf\xc3", false}, // cut off in the middle of é
+		{"a\x00b", true},
+		{"\xff\xfe\xfd\xfc\xfb", true},
+	}
+	for _, tt := range tests {
+		if got := isBinary([]byte(tt.data)); got != tt.want {
+			t.Errorf("isBinary(%q) = %v, want %v", tt.data, got, tt.want)
+		}
+	}
+}
+
+func TestPreview(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil { //nolint:gosec
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	tests := []struct {
+		name, path string
+		want       []string
+	}{
+		{"code", write("main.go", "package main\n\nfunc main() {}\n"), []string{"package main", "func main() {}"}},
+		{"markdown", write("notes.md", "# Notes\n\nSome **bold** text.\n"), []string{"Notes", "Some bold text."}},
+		{"binary", write("blob.bin", "\x00\x01\x02hi"), []string{"00000000  00 01 02 68 69", "|...hi|"}},
+		{"directory", filepath.Join(dir, "sub"), []string{"2 items", "inner/", "a.txt"}},
+		{"empty directory", filepath.Join(dir, "sub", "inner", "empty"), []string{"(empty directory)"}},
+	}
+	write("sub/a.txt", "a")
+	write("sub/.hidden", "h")
+	write("sub/inner/empty/.keep", "")
+	for _, tt := range tests {
+		got, err := preview(tt.path, 40)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		got = ansi.Strip(got)
+		for _, want := range tt.want {
+			if !strings.Contains(got, want) {
+				t.Errorf("%s: preview doesn't have %q:\n%s", tt.name, want, got)
+			}
+		}
+	}
+	if strings.Contains(ansi.Strip(must(preview(filepath.Join(dir, "notes.md"), 40))), "**") {
+		t.Error("markdown wasn't rendered")
+	}
+
+	if _, err := preview(filepath.Join(dir, "missing"), 40); err == nil {
+		t.Error("previewed a file that doesn't exist")
+	}
+	msg := loadPreview(filepath.Join(dir, "missing"), 40)().(previewMsg)
+	if !strings.Contains(msg.content, "no such file") {
+		t.Errorf("error preview = %q", msg.content)
+	}
+}
+
+func must(s string, err error) string {
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
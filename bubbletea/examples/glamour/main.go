@@ -1,12 +1,16 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/glamour"
+	glamourstyles "github.com/charmbracelet/glamour/styles"
 	"github.com/charmbracelet/lipgloss"
 )
 
@@ -50,88 +54,390 @@ Some famous people that have eaten here lately:
 Bon appétit!
 `
 
-var helpStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render
+var (
+	helpStyle       = lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render
+	errStyle        = lipgloss.NewStyle().Foreground(lipgloss.Color("203")).Render
+	borderColor     = lipgloss.Color("62")
+	tocStyle        = lipgloss.NewStyle().BorderStyle(lipgloss.RoundedBorder()).BorderForeground(borderColor).Padding(0, 1)
+	tocCursorStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("212")).Bold(true)
+	tocCurrentStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("99"))
+	tocHeadingStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("252"))
+)
 
-type example struct {
-	viewport viewport.Model
+const (
+	// tocWidth is how wide the table of contents is, borders and all.
+	tocWidth = 30
+
+	// The gutter glamour applies to the left side of the content.
+	glamourGutter = 2
+
+	// pollInterval is how often we check whether the file changed.
+	pollInterval = 500 * time.Millisecond
+)
+
+// checkMsg says it's time to see if the file changed.
+type checkMsg struct{}
+
+// stamp is enough about a file to tell when it's changed.
+type stamp struct {
+	mod  time.Time
+	size int64
+}
+
+func statFile(path string) (stamp, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return stamp{}, err
+	}
+	return stamp{fi.ModTime(), fi.Size()}, nil
 }
 
-func newExample() (*example, error) {
-	const width = 78
+type example struct {
+	viewport      viewport.Model
+	width, height int
+
+	// path is the file we're showing, or empty for the menu.
+	path      string
+	source    string
+	fileStamp stamp
+
+	// styles are the glamour styles to switch between: standard ones, or
+	// paths to JSON files.
+	styles     []string
+	style      int
+	styleStamp stamp // for a JSON style, so we can pick up changes to it
+
+	headings   []heading
+	showTOC    bool
+	tocFocused bool
+	cursor     int // the selected heading in the table of contents
+
+	status string
+	err    error
+}
 
-	vp := viewport.New(width, 20)
+func newExample(path, style string) (*example, error) {
+	vp := viewport.New(78, 20)
 	vp.Style = lipgloss.NewStyle().
 		BorderStyle(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("62")).
+		BorderForeground(borderColor).
 		PaddingRight(2)
 
-	// We need to adjust the width of the glamour render from our main width
-	// to account for a few things:
+	// Switch between dark and light, plus the style we were given if it's
+	// something else.
+	styles := []string{glamourstyles.DarkStyle, glamourstyles.LightStyle}
+	i := 0
+	switch style {
+	case glamourstyles.AutoStyle:
+		if !lipgloss.HasDarkBackground() {
+			i = 1
+		}
+	case glamourstyles.DarkStyle:
+	case glamourstyles.LightStyle:
+		i = 1
+	default:
+		styles = append(styles, style)
+		i = 2
+	}
+
+	e := &example{
+		viewport: vp,
+		width:    vp.Width,
+		height:   vp.Height + 2,
+		path:     path,
+		source:   content,
+		styles:   styles,
+		style:    i,
+	}
+	if path != "" {
+		if err := e.load(); err != nil {
+			return nil, err
+		}
+	}
+	e.styleStamp, _ = statFile(e.styles[e.style])
+	if err := e.render(); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// load reads the file again.
+func (e *example) load() error {
+	st, err := statFile(e.path)
+	if err != nil {
+		return err
+	}
+	b, err := os.ReadFile(e.path)
+	if err != nil {
+		return err
+	}
+	e.source, e.fileStamp = string(b), st
+	return nil
+}
+
+// render renders the markdown to fit the viewport. The viewport keeps its
+// scroll position, unless the document got shorter than that.
+func (e *example) render() error {
+	// We need to adjust the width of the glamour render from the viewport
+	// width to account for a few things:
 	//
 	//  * The viewport border width
 	//  * The viewport padding
 	//  * The viewport margins
 	//  * The gutter glamour applies to the left side of the content
 	//
-	const glamourGutter = 2
-	glamourRenderWidth := width - vp.Style.GetHorizontalFrameSize() - glamourGutter
+	glamourRenderWidth := e.viewport.Width - e.viewport.Style.GetHorizontalFrameSize() - glamourGutter
 
 	renderer, err := glamour.NewTermRenderer(
-		glamour.WithAutoStyle(),
-		glamour.WithWordWrap(glamourRenderWidth),
+		glamour.WithStylePath(e.styles[e.style]),
+		glamour.WithWordWrap(max(10, glamourRenderWidth)),
 	)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	str, err := renderer.Render(content)
+	str, err := renderer.Render(e.source)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	vp.SetContent(str)
+	e.viewport.SetContent(str)
+	e.headings = parseHeadings(e.source)
+	locate(e.headings, str, renderer.Render)
+	e.cursor = max(0, min(e.cursor, len(e.headings)-1))
+	return nil
+}
 
-	return &example{
-		viewport: vp,
-	}, nil
+// resize lays things out for the window, with room for the table of
+// contents if it's showing.
+func (e *example) resize() error {
+	e.viewport.Width = e.width
+	if e.showTOC {
+		e.viewport.Width -= tocWidth
+	}
+	e.viewport.Height = max(3, e.height-2) // help
+	return e.render()
+}
+
+func (e example) check() tea.Cmd {
+	return tea.Tick(pollInterval, func(time.Time) tea.Msg {
+		return checkMsg{}
+	})
+}
+
+// reload renders the file again if it, or the style, changed.
+func (e *example) reload(force bool) {
+	changed := force
+	if e.path != "" {
+		st, err := statFile(e.path)
+		if err != nil {
+			// Some editors save by replacing the file, so it can be gone
+			// for a moment. Keep showing what we had.
+			e.err = err
+			return
+		}
+		if st != e.fileStamp || force {
+			if err := e.load(); err != nil {
+				e.err = err
+				return
+			}
+			changed = true
+		}
+	}
+	if st, err := statFile(e.styles[e.style]); err == nil && st != e.styleStamp {
+		e.styleStamp = st
+		changed = true
+	}
+	if !changed {
+		return
+	}
+	if e.err = e.render(); e.err == nil {
+		e.status = "Reloaded at " + time.Now().Format(time.TimeOnly)
+	}
+}
+
+// jump scrolls to a heading.
+func (e *example) jump(i int) {
+	if i < 0 || i >= len(e.headings) {
+		return
+	}
+	e.cursor = i
+	e.viewport.SetYOffset(e.headings[i].line)
 }
 
 func (e example) Init() tea.Cmd {
-	return nil
+	if e.path == "" {
+		return nil
+	}
+	return e.check()
 }
 
 func (e example) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		e.width, e.height = msg.Width, msg.Height
+		e.err = e.resize()
+		return e, nil
+
+	case checkMsg:
+		e.reload(false)
+		return e, e.check()
+
 	case tea.KeyMsg:
 		switch msg.String() {
-		case "q", "ctrl+c", "esc":
+		case "q", "ctrl+c":
+			return e, tea.Quit
+		case "esc":
+			if e.tocFocused {
+				e.tocFocused = false
+				return e, nil
+			}
 			return e, tea.Quit
-		default:
-			var cmd tea.Cmd
-			e.viewport, cmd = e.viewport.Update(msg)
-			return e, cmd
+		case "t":
+			e.showTOC = !e.showTOC
+			e.tocFocused = e.showTOC
+			e.err = e.resize()
+			e.cursor = max(0, current(e.headings, e.viewport.YOffset))
+			return e, nil
+		case "tab":
+			e.tocFocused = e.showTOC && !e.tocFocused
+			return e, nil
+		case "s":
+			e.style = (e.style + 1) % len(e.styles)
+			e.styleStamp, _ = statFile(e.styles[e.style])
+			if e.err = e.render(); e.err == nil {
+				e.status = "Style: " + e.styles[e.style]
+			}
+			return e, nil
+		case "r":
+			e.reload(true)
+			return e, nil
 		}
-	default:
-		return e, nil
+
+		if e.tocFocused {
+			switch msg.String() {
+			case "up", "k":
+				e.jump(e.cursor - 1)
+			case "down", "j":
+				e.jump(e.cursor + 1)
+			case "home", "g":
+				e.jump(0)
+			case "end", "G":
+				e.jump(len(e.headings) - 1)
+			case "enter":
+				e.jump(e.cursor)
+				e.tocFocused = false
+			}
+			return e, nil
+		}
+
+		switch msg.String() {
+		case "]":
+			e.jump(current(e.headings, e.viewport.YOffset) + 1)
+			return e, nil
+		case "[":
+			// To the start of this section, or the one before if we're
+			// already there.
+			i := current(e.headings, e.viewport.YOffset)
+			if i >= 0 && e.headings[i].line == e.viewport.YOffset {
+				i--
+			}
+			e.jump(max(0, i))
+			return e, nil
+		}
+		var cmd tea.Cmd
+		e.viewport, cmd = e.viewport.Update(msg)
+		return e, cmd
 	}
+	return e, nil
 }
 
 func (e example) View() string {
-	return e.viewport.View() + e.helpView()
+	view := e.viewport.View()
+	if e.showTOC {
+		view = lipgloss.JoinHorizontal(lipgloss.Top, e.tocView(), view)
+	}
+	return view + e.helpView()
+}
+
+func (e example) tocView() string {
+	height := e.viewport.Height - tocStyle.GetVerticalFrameSize()
+	width := tocWidth - tocStyle.GetHorizontalFrameSize()
+
+	var lines []string
+	if len(e.headings) == 0 {
+		lines = append(lines, helpStyle("No headings"))
+	}
+
+	top := 1
+	for _, h := range e.headings {
+		top = min(top, h.level)
+	}
+	cur := current(e.headings, e.viewport.YOffset)
+
+	// Keep the selection, or the section we're in, in view.
+	sel := cur
+	if e.tocFocused {
+		sel = e.cursor
+	}
+	start := max(0, min(sel-height/2, len(e.headings)-height))
+	for i := start; i < min(start+height, len(e.headings)); i++ {
+		h := e.headings[i]
+		indent := strings.Repeat("  ", h.level-top)
+		style := tocHeadingStyle
+		marker := "  "
+		switch {
+		case e.tocFocused && i == e.cursor:
+			style, marker = tocCursorStyle, "› "
+		case i == cur:
+			style = tocCurrentStyle
+		}
+		text := indent + h.text
+		if r := []rune(text); len(r) > width-2 {
+			text = string(r[:width-3]) + "…"
+		}
+		lines = append(lines, marker+style.Render(text))
+	}
+	// Width and height include the padding, but not the border.
+	return tocStyle.
+		Width(tocWidth - tocStyle.GetHorizontalBorderSize()).
+		Height(height).
+		Render(strings.Join(lines, "\n"))
 }
 
 func (e example) helpView() string {
-	return helpStyle("\n  ↑/↓: Navigate • q: Quit\n")
+	var keys string
+	switch {
+	case e.tocFocused:
+		keys = "↑/↓: Jump • enter: Read • tab: Document • s: Style • t: Hide contents • q: Quit"
+	default:
+		keys = "↑/↓: Navigate • [/]: Sections • t: Contents • s: Style • q: Quit"
+	}
+	var status string
+	switch {
+	case e.err != nil:
+		status = "  " + errStyle(e.err.Error())
+	case e.status != "":
+		status = "  " + helpStyle(e.status)
+	}
+	return helpStyle("\n  "+keys) + status + "\n"
 }
 
 func main() {
-	model, err := newExample()
+	style := flag.String("style", glamourstyles.AutoStyle, "glamour style: dark, light, another standard style, or a JSON style file")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [file.md]\n\nShows the file, and reloads it when it changes.\n\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	model, err := newExample(flag.Arg(0), *style)
 	if err != nil {
 		fmt.Println("Could not initialize Bubble Tea model:", err)
 		os.Exit(1)
 	}
 
-	if _, err := tea.NewProgram(model).Run(); err != nil {
+	if _, err := tea.NewProgram(model, tea.WithAltScreen()).Run(); err != nil {
 		fmt.Println("Bummer, there's been an error:", err)
 		os.Exit(1)
 	}
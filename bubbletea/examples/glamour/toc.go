@@ -0,0 +1,120 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// heading is an entry in the table of contents.
+type heading struct {
+	level int
+	text  string
+	md    string // the heading as written
+	line  int    // where it is in the rendered document
+}
+
+var (
+	atxHeading = regexp.MustCompile(`^ {0,3}(#{1,6})[ \t]+(.*?)(?:[ \t]+#+)?[ \t]*$`)
+	mdLink     = regexp.MustCompile(`!?\[([^\]]*)\]\([^)]*\)`)
+)
+
+// parseHeadings finds the headings in markdown, skipping anything in code
+// blocks.
+func parseHeadings(md string) []heading {
+	var headings []heading
+	var fence string
+	for _, line := range strings.Split(md, "\n") {
+		f, rest := fenceOf(line)
+		if fence != "" {
+			// A fence is closed by at least as many of the same marks, and
+			// nothing else.
+			if f != "" && f[0] == fence[0] && len(f) >= len(fence) && rest == "" {
+				fence = ""
+			}
+			continue
+		}
+		if f != "" {
+			fence = f
+			continue
+		}
+		m := atxHeading.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		if text := plain(m[2]); text != "" {
+			headings = append(headings, heading{level: len(m[1]), text: text, md: strings.TrimSpace(line)})
+		}
+	}
+	return headings
+}
+
+// fenceOf returns the code fence marks a line starts with, like "````", and
+// what follows them, or "" if it isn't a fence.
+func fenceOf(line string) (fence, rest string) {
+	trimmed := strings.TrimSpace(line)
+	for _, mark := range []string{"`", "~"} {
+		rest := strings.TrimLeft(trimmed, mark)
+		if n := len(trimmed) - len(rest); n >= 3 {
+			return trimmed[:n], strings.TrimSpace(rest)
+		}
+	}
+	return "", ""
+}
+
+// plain strips the inline markdown from a heading: links become their text,
+// and emphasis and code marks go.
+func plain(s string) string {
+	s = mdLink.ReplaceAllString(s, "$1")
+	s = strings.NewReplacer("**", "", "__", "", "*", "", "`", "").Replace(s)
+	return strings.TrimSpace(s)
+}
+
+// locate finds where each heading ended up in the rendered document. Styles
+// decorate headings as they like, so each one is rendered on its own the
+// same way, and we look for a line that's the same as its first, in order.
+// That way a contents list naming the headings isn't mistaken for them. Any
+// we can't find are put with the heading before them.
+func locate(headings []heading, rendered string, render func(md string) (string, error)) {
+	lines := strings.Split(ansi.Strip(rendered), "\n")
+	at, from := 0, 0
+	for i := range headings {
+		first := firstLine(headings[i].md, render)
+		for j := from; first != "" && j < len(lines); j++ {
+			if strings.TrimSpace(lines[j]) == first {
+				at, from = j, j+1
+				break
+			}
+		}
+		headings[i].line = at
+	}
+}
+
+// firstLine is the first line of text a heading renders to, or "" if it
+// can't be rendered.
+func firstLine(md string, render func(md string) (string, error)) string {
+	out, err := render(md)
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(ansi.Strip(out), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// current is the heading of the section at the top of the screen, or -1
+// before the first one.
+func current(headings []heading, offset int) int {
+	cur := -1
+	for i, h := range headings {
+		if h.line > offset {
+			break
+		}
+		cur = i
+	}
+	return cur
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/x/ansi"
+)
+
+const doc = "# Guide\n\n" +
+	"Intro.\n\n" +
+	"## Install **it**\n\n" +
+	"```sh\n# not a heading\n```\n\n" +
+	"````md\n```\n# still not a heading\n````\n\n" +
+	"## See [the docs](https://example.com) ##\n\n" +
+	"### `flags`\n"
+
+func TestParseHeadings(t *testing.T) {
+	var got []string
+	for _, h := range parseHeadings(doc) {
+		got = append(got, fmt.Sprintf("%d %s", h.level, h.text))
+	}
+	want := []string{"1 Guide", "2 Install it", "2 See the docs", "3 flags"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestLocate(t *testing.T) {
+	// Headings render as themselves, apart from the one that can't be.
+	render := func(md string) (string, error) {
+		if md == "# Missing" {
+			return "", errors.New("nope")
+		}
+		return "\n\x1b[1m" + md + "\x1b[0m\n", nil
+	}
+	headings := []heading{{md: "# Intro"}, {md: "# Missing"}, {md: "# Intro"}}
+	locate(headings, "\x1b[1m# Intro\x1b[0m\ntext\n\n  # Intro  ", render)
+	for i, want := range []int{0, 0, 3} {
+		if headings[i].line != want {
+			t.Errorf("heading %d on line %d, want %d", i, headings[i].line, want)
+		}
+	}
+	if got := current(headings, 2); got != 1 {
+		t.Errorf("current = %d, want 1", got)
+	}
+}
+
+func TestLocateContents(t *testing.T) {
+	const md = "# Guide\n\n## Contents\n\n- [Install](#install)\n- [Usage](#usage)\n\n" +
+		"## Install\n\nRun it.\n\n## Usage\n\nUse it.\n"
+	for _, style := range []string{"dark", "notty", "pink"} {
+		r, err := glamour.NewTermRenderer(glamour.WithStylePath(style), glamour.WithWordWrap(40))
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, err := r.Render(md)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.Split(ansi.Strip(out), "\n")
+		headings := parseHeadings(md)
+		locate(headings, out, r.Render)
+
+		// The contents list names the headings, but they're further down.
+		for i, h := range headings {
+			if i > 0 && h.line <= headings[i-1].line {
+				t.Errorf("%s: %q on line %d, after the one before it", style, h.text, h.line)
+			}
+			if line := lines[h.line]; !strings.Contains(line, h.text) || strings.Contains(line, "•") {
+				t.Errorf("%s: %q is on line %q", style, h.text, line)
+			}
+		}
+	}
+}
+
+// longDoc has enough sections to scroll.
+func longDoc(intro string) string {
+	var b strings.Builder
+	b.WriteString(intro + "\n\n")
+	for i := range 10 {
+		fmt.Fprintf(&b, "## Section %d\n\n%s\n\n", i, strings.Repeat("Some text.\n\n", 5))
+	}
+	return b.String()
+}
+
+func TestReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "doc.md")
+	if err := os.WriteFile(path, []byte(longDoc("Hello")), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	e, err := newExample(path, "dark")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m tea.Model = *e
+	m, _ = m.Update(tea.WindowSizeMsg{Width: 80, Height: 20})
+
+	// Jump to a section from the table of contents.
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'t'}})
+	for range 3 {
+		m, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	}
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	e2 := m.(example)
+	if e2.tocFocused || e2.headings[e2.cursor].text != "Section 3" {
+		t.Fatalf("selected %q", e2.headings[e2.cursor].text)
+	}
+	offset := e2.viewport.YOffset
+	if offset == 0 || !strings.Contains(ansi.Strip(e2.viewport.View()), "Section 3") {
+		t.Fatalf("didn't scroll to section 3:\n%s", e2.viewport.View())
+	}
+
+	// The file changes, and we stay where we were.
+	if err := os.WriteFile(path, []byte(longDoc("Hello again")), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	m, _ = m.Update(checkMsg{})
+	e2 = m.(example)
+	if !strings.Contains(e2.status, "Reloaded") || e2.viewport.YOffset != offset {
+		t.Errorf("after reloading: status %q, offset %d, want %d", e2.status, e2.viewport.YOffset, offset)
+	}
+	e2.viewport.GotoTop()
+	if !strings.Contains(ansi.Strip(e2.viewport.View()), "Hello again") {
+		t.Error("didn't pick up the change")
+	}
+}
+
+func TestStyles(t *testing.T) {
+	custom := filepath.Join(t.TempDir(), "style.json")
+	if err := os.WriteFile(custom, []byte(`{"heading": {"prefix": ">> "}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	e, err := newExample("", custom)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := e.styles; len(got) != 3 || e.style != 2 {
+		t.Fatalf("styles = %v, using %d", got, e.style)
+	}
+	if !strings.Contains(ansi.Strip(e.viewport.View()), ">> Appetizers") {
+		t.Errorf("custom style wasn't used:\n%s", e.viewport.View())
+	}
+
+	var m tea.Model = *e
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'s'}})
+	if got := m.(example); got.styles[got.style] != "dark" || got.status != "Style: dark" {
+		t.Errorf("switched to %q", got.styles[got.style])
+	}
+}
@@ -14,18 +14,19 @@ import (
 // keyMap defines a set of keybindings. To work for help it must satisfy
 // key.Map. It could also very easily be a map[string]key.Binding.
 type keyMap struct {
-	Up    key.Binding
-	Down  key.Binding
-	Left  key.Binding
-	Right key.Binding
-	Help  key.Binding
-	Quit  key.Binding
+	Up      key.Binding
+	Down    key.Binding
+	Left    key.Binding
+	Right   key.Binding
+	Palette key.Binding
+	Help    key.Binding
+	Quit    key.Binding
 }
 
 // ShortHelp returns keybindings to be shown in the mini help view. It's part
 // of the key.Map interface.
 func (k keyMap) ShortHelp() []key.Binding {
-	return []key.Binding{k.Help, k.Quit}
+	return []key.Binding{k.Palette, k.Help, k.Quit}
 }
 
 // FullHelp returns keybindings for the expanded help view. It's part of the
@@ -33,7 +34,7 @@ func (k keyMap) ShortHelp() []key.Binding {
 func (k keyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
 		{k.Up, k.Down, k.Left, k.Right}, // first column
-		{k.Help, k.Quit},                // second column
+		{k.Palette, k.Help, k.Quit},     // second column
 	}
 }
 
@@ -54,6 +55,10 @@ var keys = keyMap{
 		key.WithKeys("right", "l"),
 		key.WithHelp("→/l", "move right"),
 	),
+	Palette: key.NewBinding(
+		key.WithKeys("ctrl+p"),
+		key.WithHelp("ctrl+p", "command palette"),
+	),
 	Help: key.NewBinding(
 		key.WithKeys("?"),
 		key.WithHelp("?", "toggle help"),
@@ -67,6 +72,7 @@ var keys = keyMap{
 type model struct {
 	keys       keyMap
 	help       help.Model
+	palette    palette
 	inputStyle lipgloss.Style
 	lastKey    string
 	quitting   bool
@@ -74,8 +80,11 @@ type model struct {
 
 func newModel() model {
 	return model{
-		keys:       keys,
-		help:       help.New(),
+		keys: keys,
+		help: help.New(),
+		// The palette gets its commands from the same key map as the help,
+		// so anything with help text can be found there too.
+		palette:    newPalette(commandsFromKeyMap(keys, keys.Palette)),
 		inputStyle: lipgloss.NewStyle().Foreground(lipgloss.Color("#FF75B7")),
 	}
 }
@@ -85,6 +94,15 @@ func (m model) Init() tea.Cmd {
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	// While the palette is open it gets all the keys.
+	if m.palette.active {
+		if _, ok := msg.(tea.KeyMsg); ok {
+			var cmd tea.Cmd
+			m.palette, cmd = m.palette.Update(msg)
+			return m, cmd
+		}
+	}
+
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		// If we set a width on the help menu it can gracefully truncate
@@ -101,6 +119,10 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.lastKey = "←"
 		case key.Matches(msg, m.keys.Right):
 			m.lastKey = "→"
+		case key.Matches(msg, m.keys.Palette):
+			var cmd tea.Cmd
+			m.palette, cmd = m.palette.Open()
+			return m, cmd
 		case key.Matches(msg, m.keys.Help):
 			m.help.ShowAll = !m.help.ShowAll
 		case key.Matches(msg, m.keys.Quit):
@@ -109,7 +131,9 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 	}
 
-	return m, nil
+	var cmd tea.Cmd
+	m.palette, cmd = m.palette.Update(msg)
+	return m, cmd
 }
 
 func (m model) View() string {
@@ -124,6 +148,10 @@ func (m model) View() string {
 		status = "You chose: " + m.inputStyle.Render(m.lastKey)
 	}
 
+	if m.palette.active {
+		return "\n" + status + "\n\n" + m.palette.View() + "\n"
+	}
+
 	helpView := m.help.View(m.keys)
 	height := 8 - strings.Count(status, "\n") - strings.Count(helpView, "\n")
 
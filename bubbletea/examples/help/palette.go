@@ -0,0 +1,294 @@
+package main
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/sahilm/fuzzy"
+)
+
+const (
+	maxRecent      = 5
+	paletteHeight  = 8
+	paletteWidth   = 44
+	recentHeader   = "recently used"
+	commandsHeader = "commands"
+)
+
+var (
+	paletteStyle = lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("63")).
+			Padding(0, 1)
+	paletteHeaderStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Italic(true)
+	paletteKeyStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	paletteMatchStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF75B7")).Bold(true)
+	paletteCursorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF75B7"))
+)
+
+// command is an entry in the palette. Running it sends msg.
+type command struct {
+	binding key.Binding
+	msg     tea.Msg
+}
+
+func (c command) title() string { return c.binding.Help().Desc }
+
+// keyTypes maps key names, like "ctrl+c" or "up", back to their key types,
+// so that we can press keys on the user's behalf.
+var keyTypes = func() map[string]tea.KeyType {
+	m := map[string]tea.KeyType{}
+	for t := tea.KeyType(-100); t <= tea.KeyBackspace; t++ {
+		if t == tea.KeyRunes {
+			continue
+		}
+		if s := (tea.Key{Type: t}).String(); s != "" {
+			m[s] = t
+		}
+	}
+	return m
+}()
+
+// keyMsg returns the message for pressing a key given the way key.Binding
+// names it.
+func keyMsg(s string) (tea.KeyMsg, bool) {
+	var alt bool
+	if rest, ok := strings.CutPrefix(s, "alt+"); ok && rest != "" {
+		alt, s = true, rest
+	}
+	if t, ok := keyTypes[s]; ok {
+		return tea.KeyMsg{Type: t, Alt: alt}, true
+	}
+	if r := []rune(s); len(r) == 1 {
+		return tea.KeyMsg{Type: tea.KeyRunes, Runes: r, Alt: alt}, true
+	}
+	return tea.KeyMsg{}, false
+}
+
+// commandsFromKeyMap builds the palette from the bindings in a key map. Each
+// command presses the binding's first key, so the program handles it just
+// like it would if the user typed it. Bindings without help, and disabled
+// ones, are left out, as are any in skip.
+func commandsFromKeyMap(km help.KeyMap, skip ...key.Binding) []command {
+	var cmds []command
+	seen := map[string]bool{}
+	for _, col := range km.FullHelp() {
+	bindings:
+		for _, b := range col {
+			if !b.Enabled() || b.Help().Desc == "" || len(b.Keys()) == 0 || seen[b.Help().Desc] {
+				continue
+			}
+			for _, s := range skip {
+				if slices.Equal(s.Keys(), b.Keys()) {
+					continue bindings
+				}
+			}
+			msg, ok := keyMsg(b.Keys()[0])
+			if !ok {
+				continue
+			}
+			seen[b.Help().Desc] = true
+			cmds = append(cmds, command{binding: b, msg: msg})
+		}
+	}
+	return cmds
+}
+
+// paletteEntry is a line in the palette: a command, with the characters
+// matching the query, or a section header.
+type paletteEntry struct {
+	cmd     int // index into palette.commands, or -1 for a header
+	header  string
+	matched []int
+}
+
+type commandTitles []command
+
+func (c commandTitles) String(i int) string { return c[i].title() }
+func (c commandTitles) Len() int            { return len(c) }
+
+// palette is a fuzzy finder over commands.
+type palette struct {
+	input    textinput.Model
+	commands []command
+	recent   []string // titles, most recent first
+	entries  []paletteEntry
+	cursor   int // into entries, never on a header
+	active   bool
+}
+
+func newPalette(commands []command) palette {
+	ti := textinput.New()
+	ti.Prompt = "> "
+	ti.Placeholder = "Type a command"
+	ti.PromptStyle = paletteCursorStyle
+	ti.Width = paletteWidth - 6
+	return palette{input: ti, commands: commands}
+}
+
+// Open shows the palette with an empty query.
+func (p palette) Open() (palette, tea.Cmd) {
+	p.active = true
+	p.input.Reset()
+	p.refresh()
+	return p, p.input.Focus()
+}
+
+func (p palette) Update(msg tea.Msg) (palette, tea.Cmd) {
+	if !p.active {
+		return p, nil
+	}
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "esc", "ctrl+c":
+			p.active = false
+			p.input.Blur()
+			return p, nil
+		case "enter":
+			return p.run()
+		case "up", "ctrl+p", "shift+tab":
+			p.move(-1)
+			return p, nil
+		case "down", "ctrl+n", "tab":
+			p.move(1)
+			return p, nil
+		}
+	}
+
+	prev := p.input.Value()
+	var cmd tea.Cmd
+	p.input, cmd = p.input.Update(msg)
+	if p.input.Value() != prev {
+		p.refresh()
+	}
+	return p, cmd
+}
+
+// run closes the palette and sends the message for the command under the
+// cursor.
+func (p palette) run() (palette, tea.Cmd) {
+	p.active = false
+	p.input.Blur()
+	if p.cursor >= len(p.entries) || p.entries[p.cursor].cmd < 0 {
+		return p, nil
+	}
+	c := p.commands[p.entries[p.cursor].cmd]
+
+	title := c.title()
+	p.recent = slices.DeleteFunc(p.recent, func(s string) bool { return s == title })
+	p.recent = slices.Insert(p.recent, 0, title)
+	if len(p.recent) > maxRecent {
+		p.recent = p.recent[:maxRecent]
+	}
+
+	msg := c.msg
+	return p, func() tea.Msg { return msg }
+}
+
+// refresh lists the commands matching the query. With no query, recently
+// used commands come first.
+func (p *palette) refresh() {
+	p.entries = p.entries[:0]
+	p.cursor = 0
+
+	if q := p.input.Value(); q != "" {
+		for _, m := range fuzzy.FindFrom(q, commandTitles(p.commands)) {
+			p.entries = append(p.entries, paletteEntry{cmd: m.Index, matched: m.MatchedIndexes})
+		}
+		return
+	}
+
+	used := map[int]bool{}
+	if len(p.recent) > 0 {
+		p.entries = append(p.entries, paletteEntry{cmd: -1, header: recentHeader})
+		for _, title := range p.recent {
+			if i := slices.IndexFunc(p.commands, func(c command) bool { return c.title() == title }); i >= 0 {
+				p.entries = append(p.entries, paletteEntry{cmd: i})
+				used[i] = true
+			}
+		}
+		p.entries = append(p.entries, paletteEntry{cmd: -1, header: commandsHeader})
+	}
+	for i := range p.commands {
+		if !used[i] {
+			p.entries = append(p.entries, paletteEntry{cmd: i})
+		}
+	}
+	p.move(0)
+}
+
+// move moves the cursor by n commands, skipping headers.
+func (p *palette) move(n int) {
+	if len(p.entries) == 0 {
+		return
+	}
+	step := 1
+	if n < 0 {
+		step, n = -1, -n
+	}
+	c := p.cursor
+	for {
+		for c >= 0 && c < len(p.entries) && p.entries[c].cmd < 0 {
+			c += step
+		}
+		if n == 0 || c < 0 || c >= len(p.entries) {
+			break
+		}
+		c += step
+		n--
+	}
+	if c >= 0 && c < len(p.entries) {
+		p.cursor = c
+	}
+}
+
+func (p palette) View() string {
+	var b strings.Builder
+	b.WriteString(p.input.View())
+
+	// Keep the cursor in view.
+	start := max(0, p.cursor-paletteHeight+1)
+	end := min(len(p.entries), start+paletteHeight)
+	if len(p.entries) == 0 {
+		b.WriteString("\n" + paletteHeaderStyle.Render("no matching commands"))
+	}
+	for i := start; i < end; i++ {
+		e := p.entries[i]
+		b.WriteString("\n")
+		if e.cmd < 0 {
+			b.WriteString(paletteHeaderStyle.Render(e.header))
+			continue
+		}
+		c := p.commands[e.cmd]
+		cursor := "  "
+		if i == p.cursor {
+			cursor = paletteCursorStyle.Render("▸ ")
+		}
+		title := highlight(c.title(), e.matched)
+		keys := paletteKeyStyle.Render(c.binding.Help().Key)
+		gap := max(1, paletteWidth-6-lipgloss.Width(title)-lipgloss.Width(keys))
+		b.WriteString(cursor + title + strings.Repeat(" ", gap) + keys)
+	}
+	return paletteStyle.Render(b.String())
+}
+
+// highlight styles the characters of s at the given byte offsets.
+func highlight(s string, matched []int) string {
+	if len(matched) == 0 {
+		return s
+	}
+	var b strings.Builder
+	for i, r := range s {
+		if slices.Contains(matched, i) {
+			b.WriteString(paletteMatchStyle.Render(string(r)))
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
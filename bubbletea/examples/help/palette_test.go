@@ -0,0 +1,102 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestKeyMsg(t *testing.T) {
+	for _, s := range []string{"up", "ctrl+c", "esc", "?", "q", "alt+x", "shift+tab", " "} {
+		msg, ok := keyMsg(s)
+		if !ok {
+			t.Errorf("keyMsg(%q) failed", s)
+			continue
+		}
+		if got := msg.String(); got != s {
+			t.Errorf("keyMsg(%q) is %q", s, got)
+		}
+	}
+	if _, ok := keyMsg("not a key"); ok {
+		t.Error(`keyMsg("not a key") succeeded`)
+	}
+}
+
+func TestCommandsFromKeyMap(t *testing.T) {
+	cmds := commandsFromKeyMap(keys, keys.Palette)
+	var titles []string
+	for _, c := range cmds {
+		titles = append(titles, c.title())
+	}
+	want := "move up, move down, move left, move right, toggle help, quit"
+	if got := strings.Join(titles, ", "); got != want {
+		t.Errorf("commands = %s, want %s", got, want)
+	}
+}
+
+// press sends keys to the model. When one runs a command from the palette,
+// the message it sends is fed back in. Other commands, like the cursor
+// blinking, are ignored.
+func press(m tea.Model, msgs ...tea.KeyMsg) tea.Model {
+	for _, msg := range msgs {
+		wasOpen := m.(model).palette.active
+		var cmd tea.Cmd
+		m, cmd = m.Update(msg)
+		if wasOpen && !m.(model).palette.active && cmd != nil {
+			m, _ = m.Update(cmd())
+		}
+	}
+	return m
+}
+
+func typed(s string) []tea.KeyMsg {
+	var msgs []tea.KeyMsg
+	for _, r := range s {
+		msgs = append(msgs, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	return msgs
+}
+
+func TestPalette(t *testing.T) {
+	var m tea.Model = newModel()
+	open := tea.KeyMsg{Type: tea.KeyCtrlP}
+	enter := tea.KeyMsg{Type: tea.KeyEnter}
+
+	m = press(m, open)
+	m = press(m, typed("mvlft")...)
+	if v := m.View(); !strings.Contains(v, "move left") || strings.Contains(v, "move right") {
+		t.Fatalf("palette doesn't show just the match:\n%s", v)
+	}
+	m = press(m, enter)
+	if got := m.(model).lastKey; got != "←" {
+		t.Errorf("lastKey = %q after running move left, want ←", got)
+	}
+	if m.(model).palette.active {
+		t.Error("palette still open after running a command")
+	}
+
+	// The help toggle runs through the palette too, and both commands are
+	// remembered, most recent first.
+	m = press(m, open)
+	m = press(m, typed("help")...)
+	m = press(m, enter)
+	if !m.(model).help.ShowAll {
+		t.Error("toggle help from the palette didn't show the full help")
+	}
+	m = press(m, open)
+	p := m.(model).palette
+	if p.entries[0].header != recentHeader || p.commands[p.entries[1].cmd].title() != "toggle help" ||
+		p.commands[p.entries[2].cmd].title() != "move left" {
+		t.Errorf("recent commands not listed first: %+v", p.entries)
+	}
+	if p.cursor != 1 {
+		t.Errorf("cursor = %d, want on the first recent command", p.cursor)
+	}
+
+	// Escape closes the palette without quitting.
+	m = press(m, tea.KeyMsg{Type: tea.KeyEsc})
+	if m.(model).palette.active || m.(model).quitting {
+		t.Error("escape didn't just close the palette")
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	defaultTimeout  = 10 * time.Second
+	defaultInterval = 30 * time.Second
+)
+
+// endpoint is something to keep an eye on.
+type endpoint struct {
+	Name     string
+	URL      string
+	Method   string
+	Expect   int // the status code we want back
+	Timeout  time.Duration
+	Interval time.Duration // time between checks
+}
+
+// fileEndpoint is an endpoint as it's written in the config file. Durations
+// are strings like "5s" so that JSON and YAML read the same.
+type fileEndpoint struct {
+	Name     string `json:"name" yaml:"name"`
+	URL      string `json:"url" yaml:"url"`
+	Method   string `json:"method" yaml:"method"`
+	Expect   int    `json:"expect" yaml:"expect"`
+	Timeout  string `json:"timeout" yaml:"timeout"`
+	Interval string `json:"interval" yaml:"interval"`
+}
+
+// fileConfig is the config file:
+//
+//	defaults:
+//	  timeout: 5s
+//	  interval: 30s
+//	endpoints:
+//	  - name: charm
+//	    url: https://charm.sh/
+//	    expect: 200
+//
+// Anything an endpoint leaves out comes from the defaults: GET, a 200, and
+// the timeout and interval above.
+type fileConfig struct {
+	Defaults  fileEndpoint   `json:"defaults" yaml:"defaults"`
+	Endpoints []fileEndpoint `json:"endpoints" yaml:"endpoints"`
+}
+
+// loadConfig reads endpoints from a YAML or JSON file, going by its
+// extension.
+func loadConfig(path string) ([]endpoint, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	endpoints, err := parseConfig(b, filepath.Ext(path))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return endpoints, nil
+}
+
+func parseConfig(b []byte, ext string) ([]endpoint, error) {
+	var fc fileConfig
+	switch strings.ToLower(ext) {
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&fc); err != nil {
+			return nil, err
+		}
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(b))
+		dec.KnownFields(true)
+		if err := dec.Decode(&fc); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown config format %q, want .yaml, .yml or .json", ext)
+	}
+	if len(fc.Endpoints) == 0 {
+		return nil, errors.New("no endpoints")
+	}
+
+	def, err := fc.Defaults.resolve(endpoint{
+		Method:   http.MethodGet,
+		Expect:   http.StatusOK,
+		Timeout:  defaultTimeout,
+		Interval: defaultInterval,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("defaults: %w", err)
+	}
+
+	endpoints := make([]endpoint, 0, len(fc.Endpoints))
+	for i, fe := range fc.Endpoints {
+		e, err := fe.resolve(def)
+		if err != nil {
+			return nil, fmt.Errorf("endpoint %d: %w", i+1, err)
+		}
+		if e.URL == "" {
+			return nil, fmt.Errorf("endpoint %d: no url", i+1)
+		}
+		if u, err := neturl.Parse(e.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return nil, fmt.Errorf("endpoint %d: %q isn't an http or https url", i+1, e.URL)
+		}
+		if e.Name == "" {
+			e.Name = e.URL
+		}
+		endpoints = append(endpoints, e)
+	}
+	return endpoints, nil
+}
+
+// resolve fills in what the file left out from def.
+func (fe fileEndpoint) resolve(def endpoint) (endpoint, error) {
+	e := def
+	e.Name, e.URL = fe.Name, fe.URL
+	if fe.Method != "" {
+		e.Method = strings.ToUpper(fe.Method)
+	}
+	if fe.Expect != 0 {
+		e.Expect = fe.Expect
+	}
+	for _, d := range []struct {
+		name string
+		s    string
+		to   *time.Duration
+	}{
+		{"timeout", fe.Timeout, &e.Timeout},
+		{"interval", fe.Interval, &e.Interval},
+	} {
+		if d.s == "" {
+			continue
+		}
+		v, err := time.ParseDuration(d.s)
+		if err != nil {
+			return e, fmt.Errorf("bad %s: %w", d.name, err)
+		}
+		if v <= 0 {
+			return e, fmt.Errorf("%s has to be more than zero", d.name)
+		}
+		*d.to = v
+	}
+	return e, nil
+}
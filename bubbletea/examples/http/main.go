@@ -1,11 +1,16 @@
 package main
 
 // A simple program that makes a GET request and prints the response status.
+//
+// Give it a config file with -config and it keeps an eye on a list of
+// endpoints instead. See endpoints.yaml for an example.
 
 import (
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -25,6 +30,14 @@ type errMsg struct{ error }
 func (e errMsg) Error() string { return e.error.Error() }
 
 func main() {
+	configPath := flag.String("config", "", "YAML or JSON file listing endpoints to monitor")
+	once := flag.Bool("once", false, "check the endpoints once, print a report and exit non-zero if any failed")
+	flag.Parse()
+
+	if *configPath != "" {
+		os.Exit(monitor(*configPath, *once))
+	}
+
 	p := tea.NewProgram(model{})
 	if _, err := p.Run(); err != nil {
 		log.Fatal(err)
@@ -80,3 +93,28 @@ func checkServer() tea.Msg {
 
 	return statusMsg(res.StatusCode)
 }
+
+// monitor runs the dashboard, or just checks once with -once.
+func monitor(configPath string, once bool) int {
+	endpoints, err := loadConfig(configPath)
+	if err != nil {
+		fmt.Println("Error reading config:", err)
+		return 2
+	}
+	// Timeouts are per endpoint, so the client doesn't need one.
+	client := &http.Client{}
+
+	if once {
+		if failed := runOnce(os.Stdout, client, endpoints); failed > 0 {
+			fmt.Printf("%d of %d checks failed\n", failed, len(endpoints))
+			return 1
+		}
+		return 0
+	}
+
+	if _, err := tea.NewProgram(newDashboard(client, endpoints)).Run(); err != nil {
+		fmt.Println("Error running program:", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,312 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+)
+
+// historySize is how many results we keep for each endpoint.
+const historySize = 30
+
+var (
+	titleStyle  = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("63"))
+	upStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	downStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("203"))
+	faintStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	headerStyle = lipgloss.NewStyle().Bold(true).Padding(0, 1)
+	cellStyle   = lipgloss.NewStyle().Padding(0, 1)
+)
+
+// result is the outcome of checking an endpoint once.
+type result struct {
+	at      time.Time
+	status  int
+	latency time.Duration
+	err     error
+}
+
+func (r result) ok() bool { return r.err == nil }
+
+// check requests an endpoint and sees whether it answers with the status
+// we expect in time.
+func check(ctx context.Context, client *http.Client, e endpoint) result {
+	ctx, cancel := context.WithTimeout(ctx, e.Timeout)
+	defer cancel()
+
+	r := result{at: time.Now()}
+	req, err := http.NewRequestWithContext(ctx, e.Method, e.URL, nil)
+	if err != nil {
+		r.err = err
+		return r
+	}
+	res, err := client.Do(req)
+	r.latency = time.Since(r.at)
+	if err != nil {
+		r.err = err
+		return r
+	}
+	// Read the body so the connection can be reused.
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 1<<20))
+	_ = res.Body.Close()
+
+	r.status = res.StatusCode
+	if res.StatusCode != e.Expect {
+		r.err = fmt.Errorf("got %d %s, want %d", res.StatusCode, http.StatusText(res.StatusCode), e.Expect)
+	}
+	return r
+}
+
+// checkAll checks every endpoint at once and returns the results in the same
+// order.
+func checkAll(ctx context.Context, client *http.Client, endpoints []endpoint) []result {
+	results := make([]result, len(endpoints))
+	var wg sync.WaitGroup
+	for i, e := range endpoints {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = check(ctx, client, e)
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// runOnce checks every endpoint once and writes a report, for scripts and
+// CI. It returns the number of failed checks.
+func runOnce(w io.Writer, client *http.Client, endpoints []endpoint) int {
+	failed := 0
+	for i, r := range checkAll(context.Background(), client, endpoints) {
+		e := endpoints[i]
+		if r.ok() {
+			fmt.Fprintf(w, "ok    %s %s %d %s\n", e.Name, e.URL, r.status, r.latency.Round(time.Millisecond))
+			continue
+		}
+		failed++
+		fmt.Fprintf(w, "FAIL  %s %s: %v\n", e.Name, e.URL, r.err)
+	}
+	return failed
+}
+
+// history is the recent results for an endpoint, plus running totals for
+// uptime.
+type history struct {
+	results    []result // oldest first
+	checks, up int
+}
+
+func (h *history) add(r result) {
+	h.results = append(h.results, r)
+	if len(h.results) > historySize {
+		h.results = h.results[1:]
+	}
+	h.checks++
+	if r.ok() {
+		h.up++
+	}
+}
+
+func (h history) last() (result, bool) {
+	if len(h.results) == 0 {
+		return result{}, false
+	}
+	return h.results[len(h.results)-1], true
+}
+
+func (h history) uptime() float64 {
+	if h.checks == 0 {
+		return 0
+	}
+	return 100 * float64(h.up) / float64(h.checks)
+}
+
+var sparks = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline draws the latencies of the recent checks, scaled to the slowest
+// one. Failed checks show as a gap.
+func sparkline(results []result) string {
+	var most time.Duration
+	for _, r := range results {
+		if r.ok() {
+			most = max(most, r.latency)
+		}
+	}
+	var b strings.Builder
+	for _, r := range results {
+		if !r.ok() {
+			b.WriteRune(' ')
+			continue
+		}
+		i := 0
+		if most > 0 {
+			i = int(int64(len(sparks)-1) * int64(r.latency) / int64(most))
+		}
+		b.WriteRune(sparks[i])
+	}
+	return b.String()
+}
+
+type (
+	// pollMsg says it's time to check an endpoint again.
+	pollMsg struct {
+		index, gen int
+	}
+	resultMsg struct {
+		index  int
+		result result
+	}
+)
+
+// dashboard polls endpoints, each on its own interval, and shows how they're
+// doing.
+type dashboard struct {
+	client    *http.Client
+	endpoints []endpoint
+	history   []history
+	checking  []bool
+	// gen counts the results for each endpoint. A poll scheduled before
+	// the latest result is stale, which keeps checking now from starting a
+	// second round of polling.
+	gen []int
+}
+
+func newDashboard(client *http.Client, endpoints []endpoint) dashboard {
+	return dashboard{
+		client:    client,
+		endpoints: endpoints,
+		history:   make([]history, len(endpoints)),
+		checking:  make([]bool, len(endpoints)),
+		gen:       make([]int, len(endpoints)),
+	}
+}
+
+func (m dashboard) checkCmd(i int) tea.Cmd {
+	client, e := m.client, m.endpoints[i]
+	return func() tea.Msg {
+		return resultMsg{i, check(context.Background(), client, e)}
+	}
+}
+
+func (m dashboard) Init() tea.Cmd {
+	cmds := make([]tea.Cmd, len(m.endpoints))
+	for i := range m.endpoints {
+		cmds[i] = func() tea.Msg { return pollMsg{index: i} }
+	}
+	return tea.Batch(cmds...)
+}
+
+func (m dashboard) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "ctrl+c", "esc":
+			return m, tea.Quit
+		case "r":
+			// Check everything now, on top of the regular polling.
+			var cmds []tea.Cmd
+			for i := range m.endpoints {
+				if !m.checking[i] {
+					m.checking[i] = true
+					cmds = append(cmds, m.checkCmd(i))
+				}
+			}
+			return m, tea.Batch(cmds...)
+		}
+
+	case pollMsg:
+		if msg.gen != m.gen[msg.index] || m.checking[msg.index] {
+			return m, nil
+		}
+		m.checking[msg.index] = true
+		return m, m.checkCmd(msg.index)
+
+	case resultMsg:
+		i := msg.index
+		m.history[i].add(msg.result)
+		m.checking[i] = false
+		m.gen[i]++
+		gen := m.gen[i]
+		return m, tea.Tick(m.endpoints[i].Interval, func(time.Time) tea.Msg {
+			return pollMsg{i, gen}
+		})
+	}
+	return m, nil
+}
+
+func (m dashboard) View() string {
+	rows := make([][]string, len(m.endpoints))
+	failing := 0
+	for i, e := range m.endpoints {
+		h := m.history[i]
+		status, latency := faintStyle.Render("…"), ""
+		if r, ok := h.last(); ok {
+			latency = r.latency.Round(time.Millisecond).String()
+			switch {
+			case r.ok():
+				status = upStyle.Render(fmt.Sprintf("▲ %d", r.status))
+			case r.status != 0:
+				status = downStyle.Render(fmt.Sprintf("▼ %d", r.status))
+				failing++
+			default:
+				status = downStyle.Render("▼ error")
+				failing++
+			}
+		}
+		if m.checking[i] {
+			status += faintStyle.Render(" ⟳")
+		}
+
+		var dots strings.Builder
+		for _, r := range h.results {
+			if r.ok() {
+				dots.WriteString(upStyle.Render("●"))
+			} else {
+				dots.WriteString(downStyle.Render("●"))
+			}
+		}
+
+		uptime := ""
+		if h.checks > 0 {
+			uptime = fmt.Sprintf("%.1f%%", h.uptime())
+		}
+		rows[i] = []string{e.Name, status, latency, sparkline(h.results), dots.String(), uptime}
+	}
+
+	t := table.New().
+		Border(lipgloss.RoundedBorder()).
+		BorderStyle(faintStyle).
+		Headers("Endpoint", "Status", "Latency", "Latency history", "Checks", "Uptime").
+		Rows(rows...).
+		StyleFunc(func(row, _ int) lipgloss.Style {
+			if row == table.HeaderRow {
+				return headerStyle
+			}
+			return cellStyle
+		})
+
+	summary := upStyle.Render(fmt.Sprintf("All %d endpoints up", len(m.endpoints)))
+	if failing > 0 {
+		summary = downStyle.Render(fmt.Sprintf("%d of %d endpoints failing", failing, len(m.endpoints)))
+	}
+
+	var details strings.Builder
+	for i, e := range m.endpoints {
+		if r, ok := m.history[i].last(); ok && !r.ok() {
+			fmt.Fprintf(&details, "%s %s: %v\n", downStyle.Render("●"), e.Name, r.err)
+		}
+	}
+
+	return titleStyle.Render("Endpoint health") + "  " + summary + "\n" +
+		t.Render() + "\n" +
+		details.String() +
+		faintStyle.Render("r: check now • q: quit") + "\n"
+}
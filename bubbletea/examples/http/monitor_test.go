@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/created", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	})
+	mux.HandleFunc("/broken", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	})
+	s := httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestParseConfig(t *testing.T) {
+	yamlConfig := `
+defaults:
+  timeout: 2s
+endpoints:
+  - url: https://example.com/
+  - name: api
+    url: https://example.com/api
+    method: post
+    expect: 201
+    interval: 1m
+`
+	jsonConfig := `{
+	"defaults": {"timeout": "2s"},
+	"endpoints": [
+		{"url": "https://example.com/"},
+		{"name": "api", "url": "https://example.com/api", "method": "post", "expect": 201, "interval": "1m"}
+	]
+}`
+	want := []endpoint{
+		{Name: "https://example.com/", URL: "https://example.com/", Method: "GET", Expect: 200, Timeout: 2 * time.Second, Interval: defaultInterval},
+		{Name: "api", URL: "https://example.com/api", Method: "POST", Expect: 201, Timeout: 2 * time.Second, Interval: time.Minute},
+	}
+	for ext, src := range map[string]string{".yaml": yamlConfig, ".json": jsonConfig} {
+		got, err := parseConfig([]byte(src), ext)
+		if err != nil {
+			t.Errorf("%s: %v", ext, err)
+			continue
+		}
+		if len(got) != len(want) {
+			t.Errorf("%s: got %d endpoints, want %d", ext, len(got), len(want))
+			continue
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("%s: endpoint %d = %+v, want %+v", ext, i, got[i], want[i])
+			}
+		}
+	}
+
+	for _, bad := range []string{
+		`endpoints: []`,
+		`endpoints: [{url: "ftp://example.com"}]`,
+		`endpoints: [{url: "https://example.com", timeout: soon}]`,
+		`endpoints: [{url: "https://example.com", tiemout: 1s}]`,
+	} {
+		if _, err := parseConfig([]byte(bad), ".yml"); err == nil {
+			t.Errorf("parseConfig(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	s := testServer(t)
+	client := s.Client()
+	e := func(path, method string, expect int) endpoint {
+		return endpoint{Name: path, URL: s.URL + path, Method: method, Expect: expect, Timeout: 200 * time.Millisecond}
+	}
+
+	tests := []struct {
+		endpoint endpoint
+		status   int
+		wantErr  string
+	}{
+		{e("/ok", "GET", 200), 200, ""},
+		{e("/created", "POST", 201), 201, ""},
+		{e("/created", "GET", 201), 405, "got 405 Method Not Allowed, want 201"},
+		{e("/broken", "GET", 200), 500, "got 500"},
+		{e("/slow", "GET", 200), 0, "deadline exceeded"},
+	}
+	for _, tt := range tests {
+		r := check(t.Context(), client, tt.endpoint)
+		if r.status != tt.status {
+			t.Errorf("%s %s: status %d, want %d", tt.endpoint.Method, tt.endpoint.Name, r.status, tt.status)
+		}
+		switch {
+		case tt.wantErr == "" && r.err != nil:
+			t.Errorf("%s %s: %v", tt.endpoint.Method, tt.endpoint.Name, r.err)
+		case tt.wantErr != "" && (r.err == nil || !strings.Contains(r.err.Error(), tt.wantErr)):
+			t.Errorf("%s %s: error %v, want %q", tt.endpoint.Method, tt.endpoint.Name, r.err, tt.wantErr)
+		}
+	}
+}
+
+func TestRunOnce(t *testing.T) {
+	s := testServer(t)
+	endpoints := []endpoint{
+		{Name: "ok", URL: s.URL + "/ok", Method: "GET", Expect: 200, Timeout: time.Second},
+		{Name: "broken", URL: s.URL + "/broken", Method: "GET", Expect: 200, Timeout: time.Second},
+	}
+
+	var out bytes.Buffer
+	if failed := runOnce(&out, s.Client(), endpoints); failed != 1 {
+		t.Errorf("%d failed, want 1", failed)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "ok    ok ") || !strings.HasPrefix(lines[1], "FAIL  broken ") {
+		t.Errorf("report =\n%s", out.String())
+	}
+
+	out.Reset()
+	if failed := runOnce(&out, s.Client(), endpoints[:1]); failed != 0 {
+		t.Errorf("%d failed with only the good endpoint, want 0", failed)
+	}
+}
+
+func TestDashboard(t *testing.T) {
+	s := testServer(t)
+	endpoints := []endpoint{
+		{Name: "flaky", URL: s.URL + "/ok", Method: "GET", Expect: 200, Timeout: time.Second, Interval: time.Hour},
+	}
+	m := newDashboard(s.Client(), endpoints)
+
+	// Polling checks the endpoint, and the result schedules the next poll.
+	next, cmd := m.Update(pollMsg{index: 0})
+	m = next.(dashboard)
+	if !m.checking[0] || cmd == nil {
+		t.Fatal("poll didn't start a check")
+	}
+	next, _ = m.Update(cmd())
+	m = next.(dashboard)
+
+	// A poll from before that result is stale.
+	if _, cmd := m.Update(pollMsg{index: 0}); cmd != nil {
+		t.Error("a stale poll started a check")
+	}
+
+	for _, r := range []result{
+		{status: 500, err: errTest},
+		{status: 200, latency: time.Millisecond},
+		{status: 200, latency: 2 * time.Millisecond},
+	} {
+		next, _ = m.Update(resultMsg{0, r})
+		m = next.(dashboard)
+	}
+	if got := m.history[0].uptime(); got != 75 {
+		t.Errorf("uptime = %v, want 75", got)
+	}
+	if v := m.View(); !strings.Contains(v, "75.0%") || !strings.Contains(v, "All 1 endpoints up") {
+		t.Errorf("view:\n%s", v)
+	}
+}
+
+var errTest = errors.New("test error")
+
+func TestSparkline(t *testing.T) {
+	results := []result{
+		{latency: 10 * time.Millisecond},
+		{err: errTest},
+		{latency: 80 * time.Millisecond},
+		{latency: 40 * time.Millisecond},
+	}
+	if got := sparkline(results); got != "▁ █▄" {
+		t.Errorf("sparkline = %q", got)
+	}
+}
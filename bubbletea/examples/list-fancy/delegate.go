@@ -6,7 +6,7 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 )
 
-func newItemDelegate(keys *delegateKeyMap) list.DefaultDelegate {
+func newItemDelegate(keys *delegateKeyMap) itemDelegate {
 	d := list.NewDefaultDelegate()
 
 	d.UpdateFunc = func(msg tea.Msg, m *list.Model) tea.Cmd {
@@ -23,21 +23,15 @@ func newItemDelegate(keys *delegateKeyMap) list.DefaultDelegate {
 			switch {
 			case key.Matches(msg, keys.choose):
 				return m.NewStatusMessage(statusMessageStyle("You chose " + title))
-
-			case key.Matches(msg, keys.remove):
-				index := m.Index()
-				m.RemoveItem(index)
-				if len(m.Items()) == 0 {
-					keys.remove.SetEnabled(false)
-				}
-				return m.NewStatusMessage(statusMessageStyle("Deleted " + title))
 			}
 		}
 
 		return nil
 	}
 
-	help := []key.Binding{keys.choose, keys.remove}
+	// Editing, marking and deleting change the items themselves, so the model
+	// handles those.
+	help := []key.Binding{keys.choose, keys.edit, keys.mark, keys.remove}
 
 	d.ShortHelpFunc = func() []key.Binding {
 		return help
@@ -47,11 +41,13 @@ func newItemDelegate(keys *delegateKeyMap) list.DefaultDelegate {
 		return [][]key.Binding{help}
 	}
 
-	return d
+	return itemDelegate{d}
 }
 
 type delegateKeyMap struct {
 	choose key.Binding
+	edit   key.Binding
+	mark   key.Binding
 	remove key.Binding
 }
 
@@ -60,6 +56,8 @@ type delegateKeyMap struct {
 func (d delegateKeyMap) ShortHelp() []key.Binding {
 	return []key.Binding{
 		d.choose,
+		d.edit,
+		d.mark,
 		d.remove,
 	}
 }
@@ -70,6 +68,8 @@ func (d delegateKeyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
 		{
 			d.choose,
+			d.edit,
+			d.mark,
 			d.remove,
 		},
 	}
@@ -81,6 +81,14 @@ func newDelegateKeyMap() *delegateKeyMap {
 			key.WithKeys("enter"),
 			key.WithHelp("enter", "choose"),
 		),
+		edit: key.NewBinding(
+			key.WithKeys("e"),
+			key.WithHelp("e", "edit"),
+		),
+		mark: key.NewBinding(
+			key.WithKeys(" "),
+			key.WithHelp("space", "mark"),
+		),
 		remove: key.NewBinding(
 			key.WithKeys("x", "backspace"),
 			key.WithHelp("x", "delete"),
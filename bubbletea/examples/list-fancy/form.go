@@ -0,0 +1,131 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	formStyle = lipgloss.NewStyle().
+			Border(lipgloss.NormalBorder(), true, false, false, false).
+			BorderForeground(lipgloss.AdaptiveColor{Light: "#25A065", Dark: "#25A065"})
+
+	formLabelStyle = lipgloss.NewStyle().Width(13).
+			Foreground(lipgloss.AdaptiveColor{Light: "#A49FA5", Dark: "#777777"})
+
+	formErrorStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.AdaptiveColor{Light: "#FF4672", Dark: "#ED567A"})
+)
+
+type formKeyMap struct {
+	next   key.Binding
+	prev   key.Binding
+	save   key.Binding
+	cancel key.Binding
+}
+
+var formKeys = formKeyMap{
+	next:   key.NewBinding(key.WithKeys("tab", "down"), key.WithHelp("tab", "next field")),
+	prev:   key.NewBinding(key.WithKeys("shift+tab", "up"), key.WithHelp("shift+tab", "previous field")),
+	save:   key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "save")),
+	cancel: key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "cancel")),
+}
+
+func (k formKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.next, k.save, k.cancel}
+}
+
+func (k formKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{k.ShortHelp()}
+}
+
+// formHeight is how many lines the form takes up under the list.
+const formHeight = 5
+
+// itemForm edits an item's title and description, under the list.
+type itemForm struct {
+	id      int // the item being edited, or 0 for a new one
+	inputs  []textinput.Model
+	focused int
+	err     string
+}
+
+func newItemForm(it item) itemForm {
+	f := itemForm{id: it.id, inputs: make([]textinput.Model, 2)}
+	for i, v := range []string{it.title, it.description} {
+		f.inputs[i] = textinput.New()
+		f.inputs[i].Prompt = ""
+		f.inputs[i].CharLimit = 80
+		f.inputs[i].SetValue(v)
+	}
+	f.inputs[0].Placeholder = "Bananas"
+	f.inputs[1].Placeholder = "Ripe, please"
+	f.inputs[0].Focus()
+	return f
+}
+
+// formDoneMsg is sent when the form's saved or cancelled.
+type formDoneMsg struct {
+	id                 int
+	title, description string
+	cancelled          bool
+}
+
+func (f itemForm) Update(msg tea.Msg) (itemForm, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case key.Matches(msg, formKeys.cancel):
+			return f, func() tea.Msg { return formDoneMsg{cancelled: true} }
+
+		case key.Matches(msg, formKeys.save):
+			done := formDoneMsg{
+				id:          f.id,
+				title:       strings.TrimSpace(f.inputs[0].Value()),
+				description: strings.TrimSpace(f.inputs[1].Value()),
+			}
+			if done.title == "" {
+				f.err = "Items need a title"
+				return f, nil
+			}
+			return f, func() tea.Msg { return done }
+
+		case key.Matches(msg, formKeys.next, formKeys.prev):
+			f.inputs[f.focused].Blur()
+			if key.Matches(msg, formKeys.next) {
+				f.focused = (f.focused + 1) % len(f.inputs)
+			} else {
+				f.focused = (f.focused + len(f.inputs) - 1) % len(f.inputs)
+			}
+			return f, f.inputs[f.focused].Focus()
+		}
+	}
+
+	var cmd tea.Cmd
+	f.inputs[f.focused], cmd = f.inputs[f.focused].Update(msg)
+	return f, cmd
+}
+
+func (f itemForm) View(width int) string {
+	heading := "New item"
+	if f.id != 0 {
+		heading = "Edit item"
+	}
+	heading = titleStyle.Render(heading)
+	if f.err != "" {
+		heading += "  " + formErrorStyle.Render(f.err)
+	}
+	for i := range f.inputs {
+		f.inputs[i].Width = max(0, width-lipgloss.Width(formLabelStyle.Render(""))-1)
+	}
+	return formStyle.Width(width).Render(strings.Join([]string{
+		heading,
+		formLabelStyle.Render("Title") + f.inputs[0].View(),
+		formLabelStyle.Render("Description") + f.inputs[1].View(),
+		help.New().ShortHelpView(formKeys.ShortHelp()),
+	}, "\n"))
+}
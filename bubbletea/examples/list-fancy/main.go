@@ -1,6 +1,8 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
 	"math/rand"
 	"os"
@@ -8,6 +10,7 @@ import (
 
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
@@ -23,11 +26,18 @@ var (
 	statusMessageStyle = lipgloss.NewStyle().
 				Foreground(lipgloss.AdaptiveColor{Light: "#04B575", Dark: "#04B575"}).
 				Render
+
+	errorMessageStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.AdaptiveColor{Light: "#FF4672", Dark: "#ED567A"}).
+				Render
 )
 
 type item struct {
+	id          int
 	title       string
 	description string
+	created     time.Time
+	marked      bool
 }
 
 func (i item) Title() string       { return i.title }
@@ -41,6 +51,8 @@ type listKeyMap struct {
 	togglePagination key.Binding
 	toggleHelpMenu   key.Binding
 	insertItem       key.Binding
+	sort             key.Binding
+	undo             key.Binding
 }
 
 func newListKeyMap() *listKeyMap {
@@ -49,6 +61,14 @@ func newListKeyMap() *listKeyMap {
 			key.WithKeys("a"),
 			key.WithHelp("a", "add item"),
 		),
+		sort: key.NewBinding(
+			key.WithKeys("o"),
+			key.WithHelp("o", "change order"),
+		),
+		undo: key.NewBinding(
+			key.WithKeys("z"),
+			key.WithHelp("z", "undo delete"),
+		),
 		toggleSpinner: key.NewBinding(
 			key.WithKeys("s"),
 			key.WithHelp("s", "toggle spinner"),
@@ -73,35 +93,39 @@ func newListKeyMap() *listKeyMap {
 }
 
 type model struct {
-	list          list.Model
-	itemGenerator *randomItemGenerator
-	keys          *listKeyMap
-	delegateKeys  *delegateKeyMap
+	list         list.Model
+	keys         *listKeyMap
+	delegateKeys *delegateKeyMap
+
+	path    string
+	now     func() time.Time
+	items   []item
+	lastID  int
+	order   sortOrder
+	deleted [][]item // what's been deleted, for undoing, latest last
+
+	editing       bool
+	form          itemForm
+	width, height int
 }
 
-func newModel() model {
+func newModel(path string, items []item, now func() time.Time) model {
 	var (
-		itemGenerator randomItemGenerator
-		delegateKeys  = newDelegateKeyMap()
-		listKeys      = newListKeyMap()
+		delegateKeys = newDelegateKeyMap()
+		listKeys     = newListKeyMap()
 	)
 
-	// Make initial list of items
-	const numItems = 24
-	items := make([]list.Item, numItems)
-	for i := 0; i < numItems; i++ {
-		items[i] = itemGenerator.next()
-	}
-
 	// Setup list
 	delegate := newItemDelegate(delegateKeys)
-	groceryList := list.New(items, delegate, 0, 0)
+	groceryList := list.New(nil, delegate, 0, 0)
 	groceryList.Title = "Groceries"
 	groceryList.Styles.Title = titleStyle
 	groceryList.AdditionalFullHelpKeys = func() []key.Binding {
 		return []key.Binding{
 			listKeys.toggleSpinner,
 			listKeys.insertItem,
+			listKeys.sort,
+			listKeys.undo,
 			listKeys.toggleTitleBar,
 			listKeys.toggleStatusBar,
 			listKeys.togglePagination,
@@ -109,12 +133,20 @@ func newModel() model {
 		}
 	}
 
-	return model{
-		list:          groceryList,
-		keys:          listKeys,
-		delegateKeys:  delegateKeys,
-		itemGenerator: &itemGenerator,
+	m := model{
+		list:         groceryList,
+		keys:         listKeys,
+		delegateKeys: delegateKeys,
+		path:         path,
+		now:          now,
 	}
+	for _, it := range items {
+		m.lastID++
+		it.id = m.lastID
+		m.items = append(m.items, it)
+	}
+	m.refresh(0)
+	return m
 }
 
 func (m model) Init() tea.Cmd {
@@ -126,9 +158,29 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
-		h, v := appStyle.GetFrameSize()
-		m.list.SetSize(msg.Width-h, msg.Height-v)
+		m.width, m.height = msg.Width, msg.Height
+		m.resize()
 
+	case formDoneMsg:
+		m.editing = false
+		m.resize()
+		if msg.cancelled {
+			return m, nil
+		}
+		return m, m.saveForm(msg)
+	}
+
+	// Keys go to the form while it's open, and everything else goes to both.
+	if m.editing {
+		var cmd tea.Cmd
+		m.form, cmd = m.form.Update(msg)
+		if _, ok := msg.(tea.KeyMsg); ok {
+			return m, cmd
+		}
+		cmds = append(cmds, cmd)
+	}
+
+	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		// Don't match any of the keys below if we're actively filtering.
 		if m.list.FilterState() == list.Filtering {
@@ -160,30 +212,220 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 
 		case key.Matches(msg, m.keys.insertItem):
-			m.delegateKeys.remove.SetEnabled(true)
-			newItem := m.itemGenerator.next()
-			insCmd := m.list.InsertItem(0, newItem)
-			statusCmd := m.list.NewStatusMessage(statusMessageStyle("Added " + newItem.Title()))
-			return m, tea.Batch(insCmd, statusCmd)
+			return m, m.startEditing(item{})
+
+		case key.Matches(msg, m.delegateKeys.edit):
+			if it, ok := m.list.SelectedItem().(item); ok {
+				return m, m.startEditing(it)
+			}
+			return m, nil
+
+		case key.Matches(msg, m.delegateKeys.mark):
+			if it, ok := m.list.SelectedItem().(item); ok {
+				i := m.find(it.id)
+				m.items[i].marked = !m.items[i].marked
+				return m, m.refresh(it.id)
+			}
+			return m, nil
+
+		case key.Matches(msg, m.delegateKeys.remove):
+			return m, m.remove()
+
+		case key.Matches(msg, m.keys.undo):
+			return m, m.undo()
+
+		case key.Matches(msg, m.keys.sort):
+			m.order = 1 - m.order
+			var id int
+			if it, ok := m.list.SelectedItem().(item); ok {
+				id = it.id
+			}
+			return m, tea.Batch(
+				m.refresh(id),
+				m.list.NewStatusMessage(statusMessageStyle("Sorted by "+m.order.String())),
+			)
 		}
 	}
 
 	// This will also call our delegate's update function.
+	prev := m.list.Index()
 	newListModel, cmd := m.list.Update(msg)
 	m.list = newListModel
+	m.skipHeaders(prev)
 	cmds = append(cmds, cmd)
 
 	return m, tea.Batch(cmds...)
 }
 
+func (m *model) resize() {
+	h, v := appStyle.GetFrameSize()
+	height := m.height - v
+	if m.editing {
+		height -= formHeight
+	}
+	m.list.SetSize(m.width-h, height)
+}
+
+func (m *model) startEditing(it item) tea.Cmd {
+	m.editing = true
+	m.form = newItemForm(it)
+	m.resize()
+	return textinput.Blink
+}
+
+func (m *model) saveForm(msg formDoneMsg) tea.Cmd {
+	if msg.id == 0 {
+		m.lastID++
+		m.items = append(m.items, item{
+			id:          m.lastID,
+			title:       msg.title,
+			description: msg.description,
+			created:     m.now(),
+		})
+		return m.changed(m.lastID, "Added "+msg.title)
+	}
+	i := m.find(msg.id)
+	if i < 0 {
+		return nil
+	}
+	m.items[i].title, m.items[i].description = msg.title, msg.description
+	return m.changed(msg.id, "Saved "+msg.title)
+}
+
+// remove deletes the marked items, or the selected one if none are marked.
+func (m *model) remove() tea.Cmd {
+	var gone, kept []item
+	for _, it := range m.items {
+		if it.marked {
+			it.marked = false
+			gone = append(gone, it)
+		} else {
+			kept = append(kept, it)
+		}
+	}
+	if len(gone) == 0 {
+		sel, ok := m.list.SelectedItem().(item)
+		if !ok {
+			return nil
+		}
+		i := m.find(sel.id)
+		gone = []item{m.items[i]}
+		kept = append(m.items[:i:i], m.items[i+1:]...)
+	}
+
+	m.items = kept
+	m.deleted = append(m.deleted, gone)
+	return m.changed(0, "Deleted "+describe(gone)+". Press "+m.keys.undo.Help().Key+" to undo.")
+}
+
+func (m *model) undo() tea.Cmd {
+	if len(m.deleted) == 0 {
+		return m.list.NewStatusMessage(statusMessageStyle("Nothing to undo"))
+	}
+	restored := m.deleted[len(m.deleted)-1]
+	m.deleted = m.deleted[:len(m.deleted)-1]
+	m.items = append(m.items, restored...)
+	return m.changed(restored[0].id, "Restored "+describe(restored))
+}
+
+// describe names an item, or says how many there are.
+func describe(items []item) string {
+	if len(items) == 1 {
+		return items[0].title
+	}
+	return fmt.Sprintf("%d items", len(items))
+}
+
+// find finds the index of an item by its id, or -1.
+func (m model) find(id int) int {
+	for i, it := range m.items {
+		if it.id == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// changed puts the items in the list again after a change, selecting the one
+// with the given id, and saves them.
+func (m *model) changed(id int, status string) tea.Cmd {
+	cmd := m.refresh(id)
+	if err := saveItems(m.path, m.items); err != nil {
+		return tea.Batch(cmd, m.list.NewStatusMessage(errorMessageStyle("Couldn't save: "+err.Error())))
+	}
+	return tea.Batch(cmd, m.list.NewStatusMessage(statusMessageStyle(status)))
+}
+
+// refresh sorts the items into the list, selecting the one with the given id.
+// If it isn't there, the cursor stays where it is.
+func (m *model) refresh(id int) tea.Cmd {
+	cmd := m.list.SetItems(arrange(m.items, m.order, m.now()))
+
+	enabled := len(m.items) > 0
+	m.delegateKeys.edit.SetEnabled(enabled)
+	m.delegateKeys.mark.SetEnabled(enabled)
+	m.delegateKeys.remove.SetEnabled(enabled)
+
+	visible := m.list.VisibleItems()
+	for i, li := range visible {
+		if it, ok := li.(item); ok && it.id == id {
+			m.list.Select(i)
+			return cmd
+		}
+	}
+	if n := len(visible); m.list.Index() >= n && n > 0 {
+		m.list.Select(n - 1)
+	}
+	m.skipHeaders(m.list.Index())
+	return cmd
+}
+
+// skipHeaders moves the cursor off a section header, carrying on the way it
+// was going.
+func (m *model) skipHeaders(from int) {
+	up := m.list.Index() < from
+	for range len(m.list.VisibleItems()) {
+		if _, ok := m.list.SelectedItem().(header); !ok {
+			return
+		}
+		// The first thing in the list is always a header, so we can't keep
+		// going up from there.
+		if m.list.Index() == 0 {
+			up = false
+		}
+		if up {
+			m.list.CursorUp()
+		} else {
+			m.list.CursorDown()
+		}
+	}
+}
+
 func (m model) View() string {
+	if m.editing {
+		return appStyle.Render(m.list.View() + "\n" + m.form.View(m.list.Width()))
+	}
 	return appStyle.Render(m.list.View())
 }
 
 func main() {
+	path := flag.String("file", "groceries.json", "the file to keep the list in")
+	flag.Parse()
+
 	rand.Seed(time.Now().UTC().UnixNano())
 
-	if _, err := tea.NewProgram(newModel(), tea.WithAltScreen()).Run(); err != nil {
+	// Start off with some random items the first time.
+	items, err := loadItems(*path)
+	if errors.Is(err, os.ErrNotExist) {
+		items = randomItems(24, time.Now())
+		err = saveItems(*path, items)
+	}
+	if err != nil {
+		fmt.Println("Error loading items:", err)
+		os.Exit(1)
+	}
+
+	if _, err := tea.NewProgram(newModel(*path, items, time.Now), tea.WithAltScreen()).Run(); err != nil {
 		fmt.Println("Error running program:", err)
 		os.Exit(1)
 	}
@@ -3,6 +3,7 @@ package main
 import (
 	"math/rand"
 	"sync"
+	"time"
 )
 
 type randomItemGenerator struct {
@@ -162,3 +163,15 @@ func (r *randomItemGenerator) next() item {
 
 	return i
 }
+
+// randomItems makes some items to start with, added every few hours before
+// now.
+func randomItems(n int, now time.Time) []item {
+	var gen randomItemGenerator
+	items := make([]item, n)
+	for i := range items {
+		items[i] = gen.next()
+		items[i].created = now.Add(-time.Duration(i) * 5 * time.Hour)
+	}
+	return items
+}
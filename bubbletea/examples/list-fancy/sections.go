@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var headerStyle = lipgloss.NewStyle().
+	Foreground(lipgloss.AdaptiveColor{Light: "#25A065", Dark: "#25A065"}).
+	Bold(true).
+	Padding(0, 0, 0, 2)
+
+// header is a section header in the list. It's an item, so it scrolls along
+// with the rest, but the cursor skips over it, and it never matches a filter.
+type header string
+
+func (h header) FilterValue() string { return "" }
+
+type sortOrder int
+
+const (
+	byCreated sortOrder = iota
+	byTitle
+)
+
+func (s sortOrder) String() string {
+	if s == byTitle {
+		return "title"
+	}
+	return "date added"
+}
+
+// arrange sorts the items, and puts them in sections under headers: by first
+// letter when they're sorted by title, and by day when they're sorted by date.
+func arrange(items []item, order sortOrder, now time.Time) []list.Item {
+	sorted := slices.Clone(items)
+	slices.SortStableFunc(sorted, func(a, b item) int {
+		if order == byTitle {
+			// Sort by section first, so titles that don't start with a
+			// letter all end up together.
+			if c := strings.Compare(section(a, order, now), section(b, order, now)); c != 0 {
+				return c
+			}
+			if c := strings.Compare(strings.ToLower(a.title), strings.ToLower(b.title)); c != 0 {
+				return c
+			}
+		}
+		// Newest first.
+		return b.created.Compare(a.created)
+	})
+
+	var arranged []list.Item
+	var last string
+	for _, it := range sorted {
+		if s := section(it, order, now); s != last {
+			arranged = append(arranged, header(s))
+			last = s
+		}
+		arranged = append(arranged, it)
+	}
+	return arranged
+}
+
+// section is the name of the section an item goes in.
+func section(it item, order sortOrder, now time.Time) string {
+	if order == byTitle {
+		if r, _ := utf8.DecodeRuneInString(it.title); unicode.IsLetter(r) {
+			return string(unicode.ToUpper(r))
+		}
+		return "#"
+	}
+
+	y, m, d := it.created.In(now.Location()).Date()
+	day := time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+	y, m, d = now.Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+	switch {
+	case day.Equal(today):
+		return "Today"
+	case day.Equal(today.AddDate(0, 0, -1)):
+		return "Yesterday"
+	case day.Year() == today.Year():
+		return day.Format("Monday, January 2")
+	default:
+		return day.Format("January 2, 2006")
+	}
+}
+
+// itemDelegate draws section headers itself, and everything else like the
+// default delegate, with a check mark on marked items.
+type itemDelegate struct {
+	list.DefaultDelegate
+}
+
+func (d itemDelegate) Render(w io.Writer, m list.Model, index int, li list.Item) {
+	switch li := li.(type) {
+	case header:
+		// Take up as many lines as an item, so the list pages properly, with
+		// the header at the bottom, next to its items.
+		fmt.Fprint(w, strings.Repeat("\n", d.Height()-1)+headerStyle.Render(string(li))) //nolint:errcheck
+	case item:
+		if li.marked {
+			// The mark goes after the title, so it doesn't throw off the
+			// highlighting of filter matches.
+			li.title += " ✓"
+		}
+		d.DefaultDelegate.Render(w, m, index, li)
+	}
+}
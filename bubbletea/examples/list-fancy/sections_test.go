@@ -0,0 +1,47 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+)
+
+var testNow = time.Date(2025, time.June, 10, 12, 0, 0, 0, time.UTC)
+
+func outline(items []list.Item) string {
+	var s []string
+	for _, li := range items {
+		switch li := li.(type) {
+		case header:
+			s = append(s, "["+string(li)+"]")
+		case item:
+			s = append(s, li.title)
+		}
+	}
+	return strings.Join(s, " ")
+}
+
+func TestArrange(t *testing.T) {
+	items := []item{
+		{title: "bananas", created: testNow.Add(-time.Hour)},
+		{title: "Apples", created: testNow.Add(-30 * time.Hour)},
+		{title: "avocado", created: testNow.Add(-2 * time.Hour)},
+		{title: "7up", created: testNow.Add(-20 * 24 * time.Hour)},
+		{title: "Celery", created: testNow.AddDate(-1, 0, 0)},
+		{title: "~tilde", created: testNow.Add(-3 * time.Hour)},
+	}
+
+	got := outline(arrange(items, byTitle, testNow))
+	want := "[#] 7up ~tilde [A] Apples avocado [B] bananas [C] Celery"
+	if got != want {
+		t.Errorf("by title:\n got %s\nwant %s", got, want)
+	}
+
+	got = outline(arrange(items, byCreated, testNow))
+	want = "[Today] bananas avocado ~tilde [Yesterday] Apples [Wednesday, May 21] 7up [June 10, 2024] Celery"
+	if got != want {
+		t.Errorf("by date:\n got %s\nwant %s", got, want)
+	}
+}
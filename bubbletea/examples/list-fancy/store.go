@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+)
+
+// storedItem is an item as it's saved in the JSON file.
+type storedItem struct {
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Created     time.Time `json:"created"`
+}
+
+// loadItems reads items from a JSON file.
+func loadItems(path string) ([]item, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var stored []storedItem
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, err
+	}
+	items := make([]item, len(stored))
+	for i, s := range stored {
+		items[i] = item{title: s.Title, description: s.Description, created: s.Created}
+	}
+	return items, nil
+}
+
+// saveItems writes items to a JSON file. It writes to a temporary file first,
+// so the items already saved aren't lost if something goes wrong.
+func saveItems(path string, items []item) error {
+	stored := make([]storedItem, len(items))
+	for i, it := range items {
+		stored[i] = storedItem{it.title, it.description, it.created}
+	}
+	data, err := json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
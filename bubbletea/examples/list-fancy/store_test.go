@@ -0,0 +1,185 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// testModel has n items, one added each hour before testNow, and saves them
+// in a temporary file.
+func testModel(t *testing.T, n int) model {
+	t.Helper()
+	items := make([]item, n)
+	for i := range items {
+		items[i] = item{
+			title:       string(rune('A'+i)) + " item",
+			description: "about it",
+			created:     testNow.Add(-time.Duration(i+1) * time.Hour),
+		}
+	}
+	m := newModel(filepath.Join(t.TempDir(), "items.json"), items, func() time.Time { return testNow })
+	return update(m, tea.WindowSizeMsg{Width: 80, Height: 40})
+}
+
+// update sends messages to the model. When the form's closed with enter or
+// esc, it sends what the form says too.
+func update(m model, msgs ...tea.Msg) model {
+	for _, msg := range msgs {
+		editing := m.editing
+		next, cmd := m.Update(msg)
+		m = next.(model)
+		k, ok := msg.(tea.KeyMsg)
+		if !editing || !ok || (k.Type != tea.KeyEnter && k.Type != tea.KeyEsc) || cmd == nil {
+			continue
+		}
+		if done, ok := cmd().(formDoneMsg); ok {
+			m = update(m, done)
+		}
+	}
+	return m
+}
+
+func keys(s string) []tea.Msg {
+	var msgs []tea.Msg
+	for _, r := range s {
+		switch r {
+		case '\n':
+			msgs = append(msgs, tea.KeyMsg{Type: tea.KeyEnter})
+		case '\t':
+			msgs = append(msgs, tea.KeyMsg{Type: tea.KeyTab})
+		case ' ':
+			msgs = append(msgs, tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+		case '↓':
+			msgs = append(msgs, tea.KeyMsg{Type: tea.KeyDown})
+		case '↑':
+			msgs = append(msgs, tea.KeyMsg{Type: tea.KeyUp})
+		default:
+			msgs = append(msgs, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		}
+	}
+	return msgs
+}
+
+func selected(m model) string {
+	if it, ok := m.list.SelectedItem().(item); ok {
+		return it.title
+	}
+	return ""
+}
+
+func saved(t *testing.T, m model) string {
+	t.Helper()
+	items, err := loadItems(m.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return outline(arrange(items, byTitle, testNow))
+}
+
+func TestSkipHeaders(t *testing.T) {
+	m := testModel(t, 3)
+	m.order = byTitle
+	m.refresh(0)
+	if got := selected(m); got != "A item" {
+		t.Fatalf("started on %q", got)
+	}
+	m = update(m, keys("↓↓")...)
+	if got := selected(m); got != "C item" {
+		t.Errorf("after going down: %q", got)
+	}
+	m = update(m, keys("↑↑↑")...)
+	if got := selected(m); got != "A item" || m.list.Index() != 1 {
+		t.Errorf("after going up: %q at %d", got, m.list.Index())
+	}
+}
+
+func TestEdit(t *testing.T) {
+	m := testModel(t, 2)
+
+	m = update(m, keys("a")...)
+	if !m.editing {
+		t.Fatal("the form didn't open")
+	}
+	if v := m.View(); !strings.Contains(v, "New item") {
+		t.Errorf("no form in:\n%s", v)
+	}
+	// The form needs a title.
+	m = update(m, keys("\n")...)
+	if !m.editing || m.form.err == "" {
+		t.Fatal("saved an item without a title")
+	}
+	m = update(m, keys("Zucchini\tgreen\n")...)
+	if m.editing || selected(m) != "Zucchini" {
+		t.Fatalf("after adding, editing %v with %q selected", m.editing, selected(m))
+	}
+	if got := outline(m.list.Items()); got != "[Today] Zucchini A item B item" {
+		t.Errorf("list is %s", got)
+	}
+
+	m = update(m, keys("↓e")...)
+	if got := m.form.inputs[0].Value(); got != "A item" {
+		t.Fatalf("editing %q", got)
+	}
+	m = update(m, keys("!\n")...)
+	if got := saved(t, m); got != "[A] A item! [B] B item [Z] Zucchini" {
+		t.Errorf("saved %s", got)
+	}
+
+	// Cancelling changes nothing.
+	m = update(m, keys("e???")...)
+	m = update(m, tea.KeyMsg{Type: tea.KeyEsc})
+	if m.editing || selected(m) != "A item!" {
+		t.Errorf("after cancelling, editing %v with %q selected", m.editing, selected(m))
+	}
+}
+
+func TestDeleteAndUndo(t *testing.T) {
+	m := testModel(t, 5)
+	m.order = byTitle
+	m.refresh(0)
+
+	// Mark B and D, and delete them both.
+	m = update(m, keys("↓ ↓↓ ")...)
+	if v := m.View(); !strings.Contains(v, "B item ✓") || !strings.Contains(v, "D item ✓") {
+		t.Errorf("marks aren't shown:\n%s", v)
+	}
+	if !m.items[m.find(2)].marked || !m.items[m.find(4)].marked {
+		t.Fatal("didn't mark B and D")
+	}
+	m = update(m, keys("x")...)
+	if got := saved(t, m); got != "[A] A item [C] C item [E] E item" {
+		t.Errorf("after deleting the marked items: %s", got)
+	}
+
+	// With nothing marked, x deletes the selected item.
+	m.list.Select(1)
+	m = update(m, keys("x")...)
+	if got := saved(t, m); got != "[C] C item [E] E item" {
+		t.Errorf("after deleting A: %s", got)
+	}
+	if _, ok := m.list.SelectedItem().(header); ok {
+		t.Error("the cursor's on a header")
+	}
+
+	m = update(m, keys("z")...)
+	if got := saved(t, m); got != "[A] A item [C] C item [E] E item" {
+		t.Errorf("after undoing once: %s", got)
+	}
+	m = update(m, keys("z")...)
+	if got := saved(t, m); got != "[A] A item [B] B item [C] C item [D] D item [E] E item" {
+		t.Errorf("after undoing twice: %s", got)
+	}
+	for _, it := range m.items {
+		if it.marked {
+			t.Errorf("%s is still marked", it.title)
+		}
+	}
+	m = update(m, keys("z")...)
+	if len(m.items) != 5 {
+		t.Errorf("have %d items", len(m.items))
+	}
+}
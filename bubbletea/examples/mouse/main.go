@@ -1,23 +1,99 @@
 package main
 
-// A simple program that opens the alternate screen buffer and displays mouse
-// coordinates and events.
+// A small mouse-driven interface: buttons to click, two panels with a divider
+// that can be dragged to resize them, scrolling whichever panel is under the
+// pointer with the wheel, and double clicks. The mouse events we get are
+// listed in the right-hand panel.
 
 import (
+	"flag"
+	"fmt"
 	"log"
+	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 )
 
 func main() {
-	p := tea.NewProgram(model{}, tea.WithMouseAllMotion())
+	interval := flag.Duration("double-click", 400*time.Millisecond, "how soon a second click has to come to make a double click")
+	flag.Parse()
+
+	p := tea.NewProgram(newModel(*interval, time.Now), tea.WithAltScreen(), tea.WithMouseAllMotion())
 	if _, err := p.Run(); err != nil {
 		log.Fatal(err)
 	}
 }
 
+const (
+	// panelsTop is the line the panels start on, after the buttons.
+	panelsTop     = 2
+	minPanelWidth = 12
+)
+
+type button struct {
+	id, label string
+}
+
+var buttons = []button{
+	{"add", "Add item"},
+	{"clear", "Clear log"},
+	{"quit", "Quit"},
+}
+
+var (
+	buttonStyle        = lipgloss.NewStyle().Padding(0, 1).Foreground(lipgloss.Color("#FFFDF5")).Background(lipgloss.Color("#6124DF"))
+	hoverButtonStyle   = buttonStyle.Background(lipgloss.Color("#7D56F4"))
+	pressedButtonStyle = buttonStyle.Background(lipgloss.Color("#F25D94"))
+	panelStyle         = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("240"))
+	hoverPanelStyle    = panelStyle.BorderForeground(lipgloss.Color("#7D56F4"))
+	dividerStyle       = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	activeDividerStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#F25D94"))
+	selectedStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("#F25D94")).Bold(true)
+	helpStyle          = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+)
+
+// panel is a scrolling list of lines.
+type panel struct {
+	lines  []string
+	offset int
+}
+
+func (p *panel) scroll(n, height int) {
+	p.offset = max(0, min(p.offset+n, len(p.lines)-height))
+}
+
+func (p panel) atEnd(height int) bool {
+	return p.offset >= len(p.lines)-height
+}
+
 type model struct {
-	mouseEvent tea.MouseEvent
+	width, height int
+	now           func() time.Time
+
+	split    int  // how wide the left panel is
+	dragging bool // whether the divider's being dragged
+	hover    string
+	pressed  string // the button the mouse went down on
+	clicks   clicks
+
+	items    panel
+	added    int
+	selected int
+	log      panel
+}
+
+func newModel(doubleClick time.Duration, now func() time.Time) model {
+	m := model{
+		now:    now,
+		clicks: clicks{interval: doubleClick},
+	}
+	for range 30 {
+		m.addItem()
+	}
+	return m
 }
 
 func (m model) Init() tea.Cmd {
@@ -31,15 +107,212 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, tea.Quit
 		}
 
+	case tea.WindowSizeMsg:
+		if m.width == 0 {
+			m.split = msg.Width / 3
+		}
+		m.width, m.height = msg.Width, msg.Height
+		m.split = m.clampSplit(m.split)
+
 	case tea.MouseMsg:
-		return m, tea.Printf("(X: %d, Y: %d) %s", msg.X, msg.Y, tea.MouseEvent(msg))
+		return m.handleMouse(msg)
+	}
+
+	return m, nil
+}
+
+func (m model) handleMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	z, _ := m.layout().hit(msg.X, msg.Y)
+	m.hover = z.id
+	if msg.Action != tea.MouseActionMotion {
+		on := z.id
+		if on == "" {
+			on = "nothing"
+		}
+		m.logf("%s at %d,%d on %s", tea.MouseEvent(msg), msg.X, msg.Y, on)
+	}
+
+	switch {
+	case tea.MouseEvent(msg).IsWheel():
+		// The wheel scrolls whatever's under the pointer, not whatever has
+		// focus.
+		n := 1
+		if msg.Button == tea.MouseButtonWheelUp {
+			n = -1
+		}
+		switch z.id {
+		case "items":
+			m.items.scroll(n, m.listHeight())
+		case "log":
+			m.log.scroll(n, m.listHeight())
+		}
+
+	case msg.Action == tea.MouseActionMotion:
+		if m.dragging {
+			m.split = m.clampSplit(msg.X)
+		}
+
+	case msg.Action == tea.MouseActionRelease:
+		// Buttons go off when the mouse is let go over the button it went
+		// down on, so a press can be called off by moving away.
+		m.dragging = false
+		pressed := m.pressed
+		m.pressed = ""
+		if pressed != "" && pressed == z.id {
+			return m.activate(pressed)
+		}
+
+	case msg.Button == tea.MouseButtonLeft:
+		double := m.clicks.press(msg.X, msg.Y, m.now()) == 2
+		switch z.id {
+		case "":
+		case "divider":
+			m.dragging = true
+			if double {
+				m.split = m.clampSplit(m.width / 3)
+			}
+		case "items":
+			m.clickItem(z.rect, msg.X, msg.Y, double)
+		case "log":
+		default:
+			m.pressed = z.id
+		}
+	}
+
+	return m, nil
+}
+
+// clickItem selects the item at a point in the list, and opens it on a double
+// click.
+func (m *model) clickItem(r rect, x, y int, double bool) {
+	inner := rect{r.x + 1, r.y + 1, r.w - 2, r.h - 2}
+	if !inner.contains(x, y) {
+		return
+	}
+	i := m.items.offset + y - inner.y
+	if i >= len(m.items.lines) {
+		return
+	}
+	m.selected = i
+	if double {
+		m.logf("Opened %s", m.items.lines[i])
 	}
+}
 
+func (m model) activate(id string) (tea.Model, tea.Cmd) {
+	switch id {
+	case "add":
+		m.addItem()
+		m.items.offset = max(0, len(m.items.lines)-m.listHeight())
+	case "clear":
+		m.log = panel{}
+	case "quit":
+		return m, tea.Quit
+	}
 	return m, nil
 }
 
+func (m *model) addItem() {
+	m.added++
+	m.items.lines = append(m.items.lines, fmt.Sprintf("Item %d", m.added))
+}
+
+// logf adds a line to the log, following the end of it unless it's been
+// scrolled back.
+func (m *model) logf(format string, args ...any) {
+	follow := m.log.atEnd(m.listHeight())
+	m.log.lines = append(m.log.lines, fmt.Sprintf(format, args...))
+	if follow {
+		m.log.offset = max(0, len(m.log.lines)-m.listHeight())
+	}
+}
+
+func (m model) clampSplit(x int) int {
+	return max(minPanelWidth, min(x, m.width-minPanelWidth-1))
+}
+
+// listHeight is how many lines the panels show.
+func (m model) listHeight() int {
+	return max(0, m.height-panelsTop-3)
+}
+
+// layout works out where everything goes.
+func (m model) layout() zones {
+	var z zones
+	x := 0
+	for _, b := range buttons {
+		w := lipgloss.Width(buttonStyle.Render(b.label))
+		z.add(b.id, rect{x, 0, w, 1})
+		x += w + 1
+	}
+
+	h := m.listHeight() + 2
+	z.add("items", rect{0, panelsTop, m.split, h})
+	z.add("divider", rect{m.split, panelsTop, 1, h})
+	z.add("log", rect{m.split + 1, panelsTop, m.width - m.split - 1, h})
+	return z
+}
+
 func (m model) View() string {
-	s := "Do mouse stuff. When you're done press q to quit.\n"
+	if m.width == 0 {
+		return ""
+	}
+	z := m.layout()
 
-	return s
+	var bs []string
+	for _, b := range buttons {
+		style := buttonStyle
+		switch b.id {
+		case m.pressed:
+			style = pressedButtonStyle
+		case m.hover:
+			style = hoverButtonStyle
+		}
+		bs = append(bs, style.Render(b.label))
+	}
+
+	items := make([]string, len(m.items.lines))
+	for i, item := range m.items.lines {
+		if i == m.selected {
+			items[i] = selectedStyle.Render("> " + item)
+		} else {
+			items[i] = "  " + item
+		}
+	}
+
+	div := z.get("divider")
+	divider := dividerStyle.Render(strings.TrimSuffix(strings.Repeat("│\n", div.h), "\n"))
+	if m.dragging || m.hover == "divider" {
+		divider = activeDividerStyle.Render(strings.TrimSuffix(strings.Repeat("┃\n", div.h), "\n"))
+	}
+
+	help := "Drag the divider to resize the panels, double-click it to reset them, and double-click an item to open it. Press q to quit."
+
+	return lipgloss.JoinVertical(lipgloss.Left,
+		strings.Join(bs, " "),
+		"",
+		lipgloss.JoinHorizontal(lipgloss.Top,
+			panelView(items, m.items.offset, z.get("items"), m.hover == "items"),
+			divider,
+			panelView(m.log.lines, m.log.offset, z.get("log"), m.hover == "log"),
+		),
+		helpStyle.Render(ansi.Truncate(help, m.width, "…")),
+	)
+}
+
+// panelView draws the lines of a panel that fit in it.
+func panelView(lines []string, offset int, r rect, hover bool) string {
+	w, h := r.w-2, r.h-2
+	if w <= 0 || h <= 0 {
+		return ""
+	}
+	var visible []string
+	for _, line := range lines[min(offset, len(lines)):min(offset+h, len(lines))] {
+		visible = append(visible, ansi.Truncate(line, w, "…"))
+	}
+	style := panelStyle
+	if hover {
+		style = hoverPanelStyle
+	}
+	return style.Width(w).Height(h).Render(strings.Join(visible, "\n"))
 }
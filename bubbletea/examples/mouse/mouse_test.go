@@ -0,0 +1,209 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestHit(t *testing.T) {
+	var z zones
+	z.add("back", rect{0, 0, 10, 10})
+	z.add("front", rect{2, 2, 3, 3})
+
+	tests := []struct {
+		x, y int
+		want string
+	}{
+		{0, 0, "back"},
+		{2, 2, "front"},
+		{4, 4, "front"},
+		{5, 5, "back"},
+		{10, 0, ""},
+	}
+	for _, tt := range tests {
+		if got, _ := z.hit(tt.x, tt.y); got.id != tt.want {
+			t.Errorf("hit(%d, %d) = %q, want %q", tt.x, tt.y, got.id, tt.want)
+		}
+	}
+}
+
+func TestClicks(t *testing.T) {
+	start := time.Now()
+	c := clicks{interval: 300 * time.Millisecond}
+	tests := []struct {
+		x     int
+		after time.Duration
+		want  int
+	}{
+		{1, 0, 1},
+		{1, 200 * time.Millisecond, 2},
+		{1, 400 * time.Millisecond, 3},
+		{1, time.Second, 1}, // too slow
+		{2, 1100 * time.Millisecond, 1},
+	}
+	for _, tt := range tests {
+		if got := c.press(tt.x, 0, start.Add(tt.after)); got != tt.want {
+			t.Errorf("click at %v made %d, want %d", tt.after, got, tt.want)
+		}
+	}
+}
+
+// testModel is 60x20, with a clock that only moves when told to.
+func testModel() (model, *time.Time) {
+	now := time.Now()
+	m := newModel(300*time.Millisecond, func() time.Time { return now })
+	next, _ := m.Update(tea.WindowSizeMsg{Width: 60, Height: 20})
+	return next.(model), &now
+}
+
+func mouse(m model, x, y int, button tea.MouseButton, action tea.MouseAction) (model, tea.Cmd) {
+	next, cmd := m.Update(tea.MouseMsg{X: x, Y: y, Button: button, Action: action})
+	return next.(model), cmd
+}
+
+func click(m model, x, y int) (model, tea.Cmd) {
+	m, _ = mouse(m, x, y, tea.MouseButtonLeft, tea.MouseActionPress)
+	return mouse(m, x, y, tea.MouseButtonLeft, tea.MouseActionRelease)
+}
+
+func TestLayoutMatchesView(t *testing.T) {
+	m, _ := testModel()
+	lines := strings.Split(ansi.Strip(m.View()), "\n")
+	if len(lines) != m.height {
+		t.Fatalf("view is %d lines, want %d", len(lines), m.height)
+	}
+	z := m.layout()
+	div := z.get("divider")
+	if got := []rune(lines[div.y])[div.x]; got != '│' {
+		t.Errorf("the divider is drawn with %q", got)
+	}
+	if w := lipgloss.Width(lines[panelsTop]); w != m.width {
+		t.Errorf("panels are %d wide, want %d", w, m.width)
+	}
+}
+
+func TestButtons(t *testing.T) {
+	m, _ := testModel()
+	add := m.layout().get("add")
+
+	m, _ = click(m, add.x, add.y)
+	if n := len(m.items.lines); n != 31 {
+		t.Errorf("have %d items after clicking add", n)
+	}
+
+	// Letting go somewhere else calls the click off.
+	m, _ = mouse(m, add.x, add.y, tea.MouseButtonLeft, tea.MouseActionPress)
+	if m.pressed != "add" {
+		t.Errorf("pressed %q", m.pressed)
+	}
+	m, _ = mouse(m, 40, 10, tea.MouseButtonLeft, tea.MouseActionRelease)
+	if n := len(m.items.lines); n != 31 || m.pressed != "" {
+		t.Errorf("have %d items, pressed %q", n, m.pressed)
+	}
+
+	quit := m.layout().get("quit")
+	if _, cmd := click(m, quit.x+1, quit.y); cmd == nil {
+		t.Error("quit didn't quit")
+	}
+}
+
+func TestDrag(t *testing.T) {
+	m, now := testModel()
+	div := m.layout().get("divider")
+
+	m, _ = mouse(m, div.x, div.y+3, tea.MouseButtonLeft, tea.MouseActionPress)
+	m, _ = mouse(m, 30, div.y+3, tea.MouseButtonLeft, tea.MouseActionMotion)
+	m, _ = mouse(m, 31, div.y+5, tea.MouseButtonLeft, tea.MouseActionMotion)
+	m, _ = mouse(m, 31, div.y+5, tea.MouseButtonLeft, tea.MouseActionRelease)
+	if m.split != 31 || m.dragging {
+		t.Fatalf("split at %d, dragging %v", m.split, m.dragging)
+	}
+	if got := m.layout().get("log"); got.x != 32 || got.w != 28 {
+		t.Errorf("log panel at %+v", got)
+	}
+
+	// It can't be dragged further than leaves room for the log.
+	m, _ = mouse(m, 31, div.y, tea.MouseButtonLeft, tea.MouseActionPress)
+	m, _ = mouse(m, 59, div.y, tea.MouseButtonLeft, tea.MouseActionMotion)
+	if m.split != 60-minPanelWidth-1 {
+		t.Errorf("split at %d", m.split)
+	}
+	m, _ = mouse(m, 59, div.y, tea.MouseButtonLeft, tea.MouseActionRelease)
+
+	// Moving without a button down doesn't drag.
+	m, _ = mouse(m, 20, div.y, tea.MouseButtonNone, tea.MouseActionMotion)
+	if m.split != 60-minPanelWidth-1 {
+		t.Errorf("split moved to %d", m.split)
+	}
+
+	// A double click puts it back.
+	*now = now.Add(time.Second)
+	m, _ = click(m, m.split, div.y)
+	*now = now.Add(100 * time.Millisecond)
+	m, _ = click(m, m.split, div.y)
+	if m.split != 20 {
+		t.Errorf("split at %d after a double click", m.split)
+	}
+}
+
+func TestWheel(t *testing.T) {
+	m, _ := testModel()
+	items, logs := m.layout().get("items"), m.layout().get("log")
+	for range 3 {
+		m, _ = mouse(m, items.x+2, items.y+2, tea.MouseButtonWheelDown, tea.MouseActionPress)
+	}
+	if m.items.offset != 3 || m.log.offset != 0 {
+		t.Errorf("items at %d, log at %d", m.items.offset, m.log.offset)
+	}
+	m, _ = mouse(m, items.x+2, items.y+2, tea.MouseButtonWheelUp, tea.MouseActionPress)
+	if m.items.offset != 2 {
+		t.Errorf("items at %d", m.items.offset)
+	}
+
+	// The log follows along as events come in, and scrolls on its own.
+	for range m.listHeight() {
+		m, _ = mouse(m, 0, 0, tea.MouseButtonNone, tea.MouseActionRelease)
+	}
+	end := m.log.offset
+	if end == 0 {
+		t.Fatal("the log didn't follow new events")
+	}
+	m, _ = mouse(m, logs.x+2, logs.y+2, tea.MouseButtonWheelUp, tea.MouseActionPress)
+	if m.items.offset != 2 || m.log.offset != end {
+		// The wheel event itself was logged, then scrolled back.
+		t.Errorf("items at %d, log at %d, want 2, %d", m.items.offset, m.log.offset, end)
+	}
+}
+
+func TestDoubleClickItem(t *testing.T) {
+	m, now := testModel()
+	items := m.layout().get("items")
+
+	m, _ = click(m, items.x+3, items.y+4)
+	if m.selected != 3 {
+		t.Errorf("selected %d", m.selected)
+	}
+	*now = now.Add(time.Second)
+	m, _ = click(m, items.x+3, items.y+2)
+	*now = now.Add(200 * time.Millisecond)
+	m, _ = click(m, items.x+3, items.y+2)
+	if got := m.log.lines[len(m.log.lines)-2]; m.selected != 1 || got != "Opened Item 2" {
+		t.Errorf("selected %d, logged %q", m.selected, got)
+	}
+
+	// Slow clicks don't open anything.
+	*now = now.Add(time.Second)
+	m, _ = click(m, items.x+3, items.y+3)
+	*now = now.Add(time.Second)
+	m, _ = click(m, items.x+3, items.y+3)
+	for _, line := range m.log.lines {
+		if line == "Opened Item 3" {
+			t.Error("slow clicks opened an item")
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+// Mouse events only tell us which cell was clicked, so to know what was
+// clicked we keep track of where things are drawn. We work out the layout in
+// one place, and use it both to draw the view and to hit-test mouse events
+// against it, so the two can't disagree.
+
+import "time"
+
+// rect is a region of the screen.
+type rect struct {
+	x, y, w, h int
+}
+
+func (r rect) contains(x, y int) bool {
+	return x >= r.x && x < r.x+r.w && y >= r.y && y < r.y+r.h
+}
+
+// zone is a named region of the screen.
+type zone struct {
+	id string
+	rect
+}
+
+// zones is everything on the screen that can be clicked. Later zones are
+// drawn over earlier ones.
+type zones []zone
+
+func (z *zones) add(id string, r rect) rect {
+	*z = append(*z, zone{id, r})
+	return r
+}
+
+// get finds a zone by its id.
+func (z zones) get(id string) rect {
+	for _, zone := range z {
+		if zone.id == id {
+			return zone.rect
+		}
+	}
+	return rect{}
+}
+
+// hit finds the zone at a point, if there is one.
+func (z zones) hit(x, y int) (zone, bool) {
+	for i := len(z) - 1; i >= 0; i-- {
+		if z[i].contains(x, y) {
+			return z[i], true
+		}
+	}
+	return zone{}, false
+}
+
+// clicks tells single clicks from double clicks. A click is a double click if
+// it's in the same place as the one before, and soon enough after it.
+type clicks struct {
+	interval time.Duration
+
+	last  time.Time
+	x, y  int
+	count int
+}
+
+// press records a click, and returns how many clicks in a row it makes.
+func (c *clicks) press(x, y int, at time.Time) int {
+	if c.count > 0 && x == c.x && y == c.y && at.Sub(c.last) <= c.interval {
+		c.count++
+	} else {
+		c.count = 1
+	}
+	c.last, c.x, c.y = at, x, y
+	return c.count
+}
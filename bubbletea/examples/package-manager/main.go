@@ -1,6 +1,9 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"math/rand"
 	"os"
@@ -11,41 +14,107 @@ import (
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
 )
 
+type pkgState int
+
+const (
+	statePending pkgState = iota
+	stateInstalling
+	stateInstalled
+	stateFailed
+	stateSkipped
+)
+
+// row is what we know about a single package.
+type row struct {
+	pkg     pkg
+	state   pkgState
+	percent float64
+	started time.Time
+	elapsed time.Duration
+	err     error
+}
+
 type model struct {
-	packages []string
-	index    int
+	rows     []row
+	index    map[string]int // package name to row
+	active   []string       // packages being installed, in start order
+	finished int
+	events   chan event
+	cancel   context.CancelFunc
 	width    int
 	height   int
 	spinner  spinner.Model
 	progress progress.Model
+	bar      progress.Model
 	done     bool
 }
 
 var (
 	currentPkgNameStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("211"))
 	doneStyle           = lipgloss.NewStyle().Margin(1, 2)
+	dimStyle            = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	cellStyle           = lipgloss.NewStyle().Padding(0, 1)
 	checkMark           = lipgloss.NewStyle().Foreground(lipgloss.Color("42")).SetString("✓")
+	crossMark           = lipgloss.NewStyle().Foreground(lipgloss.Color("196")).SetString("✗")
+	skipMark            = lipgloss.NewStyle().Foreground(lipgloss.Color("214")).SetString("-")
 )
 
-func newModel() model {
+func newModel(m manifest, inst installer, workers int) model {
 	p := progress.New(
 		progress.WithDefaultGradient(),
 		progress.WithWidth(40),
 		progress.WithoutPercentage(),
 	)
+	bar := progress.New(
+		progress.WithSolidFill("63"),
+		progress.WithWidth(20),
+	)
 	s := spinner.New()
 	s.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("63"))
+
+	rows := make([]row, len(m.Packages))
+	index := make(map[string]int, len(m.Packages))
+	for i, p := range m.Packages {
+		rows[i] = row{pkg: p}
+		index[p.Name] = i
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan event)
+	go schedule(ctx, m, inst, workers, events)
+
 	return model{
-		packages: getPackages(),
+		rows:     rows,
+		index:    index,
+		events:   events,
+		cancel:   cancel,
 		spinner:  s,
 		progress: p,
+		bar:      bar,
+	}
+}
+
+type (
+	eventMsg   event
+	allDoneMsg struct{}
+)
+
+// waitForEvent waits for the next event from the scheduler.
+func waitForEvent(events chan event) tea.Cmd {
+	return func() tea.Msg {
+		ev, ok := <-events
+		if !ok {
+			return allDoneMsg{}
+		}
+		return eventMsg(ev)
 	}
 }
 
 func (m model) Init() tea.Cmd {
-	return tea.Batch(downloadAndInstall(m.packages[m.index]), m.spinner.Tick)
+	return tea.Batch(waitForEvent(m.events), m.spinner.Tick)
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -55,28 +124,16 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "ctrl+c", "esc", "q":
+			m.cancel()
 			return m, tea.Quit
 		}
-	case installedPkgMsg:
-		pkg := m.packages[m.index]
-		if m.index >= len(m.packages)-1 {
-			// Everything's been installed. We're done!
-			m.done = true
-			return m, tea.Sequence(
-				tea.Printf("%s %s", checkMark, pkg), // print the last success message
-				tea.Quit,                            // exit the program
-			)
-		}
-
-		// Update progress bar
-		m.index++
-		progressCmd := m.progress.SetPercent(float64(m.index) / float64(len(m.packages)))
-
-		return m, tea.Batch(
-			progressCmd,
-			tea.Printf("%s %s", checkMark, pkg),     // print success message above our program
-			downloadAndInstall(m.packages[m.index]), // download the next package
-		)
+	case eventMsg:
+		return m.handleEvent(event(msg))
+	case allDoneMsg:
+		// Everything's been installed, failed or skipped. We're done!
+		m.done = true
+		m.cancel()
+		return m, tea.Quit
 	case spinner.TickMsg:
 		var cmd tea.Cmd
 		m.spinner, cmd = m.spinner.Update(msg)
@@ -91,50 +148,181 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
-func (m model) View() string {
-	n := len(m.packages)
-	w := lipgloss.Width(fmt.Sprintf("%d", n))
+func (m model) handleEvent(ev event) (tea.Model, tea.Cmd) {
+	i := m.index[ev.pkg.Name]
+	r := &m.rows[i]
+	next := waitForEvent(m.events)
+
+	switch ev.kind {
+	case eventStarted:
+		r.state = stateInstalling
+		r.started = time.Now()
+		m.active = append(m.active, r.pkg.Name)
+		return m, next
+	case eventProgress:
+		r.percent = ev.percent
+		return m, next
+	}
+
+	// The package is finished one way or another.
+	var line string
+	switch ev.kind {
+	case eventInstalled:
+		r.state = stateInstalled
+		r.percent = 1
+		line = fmt.Sprintf("%s %s", checkMark, r.pkg)
+	case eventFailed:
+		r.state = stateFailed
+		line = fmt.Sprintf("%s %s: %v", crossMark, r.pkg, ev.err)
+	case eventSkipped:
+		r.state = stateSkipped
+		line = fmt.Sprintf("%s %s %s", skipMark, r.pkg, dimStyle.Render("skipped, "+ev.err.Error()))
+	}
+	r.err = ev.err
+	if !r.started.IsZero() {
+		r.elapsed = time.Since(r.started)
+	}
+	m.active = remove(m.active, r.pkg.Name)
+	m.finished++
+
+	return m, tea.Batch(
+		m.progress.SetPercent(float64(m.finished)/float64(len(m.rows))),
+		tea.Printf("%s", line), // print the result above our program
+		next,
+	)
+}
 
+func remove(s []string, v string) []string {
+	out := s[:0:0]
+	for _, x := range s {
+		if x != v {
+			out = append(out, x)
+		}
+	}
+	return out
+}
+
+func (m model) View() string {
 	if m.done {
-		return doneStyle.Render(fmt.Sprintf("Done! Installed %d packages.\n", n))
+		return m.summaryView()
 	}
 
-	pkgCount := fmt.Sprintf(" %*d/%*d", w, m.index, w, n)
+	n := len(m.rows)
+	w := lipgloss.Width(fmt.Sprintf("%d", n))
 
-	spin := m.spinner.View() + " "
-	prog := m.progress.View()
-	cellsAvail := max(0, m.width-lipgloss.Width(spin+prog+pkgCount))
+	var b strings.Builder
+	for _, name := range m.active {
+		r := m.rows[m.index[name]]
+		spin := m.spinner.View() + " "
+		bar := m.bar.ViewAs(r.percent)
+		cellsAvail := max(0, m.width-lipgloss.Width(spin+bar)-1)
+		info := lipgloss.NewStyle().MaxWidth(cellsAvail).Render("Installing " + currentPkgNameStyle.Render(r.pkg.String()))
+		gap := strings.Repeat(" ", max(1, m.width-lipgloss.Width(spin+info+bar)))
+		b.WriteString(spin + info + gap + bar + "\n")
+	}
 
-	pkgName := currentPkgNameStyle.Render(m.packages[m.index])
-	info := lipgloss.NewStyle().MaxWidth(cellsAvail).Render("Installing " + pkgName)
+	pkgCount := fmt.Sprintf(" %*d/%*d", w, m.finished, w, n)
+	prog := m.progress.View()
+	gap := strings.Repeat(" ", max(0, m.width-lipgloss.Width(prog+pkgCount)))
+	b.WriteString(gap + prog + pkgCount)
 
-	cellsRemaining := max(0, m.width-lipgloss.Width(spin+info+prog+pkgCount))
-	gap := strings.Repeat(" ", cellsRemaining)
+	return b.String()
+}
 
-	return spin + info + gap + prog + pkgCount
+func (m model) summaryView() string {
+	var installed, failed, skipped int
+	t := table.New().
+		Border(lipgloss.NormalBorder()).
+		BorderStyle(dimStyle).
+		StyleFunc(func(int, int) lipgloss.Style { return cellStyle }).
+		Headers("PACKAGE", "STATUS", "TIME")
+	for _, r := range m.rows {
+		var status, elapsed string
+		switch r.state {
+		case stateInstalled:
+			installed++
+			status = checkMark.String() + " installed"
+		case stateFailed:
+			failed++
+			status = crossMark.String() + " failed"
+		case stateSkipped:
+			skipped++
+			status = skipMark.String() + " skipped"
+		default:
+			// Interrupted before we got to it.
+			continue
+		}
+		if r.elapsed > 0 {
+			elapsed = r.elapsed.Round(time.Millisecond).String()
+		}
+		t.Row(r.pkg.String(), status, elapsed)
+	}
+	return doneStyle.Render(fmt.Sprintf(
+		"%s\nDone! Installed %d packages, %d failed, %d skipped.\n",
+		t.Render(), installed, failed, skipped,
+	))
 }
 
-type installedPkgMsg string
+// failed reports whether any package failed to install.
+func (m model) failed() bool {
+	for _, r := range m.rows {
+		if r.state == stateFailed || r.state == stateSkipped {
+			return true
+		}
+	}
+	return false
+}
 
-func downloadAndInstall(pkg string) tea.Cmd {
-	// This is where you'd do i/o stuff to download and install packages. In
-	// our case we're just pausing for a moment to simulate the process.
-	d := time.Millisecond * time.Duration(rand.Intn(500)) //nolint:gosec
-	return tea.Tick(d, func(t time.Time) tea.Msg {
-		return installedPkgMsg(pkg)
-	})
+// fakeInstaller pretends to download and install packages. This is where
+// you'd do i/o stuff to download and install packages. In our case we're
+// just pausing for a moment to simulate the process.
+type fakeInstaller struct {
+	failRate float64
 }
 
-func max(a, b int) int {
-	if a > b {
-		return a
+func (f fakeInstaller) Install(ctx context.Context, _ pkg, progress func(float64)) error {
+	const steps = 10
+	d := time.Millisecond * time.Duration(100+rand.Intn(900)) //nolint:gosec
+	for i := 1; i <= steps; i++ {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(d / steps):
+		}
+		progress(float64(i) / steps)
+	}
+	if rand.Float64() < f.failRate { //nolint:gosec
+		return errors.New("checksum mismatch")
 	}
-	return b
+	return nil
 }
 
 func main() {
-	if _, err := tea.NewProgram(newModel()).Run(); err != nil {
+	var (
+		manifestPath string
+		workers      int
+		failRate     float64
+	)
+	flag.StringVar(&manifestPath, "manifest", "", "path to a JSON manifest (default: a random one)")
+	flag.IntVar(&workers, "workers", 4, "number of packages to install at once")
+	flag.Float64Var(&failRate, "fail", 0.05, "chance of a simulated install failing")
+	flag.Parse()
+
+	m := randomManifest()
+	if manifestPath != "" {
+		var err error
+		if m, err = readManifest(manifestPath); err != nil {
+			fmt.Println("Error reading manifest:", err)
+			os.Exit(1)
+		}
+	}
+
+	final, err := tea.NewProgram(newModel(m, fakeInstaller{failRate: failRate}, workers)).Run()
+	if err != nil {
 		fmt.Println("Error running program:", err)
 		os.Exit(1)
 	}
+	if final.(model).failed() {
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// pkg is a package declared in a manifest.
+type pkg struct {
+	Name    string   `json:"name"`
+	Version string   `json:"version"`
+	Deps    []string `json:"deps,omitempty"`
+}
+
+func (p pkg) String() string {
+	if p.Version == "" {
+		return p.Name
+	}
+	return p.Name + "-" + p.Version
+}
+
+// manifest is the list of packages to install, for example:
+//
+//	{
+//	  "packages": [
+//	    {"name": "chai", "version": "1.0.0"},
+//	    {"name": "hojicha", "version": "2.1.0", "deps": ["chai"]}
+//	  ]
+//	}
+type manifest struct {
+	Packages []pkg `json:"packages"`
+}
+
+func readManifest(path string) (manifest, error) {
+	var m manifest
+	bts, err := os.ReadFile(path)
+	if err != nil {
+		return m, err
+	}
+	if err := json.Unmarshal(bts, &m); err != nil {
+		return m, fmt.Errorf("%s: %w", path, err)
+	}
+	return m, m.validate()
+}
+
+// validate checks that package names are unique, that every dependency is
+// declared and that there are no dependency cycles.
+func (m manifest) validate() error {
+	byName := make(map[string]pkg, len(m.Packages))
+	for _, p := range m.Packages {
+		if p.Name == "" {
+			return errors.New("package with no name")
+		}
+		if _, ok := byName[p.Name]; ok {
+			return fmt.Errorf("package %s is declared twice", p.Name)
+		}
+		byName[p.Name] = p
+	}
+	for _, p := range m.Packages {
+		for _, d := range p.Deps {
+			if _, ok := byName[d]; !ok {
+				return fmt.Errorf("package %s depends on unknown package %s", p.Name, d)
+			}
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int, len(m.Packages))
+	var path []string
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case visiting:
+			i := slices.Index(path, name)
+			return fmt.Errorf("dependency cycle: %s", strings.Join(append(path[i:], name), " -> "))
+		case visited:
+			return nil
+		}
+		state[name] = visiting
+		path = append(path, name)
+		for _, d := range byName[name].Deps {
+			if err := visit(d); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = visited
+		return nil
+	}
+	for _, p := range m.Packages {
+		if err := visit(p.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"math/rand"
+	"slices"
 )
 
 var packages = []string{
@@ -37,16 +38,32 @@ var packages = []string{
 	"libyuzu",
 }
 
-func getPackages() []string {
-	pkgs := packages
-	copy(pkgs, packages)
+// randomManifest makes up a manifest where each package depends on up to two
+// of the packages before it, so there are never any cycles.
+func randomManifest() manifest {
+	names := make([]string, len(packages))
+	copy(names, packages)
 
-	rand.Shuffle(len(pkgs), func(i, j int) {
-		pkgs[i], pkgs[j] = pkgs[j], pkgs[i]
+	rand.Shuffle(len(names), func(i, j int) {
+		names[i], names[j] = names[j], names[i]
 	})
 
-	for k := range pkgs {
-		pkgs[k] += fmt.Sprintf("-%d.%d.%d", rand.Intn(10), rand.Intn(10), rand.Intn(10)) //nolint:gosec
+	var m manifest
+	for i, name := range names {
+		p := pkg{
+			Name:    name,
+			Version: fmt.Sprintf("%d.%d.%d", rand.Intn(10), rand.Intn(10), rand.Intn(10)), //nolint:gosec
+		}
+		for range rand.Intn(3) { //nolint:gosec
+			if i == 0 {
+				break
+			}
+			dep := names[rand.Intn(i)] //nolint:gosec
+			if !slices.Contains(p.Deps, dep) {
+				p.Deps = append(p.Deps, dep)
+			}
+		}
+		m.Packages = append(m.Packages, p)
 	}
-	return pkgs
+	return m
 }
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// installer downloads and installs a single package, reporting progress
+// between 0 and 1 as it goes.
+type installer interface {
+	Install(ctx context.Context, p pkg, progress func(float64)) error
+}
+
+type eventKind int
+
+const (
+	eventStarted eventKind = iota
+	eventProgress
+	eventInstalled
+	eventFailed
+	eventSkipped
+)
+
+// event describes something that happened to a package during a run.
+type event struct {
+	kind    eventKind
+	pkg     pkg
+	percent float64
+	err     error
+}
+
+// schedule installs the packages in the manifest, running at most workers
+// installs at once. A package is only installed once all of its dependencies
+// have been, and if a package fails everything that depends on it, directly
+// or not, is skipped.
+//
+// Events are sent on the given channel, which is closed once every package
+// has been installed, has failed or has been skipped, or as soon as ctx is
+// canceled. Nothing is left waiting to send after that.
+func schedule(ctx context.Context, m manifest, inst installer, workers int, events chan<- event) {
+	// Installs still report progress on events, so wait for them before
+	// closing it.
+	var wg sync.WaitGroup
+	defer func() {
+		wg.Wait()
+		close(events)
+	}()
+	workers = max(workers, 1)
+
+	// send gives up once ctx is canceled, since nobody may be listening.
+	send := func(ch chan<- event, ev event) bool {
+		select {
+		case ch <- ev:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	var (
+		waiting    = make(map[string]int, len(m.Packages)) // unmet deps
+		dependents = make(map[string][]pkg, len(m.Packages))
+		ready      []pkg
+		results    = make(chan event)
+		running    int
+		remaining  = len(m.Packages)
+	)
+	for _, p := range m.Packages {
+		waiting[p.Name] = len(p.Deps)
+		for _, d := range p.Deps {
+			dependents[d] = append(dependents[d], p)
+		}
+		if len(p.Deps) == 0 {
+			ready = append(ready, p)
+		}
+	}
+
+	// skip marks everything downstream of a failed package as skipped.
+	skipped := map[string]bool{}
+	var skip func(p pkg, cause string) bool
+	skip = func(p pkg, cause string) bool {
+		for _, d := range dependents[p.Name] {
+			if skipped[d.Name] {
+				continue
+			}
+			skipped[d.Name] = true
+			remaining--
+			if !send(events, event{kind: eventSkipped, pkg: d, err: fmt.Errorf("%s failed", cause)}) {
+				return false
+			}
+			if !skip(d, cause) {
+				return false
+			}
+		}
+		return true
+	}
+
+	for remaining > 0 {
+		for running < workers && len(ready) > 0 && ctx.Err() == nil {
+			p := ready[0]
+			ready = ready[1:]
+			running++
+			if !send(events, event{kind: eventStarted, pkg: p}) {
+				return
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := inst.Install(ctx, p, func(percent float64) {
+					send(events, event{kind: eventProgress, pkg: p, percent: percent})
+				})
+				if err != nil {
+					send(results, event{kind: eventFailed, pkg: p, err: err})
+					return
+				}
+				send(results, event{kind: eventInstalled, pkg: p, percent: 1})
+			}()
+		}
+		if running == 0 {
+			// Canceled with nothing in flight.
+			return
+		}
+
+		var ev event
+		select {
+		case ev = <-results:
+		case <-ctx.Done():
+			return
+		}
+		running--
+		remaining--
+		if !send(events, ev) {
+			return
+		}
+
+		switch ev.kind {
+		case eventInstalled:
+			for _, d := range dependents[ev.pkg.Name] {
+				waiting[d.Name]--
+				if waiting[d.Name] == 0 && !skipped[d.Name] {
+					ready = append(ready, d)
+				}
+			}
+		case eventFailed:
+			if !skip(ev.pkg, ev.pkg.Name) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testInstaller records the order packages were installed in and how many
+// installs ran at once.
+type testInstaller struct {
+	fail map[string]bool
+
+	mu      sync.Mutex
+	running int
+	peak    int
+	order   []string
+}
+
+func (ti *testInstaller) Install(ctx context.Context, p pkg, progress func(float64)) error {
+	ti.mu.Lock()
+	ti.running++
+	ti.peak = max(ti.peak, ti.running)
+	ti.mu.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+	progress(1)
+
+	ti.mu.Lock()
+	defer ti.mu.Unlock()
+	ti.running--
+	ti.order = append(ti.order, p.Name)
+	if ti.fail[p.Name] {
+		return errors.New("boom")
+	}
+	return nil
+}
+
+func run(t *testing.T, m manifest, inst installer, workers int) map[string]eventKind {
+	t.Helper()
+	if err := m.validate(); err != nil {
+		t.Fatal(err)
+	}
+	events := make(chan event)
+	go schedule(context.Background(), m, inst, workers, events)
+
+	results := map[string]eventKind{}
+	for ev := range events {
+		switch ev.kind {
+		case eventInstalled, eventFailed, eventSkipped:
+			if _, ok := results[ev.pkg.Name]; ok {
+				t.Fatalf("%s finished twice", ev.pkg.Name)
+			}
+			results[ev.pkg.Name] = ev.kind
+		}
+	}
+	return results
+}
+
+func testManifest() manifest {
+	return manifest{Packages: []pkg{
+		{Name: "a"},
+		{Name: "b"},
+		{Name: "c", Deps: []string{"a"}},
+		{Name: "d", Deps: []string{"b", "c"}},
+		{Name: "e"},
+		{Name: "f", Deps: []string{"d"}},
+	}}
+}
+
+func TestScheduleOrder(t *testing.T) {
+	m := testManifest()
+	inst := &testInstaller{}
+	results := run(t, m, inst, 3)
+
+	if len(results) != len(m.Packages) {
+		t.Fatalf("expected %d results, got %d", len(m.Packages), len(results))
+	}
+	pos := map[string]int{}
+	for i, name := range inst.order {
+		pos[name] = i
+	}
+	for _, p := range m.Packages {
+		if results[p.Name] != eventInstalled {
+			t.Errorf("%s was not installed", p.Name)
+		}
+		for _, d := range p.Deps {
+			if pos[d] > pos[p.Name] {
+				t.Errorf("%s was installed before its dependency %s", p.Name, d)
+			}
+		}
+	}
+	if inst.peak > 3 {
+		t.Errorf("expected at most 3 concurrent installs, got %d", inst.peak)
+	}
+	if inst.peak < 2 {
+		t.Errorf("expected independent packages to be installed concurrently")
+	}
+}
+
+func TestScheduleSingleWorker(t *testing.T) {
+	inst := &testInstaller{}
+	run(t, testManifest(), inst, 1)
+	if inst.peak != 1 {
+		t.Errorf("expected 1 concurrent install, got %d", inst.peak)
+	}
+}
+
+// blockingInstaller installs nothing until it's canceled.
+type blockingInstaller struct{}
+
+func (blockingInstaller) Install(ctx context.Context, p pkg, progress func(float64)) error {
+	progress(0.5)
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestScheduleCancel(t *testing.T) {
+	before := runtime.NumGoroutine()
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan event)
+	go schedule(ctx, testManifest(), blockingInstaller{}, 3, events)
+	if ev := <-events; ev.kind != eventStarted {
+		t.Fatalf("first event is %v, want a start", ev.kind)
+	}
+
+	// Nobody reads the rest of the events, like after quitting, and still
+	// nothing is left behind.
+	cancel()
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d goroutines still running after canceling", runtime.NumGoroutine()-before)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if _, ok := <-events; ok {
+		t.Error("events still open after canceling")
+	}
+}
+
+func TestScheduleFailureSkipsDependents(t *testing.T) {
+	inst := &testInstaller{fail: map[string]bool{"c": true}}
+	results := run(t, testManifest(), inst, 2)
+
+	want := map[string]eventKind{
+		"a": eventInstalled,
+		"b": eventInstalled,
+		"c": eventFailed,
+		"d": eventSkipped,
+		"e": eventInstalled,
+		"f": eventSkipped,
+	}
+	for name, kind := range want {
+		if results[name] != kind {
+			t.Errorf("%s: expected %v, got %v", name, kind, results[name])
+		}
+	}
+	for _, name := range inst.order {
+		if name == "d" || name == "f" {
+			t.Errorf("%s should not have been installed", name)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for name, tc := range map[string]struct {
+		m   manifest
+		err string
+	}{
+		"unknown dep": {
+			m:   manifest{Packages: []pkg{{Name: "a", Deps: []string{"nope"}}}},
+			err: "unknown package nope",
+		},
+		"duplicate": {
+			m:   manifest{Packages: []pkg{{Name: "a"}, {Name: "a"}}},
+			err: "declared twice",
+		},
+		"cycle": {
+			m: manifest{Packages: []pkg{
+				{Name: "a", Deps: []string{"c"}},
+				{Name: "b", Deps: []string{"a"}},
+				{Name: "c", Deps: []string{"b"}},
+			}},
+			err: "dependency cycle: a -> c -> b -> a",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := tc.m.validate()
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Fatalf("expected error containing %q, got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestRandomManifest(t *testing.T) {
+	if err := randomManifest().validate(); err != nil {
+		t.Fatal(err)
+	}
+}
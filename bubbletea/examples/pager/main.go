@@ -2,12 +2,22 @@ package main
 
 // An example program demonstrating the pager component from the Bubbles
 // component library.
+//
+// Usage:
+//
+//	pager [file]
+//	some-command | pager
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 
+	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -25,16 +35,67 @@ var (
 		b.Left = "┤"
 		return titleStyle.BorderStyle(b)
 	}()
+
+	promptStyle = func() lipgloss.Style {
+		b := lipgloss.RoundedBorder()
+		b.Right = "├"
+		return titleStyle.BorderStyle(b)
+	}()
+
+	followStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("42")).Bold(true)
+	helpStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
 )
 
 type model struct {
-	content  string
+	title    string
+	path     string    // file to read, empty when reading stdin
+	stdin    io.Reader // set when reading stdin
+	offset   int64     // how much of the file we've read
+	lines    []string
+	partial  string // trailing line with no newline yet
+	eof      bool
 	ready    bool
+	stale    bool // lines came in since the last render, and a render is due
 	viewport viewport.Model
+
+	// prompt is the kind of input we're reading: '/' or '?' for a search,
+	// ':' for a line number, or 0 when we're not prompting.
+	prompt rune
+	input  textinput.Model
+
+	search   *regexp.Regexp
+	backward bool
+	matches  []match
+	current  int
+	searched int // complete lines searched so far
+
+	lineNumbers bool
+	follow      bool
+	status      string
+}
+
+func newModel(path string, stdin io.Reader) model {
+	ti := textinput.New()
+	ti.Prompt = ""
+
+	title := "stdin"
+	if path != "" {
+		title = filepath.Base(path)
+	}
+	return model{
+		title:   title,
+		path:    path,
+		stdin:   stdin,
+		input:   ti,
+		current: -1,
+	}
 }
 
 func (m model) Init() tea.Cmd {
-	return nil
+	if m.stdin != nil {
+		return readStdin(m.stdin)
+	}
+	return readFrom(m.path, 0)
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -45,8 +106,51 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		if k := msg.String(); k == "ctrl+c" || k == "q" || k == "esc" {
+		if m.prompt != 0 {
+			return m.updatePrompt(msg)
+		}
+		m.status = ""
+
+		switch msg.String() {
+		case "ctrl+c", "q":
+			return m, tea.Quit
+		case "esc":
+			if m.search != nil {
+				m.clearSearch()
+				return m, nil
+			}
 			return m, tea.Quit
+		case "/", "?", ":":
+			m.prompt = []rune(msg.String())[0]
+			m.input.Reset()
+			return m, m.input.Focus()
+		case "n":
+			m.nextMatch(m.backward)
+			return m, nil
+		case "N":
+			m.nextMatch(!m.backward)
+			return m, nil
+		case "l":
+			m.lineNumbers = !m.lineNumbers
+			m.refresh()
+			return m, nil
+		case "g", "home":
+			m.follow = false
+			m.viewport.GotoTop()
+			return m, nil
+		case "G", "end":
+			m.viewport.GotoBottom()
+			return m, nil
+		case "F":
+			m.follow = !m.follow
+			if !m.follow {
+				return m, nil
+			}
+			m.viewport.GotoBottom()
+			if m.path != "" {
+				return m, followTick()
+			}
+			return m, nil
 		}
 
 	case tea.WindowSizeMsg:
@@ -62,21 +166,178 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			// here.
 			m.viewport = viewport.New(msg.Width, msg.Height-verticalMarginHeight)
 			m.viewport.YPosition = headerHeight
-			m.viewport.SetContent(m.content)
+			m.viewport.SetContent(m.render())
 			m.ready = true
 		} else {
 			m.viewport.Width = msg.Width
 			m.viewport.Height = msg.Height - verticalMarginHeight
 		}
+		m.input.Width = msg.Width / 2
+
+	case moreMsg:
+		if msg.reset {
+			m.lines, m.partial = nil, ""
+			m.matches, m.current, m.searched = nil, -1, 0
+		}
+		m.appendData(msg.data)
+		m.updateMatches()
+		m.offset = msg.offset
+		m.eof = msg.eof
+		switch {
+		case m.stdin == nil || msg.eof:
+			m.refresh()
+		case !m.stale:
+			m.stale = true
+			cmds = append(cmds, renderTick())
+		}
+		if m.stdin != nil && !msg.eof {
+			cmds = append(cmds, readStdin(m.stdin))
+		}
+		return m, tea.Batch(cmds...)
+
+	case renderTickMsg:
+		if m.stale {
+			m.stale = false
+			m.refresh()
+		}
+		return m, nil
+
+	case followTickMsg:
+		if !m.follow {
+			return m, nil
+		}
+		return m, tea.Batch(readFrom(m.path, m.offset), followTick())
+
+	case readErrMsg:
+		m.status = msg.err.Error()
+		return m, nil
 	}
 
 	// Handle keyboard and mouse events in the viewport
+	prev := m.viewport.YOffset
 	m.viewport, cmd = m.viewport.Update(msg)
 	cmds = append(cmds, cmd)
 
+	// Scrolling up stops following, just like in less.
+	if m.viewport.YOffset < prev {
+		m.follow = false
+	}
+
 	return m, tea.Batch(cmds...)
 }
 
+// updatePrompt handles keys while we're reading a search pattern or a line
+// number.
+func (m model) updatePrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc, tea.KeyCtrlC:
+		m.prompt = 0
+		m.input.Blur()
+		return m, nil
+	case tea.KeyEnter:
+		prompt, value := m.prompt, m.input.Value()
+		m.prompt = 0
+		m.input.Blur()
+		if value == "" {
+			return m, nil
+		}
+		if prompt == ':' {
+			m.gotoLine(value)
+		} else {
+			m.startSearch(value, prompt == '?')
+		}
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+func (m *model) gotoLine(value string) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		m.status = "invalid line number: " + value
+		return
+	}
+	m.follow = false
+	m.viewport.SetYOffset(n - 1)
+}
+
+func (m *model) startSearch(pattern string, backward bool) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		m.status = "invalid pattern: " + err.Error()
+		return
+	}
+	m.search = re
+	m.backward = backward
+	m.matches, m.current, m.searched = nil, -1, 0
+	m.updateMatches()
+	if len(m.matches) == 0 {
+		m.status = "pattern not found"
+		m.refresh()
+		return
+	}
+
+	// Like less, start looking from the top of the screen, or the bottom
+	// when searching backward.
+	from := m.viewport.YOffset
+	if backward {
+		from += m.viewport.Height - 1
+	}
+	m.current = firstMatch(m.matches, from, backward)
+	m.showCurrent()
+}
+
+func (m *model) clearSearch() {
+	m.search = nil
+	m.matches = nil
+	m.current = -1
+	m.refresh()
+}
+
+// nextMatch moves to the next match, or the previous one if backward is
+// set, wrapping around at either end.
+func (m *model) nextMatch(backward bool) {
+	if len(m.matches) == 0 {
+		if m.search != nil {
+			m.status = "pattern not found"
+		}
+		return
+	}
+	if backward {
+		m.current = (m.current - 1 + len(m.matches)) % len(m.matches)
+	} else {
+		m.current = (m.current + 1) % len(m.matches)
+	}
+	m.showCurrent()
+}
+
+// showCurrent highlights the current match and scrolls to it if it's off
+// screen.
+func (m *model) showCurrent() {
+	m.follow = false
+	m.refresh()
+	line := m.matches[m.current].line
+	if line < m.viewport.YOffset || line >= m.viewport.YOffset+m.viewport.Height {
+		m.viewport.SetYOffset(line - m.viewport.Height/3)
+	}
+}
+
+// refresh re-renders the content after the lines, search or display
+// options change.
+func (m *model) refresh() {
+	if !m.ready {
+		return
+	}
+	m.stale = false
+	m.viewport.SetContent(m.render())
+	if m.follow {
+		m.viewport.GotoBottom()
+	}
+}
+
 func (m model) View() string {
 	if !m.ready {
 		return "\n  Initializing..."
@@ -85,13 +346,37 @@ func (m model) View() string {
 }
 
 func (m model) headerView() string {
-	title := titleStyle.Render("Mr. Pager")
+	title := titleStyle.Render("Mr. Pager: " + m.title)
 	line := strings.Repeat("─", max(0, m.viewport.Width-lipgloss.Width(title)))
 	return lipgloss.JoinHorizontal(lipgloss.Center, title, line)
 }
 
 func (m model) footerView() string {
-	info := infoStyle.Render(fmt.Sprintf("%3.f%%", m.viewport.ScrollPercent()*100))
+	if m.prompt != 0 {
+		prompt := promptStyle.Render(string(m.prompt) + m.input.View())
+		line := strings.Repeat("─", max(0, m.viewport.Width-lipgloss.Width(prompt)))
+		return lipgloss.JoinHorizontal(lipgloss.Center, prompt, line)
+	}
+
+	var parts []string
+	switch {
+	case m.status != "":
+		parts = append(parts, m.status)
+	case m.search != nil:
+		prompt := '/'
+		if m.backward {
+			prompt = '?'
+		}
+		parts = append(parts, fmt.Sprintf("%c%s %d/%d", prompt, m.search, m.current+1, len(m.matches)))
+	default:
+		parts = append(parts, helpStyle.Render("/ search • n/N next/prev • : line • l numbers • F follow"))
+	}
+	if m.follow {
+		parts = append(parts, followStyle.Render("FOLLOW"))
+	}
+	parts = append(parts, fmt.Sprintf("%3.f%%", m.viewport.ScrollPercent()*100))
+
+	info := infoStyle.Render(strings.Join(parts, "  "))
 	line := strings.Repeat("─", max(0, m.viewport.Width-lipgloss.Width(info)))
 	return lipgloss.JoinHorizontal(lipgloss.Center, line, info)
 }
@@ -104,18 +389,39 @@ func max(a, b int) int {
 }
 
 func main() {
-	// Load some text for our viewport
-	content, err := os.ReadFile("artichoke.md")
+	var (
+		path  = "artichoke.md"
+		stdin io.Reader
+		opts  = []tea.ProgramOption{
+			tea.WithAltScreen(),       // use the full size of the terminal in its "alternate screen buffer"
+			tea.WithMouseCellMotion(), // turn on mouse support so we can track the mouse wheel
+		}
+	)
+
+	stat, err := os.Stdin.Stat()
 	if err != nil {
-		fmt.Println("could not load file:", err)
+		fmt.Println("could not stat stdin:", err)
 		os.Exit(1)
 	}
 
-	p := tea.NewProgram(
-		model{content: string(content)},
-		tea.WithAltScreen(),       // use the full size of the terminal in its "alternate screen buffer"
-		tea.WithMouseCellMotion(), // turn on mouse support so we can track the mouse wheel
-	)
+	switch {
+	case len(os.Args) > 1:
+		path = os.Args[1]
+	case stat.Mode()&os.ModeCharDevice == 0:
+		// Something's being piped in, so read that and get keyboard input
+		// from the terminal instead.
+		path, stdin = "", os.Stdin
+		opts = append(opts, tea.WithInputTTY())
+	}
+
+	if path != "" {
+		if _, err := os.Stat(path); err != nil {
+			fmt.Println("could not load file:", err)
+			os.Exit(1)
+		}
+	}
+
+	p := tea.NewProgram(newModel(path, stdin), opts...)
 
 	if _, err := p.Run(); err != nil {
 		fmt.Println("could not run program:", err)
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// While stdin is streaming in, chunks are rendered together on a tick
+// rather than one at a time.
+func TestStdinRendersOnTick(t *testing.T) {
+	var tm tea.Model = newModel("", strings.NewReader(""))
+	tm, _ = tm.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+
+	ticks := 0
+	for range 3 {
+		var cmd tea.Cmd
+		tm, cmd = tm.Update(moreMsg{data: "line\n"})
+		ticks += countTicks(cmd)
+	}
+	m := tm.(model)
+	if n := m.viewport.TotalLineCount(); n > 1 {
+		t.Errorf("rendered %d lines before the tick", n)
+	}
+	if ticks != 1 {
+		t.Errorf("scheduled %d renders, want 1", ticks)
+	}
+
+	tm, _ = tm.Update(renderTickMsg{})
+	if n := tm.(model).viewport.TotalLineCount(); n != 3 {
+		t.Errorf("rendered %d lines after the tick, want 3", n)
+	}
+
+	// The end of the input is shown straight away.
+	tm, _ = tm.Update(moreMsg{data: "last", eof: true})
+	if n := tm.(model).viewport.TotalLineCount(); n != 4 {
+		t.Errorf("rendered %d lines at the end, want 4", n)
+	}
+}
+
+// countTicks runs cmd and reports how many render ticks it scheduled.
+func countTicks(cmd tea.Cmd) int {
+	if cmd == nil {
+		return 0
+	}
+	switch msg := cmd().(type) {
+	case renderTickMsg:
+		return 1
+	case tea.BatchMsg:
+		n := 0
+		for _, c := range msg {
+			n += countTicks(c)
+		}
+		return n
+	}
+	return 0
+}
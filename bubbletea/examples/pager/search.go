@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	matchStyle   = lipgloss.NewStyle().Background(lipgloss.Color("237")).Foreground(lipgloss.Color("229"))
+	currentStyle = lipgloss.NewStyle().Background(lipgloss.Color("212")).Foreground(lipgloss.Color("0"))
+	lineNumStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+)
+
+// match is the position of a search match.
+type match struct {
+	line, start, end int
+}
+
+// findMatches returns every match of re in lines, in order. first is the
+// line number of lines[0].
+func findMatches(re *regexp.Regexp, lines []string, first int) []match {
+	var matches []match
+	for i, line := range lines {
+		for _, loc := range re.FindAllStringIndex(line, -1) {
+			if loc[0] == loc[1] {
+				// Skip empty matches, there's nothing to highlight.
+				continue
+			}
+			matches = append(matches, match{line: first + i, start: loc[0], end: loc[1]})
+		}
+	}
+	return matches
+}
+
+// firstMatch returns the index of the first match at or after line when
+// searching forward, or at or before it when searching backward. It wraps
+// around and returns -1 if there are no matches at all.
+func firstMatch(matches []match, line int, backward bool) int {
+	if len(matches) == 0 {
+		return -1
+	}
+	if backward {
+		for i := len(matches) - 1; i >= 0; i-- {
+			if matches[i].line <= line {
+				return i
+			}
+		}
+		return len(matches) - 1
+	}
+	for i, mt := range matches {
+		if mt.line >= line {
+			return i
+		}
+	}
+	return 0
+}
+
+// updateMatches searches the lines that have come in since it was last
+// called, so that a long stream isn't searched from the top every time more
+// of it arrives. The partial line at the end is searched again, since it may
+// have grown.
+func (m *model) updateMatches() {
+	if m.search == nil {
+		return
+	}
+	var cur match
+	if m.current >= 0 {
+		cur = m.matches[m.current]
+	}
+	keep := len(m.matches)
+	for keep > 0 && m.matches[keep-1].line >= m.searched {
+		keep--
+	}
+	m.matches = append(m.matches[:keep], findMatches(m.search, m.allLines()[m.searched:], m.searched)...)
+	m.searched = len(m.lines)
+	if m.current >= keep {
+		// The current match was on the partial line, and may be gone now.
+		m.current = slices.Index(m.matches, cur)
+	}
+}
+
+// render builds the viewport content with line numbers and highlighted
+// matches.
+func (m model) render() string {
+	lines := m.allLines()
+	width := len(fmt.Sprint(len(lines)))
+
+	var cur match
+	if m.current >= 0 && m.current < len(m.matches) {
+		cur = m.matches[m.current]
+	} else {
+		cur.line = -1
+	}
+
+	var b strings.Builder
+	mi := 0
+	for i, line := range lines {
+		if m.lineNumbers {
+			b.WriteString(lineNumStyle.Render(fmt.Sprintf("%*d ", width, i+1)))
+		}
+
+		// Matches are sorted, so we can walk them alongside the lines.
+		for mi < len(m.matches) && m.matches[mi].line < i {
+			mi++
+		}
+		pos := 0
+		for ; mi < len(m.matches) && m.matches[mi].line == i; mi++ {
+			mt := m.matches[mi]
+			style := matchStyle
+			if mt == cur {
+				style = currentStyle
+			}
+			b.WriteString(line[pos:mt.start])
+			b.WriteString(style.Render(line[mt.start:mt.end]))
+			pos = mt.end
+		}
+		b.WriteString(line[pos:])
+
+		if i < len(lines)-1 {
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
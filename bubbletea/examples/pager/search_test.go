@@ -0,0 +1,98 @@
+package main
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestFindMatches(t *testing.T) {
+	tests := []struct {
+		pattern string
+		lines   []string
+		want    []match
+	}{
+		{"an", []string{"banana", "", "and"}, []match{{0, 1, 3}, {0, 3, 5}, {2, 0, 2}}},
+		{"x*", []string{"abc", "xx"}, []match{{1, 0, 2}}}, // empty matches are skipped
+		{"zz", []string{"abc"}, nil},
+	}
+	for _, tt := range tests {
+		got := findMatches(regexp.MustCompile(tt.pattern), tt.lines, 0)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("findMatches(%q, %q) = %v, want %v", tt.pattern, tt.lines, got, tt.want)
+		}
+	}
+	if got := findMatches(regexp.MustCompile("b"), []string{"b"}, 7); got[0].line != 7 {
+		t.Errorf("match on line %d, want 7", got[0].line)
+	}
+}
+
+func TestFirstMatch(t *testing.T) {
+	matches := []match{{line: 2}, {line: 5}, {line: 5}, {line: 9}}
+	tests := []struct {
+		line     int
+		backward bool
+		want     int
+	}{
+		{0, false, 0},
+		{5, false, 1},
+		{6, false, 3},
+		{10, false, 0}, // wraps around
+		{5, true, 2},
+		{8, true, 2},
+		{1, true, 3}, // wraps around
+	}
+	for _, tt := range tests {
+		if got := firstMatch(matches, tt.line, tt.backward); got != tt.want {
+			t.Errorf("firstMatch(%d, backward %v) = %d, want %d", tt.line, tt.backward, got, tt.want)
+		}
+	}
+	if got := firstMatch(nil, 0, false); got != -1 {
+		t.Errorf("firstMatch with no matches = %d", got)
+	}
+}
+
+// Searching a stream as it comes in finds the same matches as searching it
+// all at once, including on lines that arrive in pieces.
+func TestUpdateMatches(t *testing.T) {
+	input := "one fox\ntwo\nfo" + "x three\nfox fox\nlast f" + "ox"
+	m := newModel("", strings.NewReader(""))
+	m.startSearch("fox", false)
+	for _, chunk := range []string{"one fox\ntwo\nfo", "x three\nfox fox\nlast f", "ox"} {
+		m.appendData(chunk)
+		m.updateMatches()
+	}
+	whole := newModel("", strings.NewReader(""))
+	whole.appendData(input)
+	whole.startSearch("fox", false)
+	if !reflect.DeepEqual(m.matches, whole.matches) || len(m.matches) != 5 {
+		t.Errorf("matches = %v, want %v", m.matches, whole.matches)
+	}
+	if m.searched != 4 {
+		t.Errorf("searched %d lines, want 4", m.searched)
+	}
+
+	// The current match stays put as more comes in, even when it's on the
+	// partial line, which is searched again.
+	m.current = 4
+	m.appendData("\nfox again\n")
+	m.updateMatches()
+	if m.current != 4 || len(m.matches) != 6 {
+		t.Errorf("current = %d of %d, want 4 of 6", m.current, len(m.matches))
+	}
+}
+
+// The status shows which way the search goes.
+func TestSearchStatus(t *testing.T) {
+	m := newModel("", strings.NewReader(""))
+	m.appendData("fox\n")
+	m.startSearch("fox", true)
+	if got := m.footerView(); !strings.Contains(got, "?fox 1/1") {
+		t.Errorf("footer = %q, want ?fox", got)
+	}
+	m.startSearch("fox", false)
+	if got := m.footerView(); !strings.Contains(got, "/fox 1/1") {
+		t.Errorf("footer = %q, want /fox", got)
+	}
+}
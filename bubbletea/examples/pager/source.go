@@ -0,0 +1,116 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	followInterval = 500 * time.Millisecond
+	renderInterval = 100 * time.Millisecond
+	readChunkSize  = 64 * 1024
+)
+
+type (
+	// moreMsg carries data read from the file or stdin.
+	moreMsg struct {
+		data   string
+		offset int64 // where the next read should start
+		reset  bool  // the file was truncated, start over
+		eof    bool
+	}
+	followTickMsg struct{}
+	renderTickMsg struct{}
+	readErrMsg    struct{ err error }
+)
+
+// readFrom reads whatever has been added to the file since offset.
+func readFrom(path string, offset int64) tea.Cmd {
+	return func() tea.Msg {
+		f, err := os.Open(path)
+		if err != nil {
+			return readErrMsg{err}
+		}
+		defer f.Close() //nolint:errcheck
+
+		info, err := f.Stat()
+		if err != nil {
+			return readErrMsg{err}
+		}
+		var msg moreMsg
+		if info.Size() < offset {
+			// Truncated, like a rotated log.
+			msg.reset = true
+			offset = 0
+		}
+		if _, err := f.Seek(offset, io.SeekStart); err != nil {
+			return readErrMsg{err}
+		}
+		bts, err := io.ReadAll(f)
+		if err != nil {
+			return readErrMsg{err}
+		}
+		msg.data = string(bts)
+		msg.offset = offset + int64(len(bts))
+		msg.eof = true
+		return msg
+	}
+}
+
+// readStdin reads the next chunk from stdin. We keep calling it until we hit
+// the end of the input so that we can show what we have while the rest is
+// still coming in.
+func readStdin(r io.Reader) tea.Cmd {
+	return func() tea.Msg {
+		buf := make([]byte, readChunkSize)
+		n, err := r.Read(buf)
+		msg := moreMsg{data: string(buf[:n])}
+		switch {
+		case errors.Is(err, io.EOF):
+			msg.eof = true
+		case err != nil:
+			return readErrMsg{err}
+		}
+		return msg
+	}
+}
+
+func followTick() tea.Cmd {
+	return tea.Tick(followInterval, func(time.Time) tea.Msg {
+		return followTickMsg{}
+	})
+}
+
+// renderTick batches up re-rendering while stdin is streaming in, rather
+// than rendering the whole buffer again for every chunk.
+func renderTick() tea.Cmd {
+	return tea.Tick(renderInterval, func(time.Time) tea.Msg {
+		return renderTickMsg{}
+	})
+}
+
+// appendData splits data into lines, holding on to a trailing partial line
+// until the rest of it shows up.
+func (m *model) appendData(data string) {
+	if data == "" {
+		return
+	}
+	parts := strings.Split(m.partial+data, "\n")
+	m.partial = parts[len(parts)-1]
+	for _, line := range parts[:len(parts)-1] {
+		m.lines = append(m.lines, strings.TrimSuffix(line, "\r"))
+	}
+}
+
+// allLines returns the complete lines plus any partial one.
+func (m model) allLines() []string {
+	if m.partial == "" {
+		return m.lines
+	}
+	return append(m.lines[:len(m.lines):len(m.lines)], m.partial)
+}
@@ -4,9 +4,17 @@ package main
 // More so, this serves as proof that Bubble Tea will automatically listen for
 // keystrokes when input is not a TTY, such as when data is piped or redirected
 // in.
+//
+// With -stream it works as a picker instead, a little like fzf: lines are
+// read while the program runs, you filter and choose them, and the ones you
+// chose are written to stdout.
+//
+//	ls | pipe
+//	find . -name '*.go' | pipe -stream | xargs wc -l
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
 	"io"
 	"os"
@@ -18,6 +26,9 @@ import (
 )
 
 func main() {
+	streaming := flag.Bool("stream", false, "pick lines from the input as it streams in and print them")
+	flag.Parse()
+
 	stat, err := os.Stdin.Stat()
 	if err != nil {
 		panic(err)
@@ -28,6 +39,10 @@ func main() {
 		os.Exit(1)
 	}
 
+	if *streaming {
+		os.Exit(pick())
+	}
+
 	reader := bufio.NewReader(os.Stdin)
 	var b strings.Builder
 
@@ -51,6 +66,43 @@ func main() {
 	}
 }
 
+// pick runs the picker and prints the chosen lines. It returns the exit
+// code, which follows fzf: 1 when nothing matched and 130 when the user gave
+// up.
+func pick() int {
+	// Stdin is taken, and stdout is for the result, so we talk to the
+	// terminal directly. Bubble Tea opens it for input for us.
+	out, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+	if err != nil {
+		out = os.Stderr
+	}
+	defer out.Close() //nolint:errcheck
+
+	p := tea.NewProgram(
+		newPicker(newStream(os.Stdin)),
+		tea.WithInputTTY(),
+		tea.WithOutput(out),
+		tea.WithAltScreen(),
+	)
+	m, err := p.Run()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Couldn't start program:", err)
+		return 2
+	}
+
+	result := m.(picker)
+	switch {
+	case result.aborted:
+		return 130
+	case len(result.chosen) == 0:
+		return 1
+	}
+	for _, line := range result.chosen {
+		fmt.Println(line)
+	}
+	return 0
+}
+
 type model struct {
 	userInput textinput.Model
 }
@@ -0,0 +1,376 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/sahilm/fuzzy"
+)
+
+// maxBatch is the most lines we hand to the picker at once. Batching keeps
+// us from re-rendering for every line of a fast stream.
+const maxBatch = 4096
+
+var (
+	promptStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("63")).Bold(true)
+	infoStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	cursorStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("212")).Bold(true)
+	currentStyle  = lipgloss.NewStyle().Background(lipgloss.Color("236"))
+	markStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	matchStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("205")).Bold(true)
+	curMatchStyle = matchStyle.Background(lipgloss.Color("236"))
+)
+
+// stream reads lines in the background so the picker can show them as they
+// come in.
+type stream struct {
+	lines chan string
+	err   error // only read once lines is closed
+}
+
+func newStream(r io.Reader) *stream {
+	s := &stream{lines: make(chan string, maxBatch)}
+	go func() {
+		defer close(s.lines)
+		sc := bufio.NewScanner(r)
+		sc.Buffer(make([]byte, 64*1024), 1024*1024)
+		for sc.Scan() {
+			s.lines <- sc.Text()
+		}
+		s.err = sc.Err()
+	}()
+	return s
+}
+
+type linesMsg struct {
+	lines []string
+	eof   bool
+	err   error
+}
+
+// waitForLines waits for the next line and then grabs whatever else is
+// ready, without waiting any longer.
+func waitForLines(s *stream) tea.Cmd {
+	return func() tea.Msg {
+		line, ok := <-s.lines
+		if !ok {
+			return linesMsg{eof: true, err: s.err}
+		}
+		lines := []string{line}
+		for len(lines) < maxBatch {
+			select {
+			case line, ok := <-s.lines:
+				if !ok {
+					return linesMsg{lines: lines, eof: true, err: s.err}
+				}
+				lines = append(lines, line)
+			default:
+				return linesMsg{lines: lines}
+			}
+		}
+		return linesMsg{lines: lines}
+	}
+}
+
+// item is a line that matches the query.
+type item struct {
+	index   int   // into picker.lines
+	score   int   // higher is better
+	matched []int // byte offsets of the matched characters
+}
+
+// lineSource lets fuzzy search a batch of lines.
+type lineSource []string
+
+func (s lineSource) String(i int) string { return s[i] }
+func (s lineSource) Len() int            { return len(s) }
+
+// filter returns the items in lines matching query, with their indexes
+// starting at offset. An empty query matches everything.
+func filter(query string, lines []string, offset int) []item {
+	items := make([]item, 0, len(lines))
+	if query == "" {
+		for i := range lines {
+			items = append(items, item{index: offset + i})
+		}
+		return items
+	}
+	for _, m := range fuzzy.FindFromNoSort(query, lineSource(lines)) {
+		items = append(items, item{index: offset + m.Index, score: m.Score, matched: m.MatchedIndexes})
+	}
+	return items
+}
+
+// sortItems puts the best matches first. Ties stay in input order.
+func sortItems(items []item) {
+	slices.SortStableFunc(items, func(a, b item) int {
+		return b.score - a.score
+	})
+}
+
+type picker struct {
+	input   textinput.Model
+	spinner spinner.Model
+	stream  *stream
+
+	lines    []string
+	items    []item
+	selected map[int]bool // by line index
+	cursor   int          // into items
+	offset   int          // first item on screen
+	eof      bool
+	err      error
+
+	width, height int
+
+	chosen  []string
+	aborted bool
+}
+
+func newPicker(s *stream) picker {
+	ti := textinput.New()
+	ti.Prompt = promptStyle.Render("> ")
+	ti.Placeholder = "type to filter"
+	ti.Focus()
+
+	sp := spinner.New()
+	sp.Spinner = spinner.MiniDot
+	sp.Style = infoStyle
+
+	return picker{
+		input:    ti,
+		spinner:  sp,
+		stream:   s,
+		selected: map[int]bool{},
+		height:   10,
+	}
+}
+
+func (m picker) Init() tea.Cmd {
+	return tea.Batch(textinput.Blink, m.spinner.Tick, waitForLines(m.stream))
+}
+
+func (m picker) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.input.Width = max(msg.Width-4, 1)
+		m.scroll()
+		return m, nil
+
+	case linesMsg:
+		// Only the new lines need matching, then the lot gets re-ranked.
+		// The cursor stays on the line it was on, wherever that ends up.
+		cur := -1
+		if m.cursor < len(m.items) {
+			cur = m.items[m.cursor].index
+		}
+		m.items = append(m.items, filter(m.input.Value(), msg.lines, len(m.lines))...)
+		if m.input.Value() != "" {
+			sortItems(m.items)
+			if i := slices.IndexFunc(m.items, func(it item) bool { return it.index == cur }); i >= 0 {
+				m.cursor = i
+				m.scroll()
+			}
+		}
+		m.lines = append(m.lines, msg.lines...)
+		if msg.eof {
+			m.eof, m.err = true, msg.err
+			return m, nil
+		}
+		return m, waitForLines(m.stream)
+
+	case spinner.TickMsg:
+		if m.eof {
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "esc":
+			m.aborted = true
+			return m, tea.Quit
+		case "enter":
+			m.chosen = m.choose()
+			return m, tea.Quit
+		case "up", "ctrl+p", "ctrl+k":
+			m.move(-1)
+			return m, nil
+		case "down", "ctrl+n", "ctrl+j":
+			m.move(1)
+			return m, nil
+		case "pgup":
+			m.move(-m.listHeight())
+			return m, nil
+		case "pgdown":
+			m.move(m.listHeight())
+			return m, nil
+		case "tab":
+			m.toggle()
+			m.move(1)
+			return m, nil
+		case "shift+tab":
+			m.toggle()
+			m.move(-1)
+			return m, nil
+		}
+	}
+
+	prev := m.input.Value()
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	if m.input.Value() != prev {
+		m.items = filter(m.input.Value(), m.lines, 0)
+		sortItems(m.items)
+		m.cursor, m.offset = 0, 0
+	}
+	return m, cmd
+}
+
+func (m *picker) move(n int) {
+	m.cursor = max(0, min(m.cursor+n, len(m.items)-1))
+	m.scroll()
+}
+
+// scroll keeps the cursor on screen.
+func (m *picker) scroll() {
+	h := m.listHeight()
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	} else if m.cursor >= m.offset+h {
+		m.offset = m.cursor - h + 1
+	}
+}
+
+func (m *picker) toggle() {
+	if m.cursor >= len(m.items) {
+		return
+	}
+	i := m.items[m.cursor].index
+	if m.selected[i] {
+		delete(m.selected, i)
+	} else {
+		m.selected[i] = true
+	}
+}
+
+// choose returns the selected lines in the order they came in, or the line
+// under the cursor if nothing's selected.
+func (m picker) choose() []string {
+	if len(m.selected) == 0 {
+		if m.cursor < len(m.items) {
+			return []string{m.lines[m.items[m.cursor].index]}
+		}
+		return nil
+	}
+	indexes := make([]int, 0, len(m.selected))
+	for i := range m.selected {
+		indexes = append(indexes, i)
+	}
+	slices.Sort(indexes)
+	chosen := make([]string, len(indexes))
+	for n, i := range indexes {
+		chosen[n] = m.lines[i]
+	}
+	return chosen
+}
+
+// listHeight is how many lines we have for items, after the prompt and the
+// info line.
+func (m picker) listHeight() int {
+	return max(m.height-2, 1)
+}
+
+func (m picker) View() string {
+	var b strings.Builder
+	b.WriteString(m.input.View() + "\n")
+
+	info := fmt.Sprintf("  %d/%d", len(m.items), len(m.lines))
+	if len(m.selected) > 0 {
+		info += fmt.Sprintf(" (%d selected)", len(m.selected))
+	}
+	if !m.eof {
+		info += " " + m.spinner.View()
+	} else if m.err != nil {
+		info += " error reading input: " + m.err.Error()
+	}
+	b.WriteString(infoStyle.Render(info))
+
+	end := min(m.offset+m.listHeight(), len(m.items))
+	for n := m.offset; n < end; n++ {
+		it := m.items[n]
+		gutter, mark := " ", " "
+		if n == m.cursor {
+			gutter = cursorStyle.Render("▌")
+		}
+		if m.selected[it.index] {
+			mark = markStyle.Render("●")
+		}
+		b.WriteString("\n" + gutter + mark + " " + m.renderLine(it, n == m.cursor))
+	}
+	return b.String()
+}
+
+// renderLine highlights the matched characters and cuts the line to fit.
+func (m picker) renderLine(it item, current bool) string {
+	width := m.width - 3 // the gutter
+	if width <= 0 {
+		width = 80
+	}
+	base, hl := lipgloss.NewStyle(), matchStyle
+	if current {
+		base, hl = currentStyle, curMatchStyle
+	}
+
+	// Collect runs of characters with the same style so we don't wrap
+	// every one of them in escape codes.
+	var (
+		b       strings.Builder
+		run     strings.Builder
+		inMatch bool
+		used    int
+		mi      int
+	)
+	flush := func() {
+		if inMatch {
+			b.WriteString(hl.Render(run.String()))
+		} else {
+			b.WriteString(base.Render(run.String()))
+		}
+		run.Reset()
+	}
+	for i, r := range m.lines[it.index] {
+		s := string(r)
+		if r == '\t' {
+			s = "    "
+		}
+		w := lipgloss.Width(s)
+		if used+w > width {
+			break
+		}
+		used += w
+
+		// Matched offsets are sorted, so walk them alongside the line.
+		for mi < len(it.matched) && it.matched[mi] < i {
+			mi++
+		}
+		matched := mi < len(it.matched) && it.matched[mi] == i
+		if matched != inMatch && run.Len() > 0 {
+			flush()
+		}
+		inMatch = matched
+		run.WriteString(s)
+	}
+	flush()
+	return b.String()
+}
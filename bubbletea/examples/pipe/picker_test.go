@@ -0,0 +1,111 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestFilter(t *testing.T) {
+	lines := []string{"main.go", "picker.go", "README.md", "go.mod"}
+
+	items := filter("", lines, 10)
+	if len(items) != len(lines) || items[0].index != 10 || items[3].index != 13 {
+		t.Errorf("empty query = %+v, want every line", items)
+	}
+
+	items = filter("go", lines, 0)
+	sortItems(items)
+	var got []string
+	for _, it := range items {
+		got = append(got, lines[it.index])
+	}
+	// "go.mod" starts with the match, so it ranks first.
+	if want := []string{"go.mod", "main.go", "picker.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("filter(go) = %q, want %q", got, want)
+	}
+}
+
+func send(m tea.Model, msgs ...tea.Msg) tea.Model {
+	for _, msg := range msgs {
+		m, _ = m.Update(msg)
+	}
+	return m
+}
+
+func keys(s string) []tea.Msg {
+	var msgs []tea.Msg
+	for _, r := range s {
+		msgs = append(msgs, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	return msgs
+}
+
+func TestPickerStreaming(t *testing.T) {
+	var m tea.Model = newPicker(newStream(strings.NewReader("")))
+	m = send(m, tea.WindowSizeMsg{Width: 40, Height: 10})
+	m = send(m, linesMsg{lines: []string{"apple", "banana", "cherry"}})
+
+	// Filter while lines are still coming in.
+	m = send(m, keys("an")...)
+	if got := len(m.(picker).items); got != 1 {
+		t.Fatalf("got %d matches for \"an\", want 1", got)
+	}
+	m = send(m, linesMsg{lines: []string{"mango", "grape"}, eof: true})
+	p := m.(picker)
+	if len(p.items) != 2 || !p.eof {
+		t.Fatalf("got %d matches after more lines, want 2", len(p.items))
+	}
+	if !strings.Contains(p.View(), "2/5") {
+		t.Errorf("view doesn't show the counts:\n%s", p.View())
+	}
+
+	// Select both and accept.
+	m = send(m, tea.KeyMsg{Type: tea.KeyPgUp}, tea.KeyMsg{Type: tea.KeyTab}, tea.KeyMsg{Type: tea.KeyTab}, tea.KeyMsg{Type: tea.KeyEnter})
+	if got, want := m.(picker).chosen, []string{"banana", "mango"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("chosen = %q, want %q", got, want)
+	}
+}
+
+func TestPickerCursorFollowsLine(t *testing.T) {
+	var m tea.Model = newPicker(newStream(strings.NewReader("")))
+	m = send(m, tea.WindowSizeMsg{Width: 40, Height: 10})
+	m = send(m, linesMsg{lines: []string{"zzzazzzpzzzp", "zazpzp"}})
+	m = send(m, keys("app")...)
+	m = send(m, tea.KeyMsg{Type: tea.KeyDown})
+	p := m.(picker)
+	want := p.lines[p.items[p.cursor].index]
+
+	// Better matches coming in go above it, and the cursor goes with it.
+	m = send(m, linesMsg{lines: []string{"app", "apple"}})
+	p = m.(picker)
+	if got := p.lines[p.items[p.cursor].index]; got != want || p.cursor != 3 {
+		t.Errorf("cursor on %q at %d, want %q at 3", got, p.cursor, want)
+	}
+}
+
+func TestPickerChoosesCurrentLine(t *testing.T) {
+	var m tea.Model = newPicker(newStream(strings.NewReader("")))
+	m = send(m, linesMsg{lines: []string{"one", "two", "three"}, eof: true})
+	m = send(m, tea.KeyMsg{Type: tea.KeyDown}, tea.KeyMsg{Type: tea.KeyEnter})
+	if got := m.(picker).chosen; !reflect.DeepEqual(got, []string{"two"}) {
+		t.Errorf("chosen = %q, want [two]", got)
+	}
+}
+
+func TestStream(t *testing.T) {
+	s := newStream(strings.NewReader("a\nb\nc"))
+	var lines []string
+	for {
+		msg := waitForLines(s)().(linesMsg)
+		lines = append(lines, msg.lines...)
+		if msg.eof {
+			break
+		}
+	}
+	if want := []string{"a", "b", "c"}; !reflect.DeepEqual(lines, want) {
+		t.Errorf("lines = %q, want %q", lines, want)
+	}
+}
@@ -3,8 +3,14 @@ package main
 // A program demonstrating how to use the WithFilter option to intercept events.
 
 import (
+	"flag"
 	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/key"
@@ -16,50 +22,139 @@ import (
 var (
 	choiceStyle   = lipgloss.NewStyle().PaddingLeft(1).Foreground(lipgloss.Color("241"))
 	saveTextStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("170"))
+	errTextStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("203"))
 	quitViewStyle = lipgloss.NewStyle().Padding(1).Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("170"))
+	buttonStyle   = lipgloss.NewStyle().Padding(0, 2).MarginRight(1).Background(lipgloss.Color("238")).Foreground(lipgloss.Color("252"))
+	activeStyle   = buttonStyle.Background(lipgloss.Color("170")).Foreground(lipgloss.Color("230"))
 )
 
 func main() {
-	p := tea.NewProgram(initialModel(), tea.WithFilter(filter))
+	path := flag.String("file", "notes.txt", "file to edit")
+	every := flag.Duration("autosave", 5*time.Second, "how often to autosave unsaved changes")
+	flag.Parse()
 
-	if _, err := p.Run(); err != nil {
+	m, err := initialModel(*path, *every)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// We handle signals ourselves, rather than letting Bubble Tea quit, so
+	// that we can save what's been typed first. SIGHUP is what we get when
+	// the terminal window is closed.
+	p := tea.NewProgram(m, tea.WithFilter(filter), tea.WithoutSignalHandler())
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	go func() {
+		for sig := range sigs {
+			p.Send(signalMsg{sig})
+		}
+	}()
+
+	final, err := p.Run()
+	signal.Stop(sigs)
+
+	// If we're going away without the user saving or throwing their changes
+	// away, say because the terminal is gone, keep them for next time.
+	if m, ok := final.(model); ok && m.hasChanges {
+		if err := m.autosave(); err != nil {
+			fmt.Fprintln(os.Stderr, "Couldn't save your changes:", err)
+		}
+	}
+	if err != nil {
 		log.Fatal(err)
 	}
 }
 
+// filter turns requests to quit into a question while there are unsaved
+// changes, wherever they come from.
 func filter(teaModel tea.Model, msg tea.Msg) tea.Msg {
 	if _, ok := msg.(tea.QuitMsg); !ok {
 		return msg
 	}
 
 	m := teaModel.(model)
-	if m.hasChanges {
-		return nil
+	if m.hasChanges && !m.exiting {
+		return confirmQuitMsg{}
 	}
 
 	return msg
 }
 
+type (
+	// confirmQuitMsg asks the user what to do with their changes before
+	// quitting.
+	confirmQuitMsg struct{}
+
+	// autosaveMsg says it's time to autosave.
+	autosaveMsg struct{}
+
+	// signalMsg is a signal asking us to go away.
+	signalMsg struct{ sig os.Signal }
+)
+
+type screen int
+
+const (
+	editing screen = iota
+	recovering
+	quitting
+)
+
+// The choices in the quit dialog, in order.
+const (
+	choiceSave = iota
+	choiceDiscard
+	choiceCancel
+)
+
+var choices = []string{"Save", "Discard", "Cancel"}
+
 type model struct {
 	textarea   textarea.Model
 	help       help.Model
 	keymap     keymap
 	saveText   string
+	err        error
 	hasChanges bool
-	quitting   bool
+	screen     screen
+	choice     int // the selected button in the quit dialog
+
+	path     string
+	saved    string // what's in the file
+	recovery recovery
+	every    time.Duration
+	// autosaved is what's in the recovery file.
+	autosaved string
+	// recovered is what we found in the recovery file at launch, and when
+	// it was saved.
+	recovered   string
+	recoveredAt time.Time
+	// exiting is set once we're quitting for good.
+	exiting bool
 }
 
 type keymap struct {
-	save key.Binding
-	quit key.Binding
+	save    key.Binding
+	quit    key.Binding
+	recover key.Binding
+	discard key.Binding
+	left    key.Binding
+	right   key.Binding
+	choose  key.Binding
 }
 
-func initialModel() model {
+func initialModel(path string, every time.Duration) (model, error) {
 	ti := textarea.New()
 	ti.Placeholder = "Only the best words"
 	ti.Focus()
 
-	return model{
+	saved, err := readFile(path)
+	if err != nil {
+		return model{}, err
+	}
+	ti.SetValue(saved)
+
+	m := model{
 		textarea: ti,
 		help:     help.New(),
 		keymap: keymap{
@@ -71,22 +166,144 @@ func initialModel() model {
 				key.WithKeys("esc", "ctrl+c"),
 				key.WithHelp("esc", "quit"),
 			),
+			recover: key.NewBinding(
+				key.WithKeys("r", "y", "enter"),
+				key.WithHelp("r", "recover"),
+			),
+			discard: key.NewBinding(
+				key.WithKeys("d", "n"),
+				key.WithHelp("d", "discard"),
+			),
+			left: key.NewBinding(
+				key.WithKeys("left", "h", "shift+tab"),
+				key.WithHelp("←/→", "choose"),
+			),
+			right: key.NewBinding(
+				key.WithKeys("right", "l", "tab"),
+			),
+			choose: key.NewBinding(
+				key.WithKeys("enter"),
+				key.WithHelp("enter", "confirm"),
+			),
 		},
+		path:     path,
+		saved:    saved,
+		recovery: recoveryFor(path),
+		every:    every,
 	}
+
+	text, at, ok, err := m.recovery.read()
+	if err != nil {
+		return model{}, err
+	}
+	switch {
+	case !ok:
+	case text == saved:
+		// Nothing was lost after all.
+		err = m.recovery.remove()
+	default:
+		m.recovered, m.recoveredAt, m.autosaved = text, at, text
+		m.screen = recovering
+	}
+	return m, err
 }
 
 func (m model) Init() tea.Cmd {
-	return textarea.Blink
+	return tea.Batch(textarea.Blink, m.scheduleAutosave())
+}
+
+func (m model) scheduleAutosave() tea.Cmd {
+	return tea.Tick(m.every, func(time.Time) tea.Msg {
+		return autosaveMsg{}
+	})
+}
+
+// autosave writes unsaved changes to the recovery file, or removes it if
+// the changes were undone. While we're asking whether to recover the file,
+// it's left alone until the user decides.
+func (m *model) autosave() error {
+	if m.screen == recovering {
+		return nil
+	}
+	text := m.textarea.Value()
+	if !m.hasChanges {
+		if m.autosaved == "" {
+			return nil
+		}
+		m.autosaved = ""
+		return m.recovery.remove()
+	}
+	if text == m.autosaved {
+		return nil
+	}
+	if err := m.recovery.write(text); err != nil {
+		return err
+	}
+	m.autosaved = text
+	return nil
+}
+
+// save writes the changes to the file, after which there's nothing to
+// recover.
+func (m *model) save() error {
+	text := m.textarea.Value()
+	if err := writeFile(m.path, text); err != nil {
+		return err
+	}
+	m.saved, m.hasChanges = text, false
+	m.autosaved = ""
+	return m.recovery.remove()
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	if m.quitting {
-		return m.updatePromptView(msg)
+	switch msg.(type) {
+	case autosaveMsg:
+		m.err = m.autosave()
+		return m, m.scheduleAutosave()
+
+	case signalMsg:
+		// Keep the changes for next time and go.
+		m.err = m.autosave()
+		m.exiting = true
+		return m, tea.Quit
+
+	case confirmQuitMsg:
+		m.screen, m.choice = quitting, choiceSave
+		return m, nil
 	}
 
+	switch m.screen {
+	case recovering:
+		return m.updateRecoverView(msg)
+	case quitting:
+		return m.updatePromptView(msg)
+	}
 	return m.updateTextView(msg)
 }
 
+func (m model) updateRecoverView(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	switch {
+	case key.Matches(keyMsg, m.keymap.quit):
+		// Leave the recovery file for next time.
+		return m, tea.Quit
+	case key.Matches(keyMsg, m.keymap.recover):
+		m.textarea.SetValue(m.recovered)
+		m.hasChanges = true
+		m.saveText = "Recovered your unsaved changes."
+	case key.Matches(keyMsg, m.keymap.discard):
+		m.err = m.recovery.remove()
+		m.autosaved = ""
+	default:
+		return m, nil
+	}
+	m.screen, m.recovered = editing, ""
+	return m, nil
+}
+
 func (m model) updateTextView(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 	var cmd tea.Cmd
@@ -96,15 +313,13 @@ func (m model) updateTextView(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.saveText = ""
 		switch {
 		case key.Matches(msg, m.keymap.save):
-			m.saveText = "Changes saved!"
-			m.hasChanges = false
+			if m.err = m.save(); m.err == nil {
+				m.saveText = "Changes saved!"
+			}
+			return m, nil
 		case key.Matches(msg, m.keymap.quit):
-			m.quitting = true
+			// The filter asks first if there are unsaved changes.
 			return m, tea.Quit
-		case msg.Type == tea.KeyRunes:
-			m.saveText = ""
-			m.hasChanges = true
-			fallthrough
 		default:
 			if !m.textarea.Focused() {
 				cmd = m.textarea.Focus()
@@ -113,42 +328,108 @@ func (m model) updateTextView(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 	}
 	m.textarea, cmd = m.textarea.Update(msg)
+	m.hasChanges = m.textarea.Value() != m.saved
 	cmds = append(cmds, cmd)
 	return m, tea.Batch(cmds...)
 }
 
 func (m model) updatePromptView(msg tea.Msg) (tea.Model, tea.Cmd) {
-	switch msg := msg.(type) {
-	case tea.KeyMsg:
-		// For simplicity's sake, we'll treat any key besides "y" as "no"
-		if key.Matches(msg, m.keymap.quit) || msg.String() == "y" {
-			m.hasChanges = false
-			return m, tea.Quit
-		}
-		m.quitting = false
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	choice := -1
+	switch {
+	case key.Matches(keyMsg, m.keymap.left):
+		m.choice = (m.choice + len(choices) - 1) % len(choices)
+	case key.Matches(keyMsg, m.keymap.right):
+		m.choice = (m.choice + 1) % len(choices)
+	case key.Matches(keyMsg, m.keymap.choose):
+		choice = m.choice
+	case keyMsg.String() == "s":
+		choice = choiceSave
+	case keyMsg.String() == "d":
+		choice = choiceDiscard
+	case keyMsg.String() == "c", key.Matches(keyMsg, m.keymap.quit):
+		choice = choiceCancel
 	}
 
+	switch choice {
+	case choiceSave:
+		if m.err = m.save(); m.err != nil {
+			// Stay put so they can decide what to do instead.
+			return m, nil
+		}
+		m.exiting = true
+		return m, tea.Quit
+	case choiceDiscard:
+		m.err = m.recovery.remove()
+		m.hasChanges, m.exiting = false, true
+		return m, tea.Quit
+	case choiceCancel:
+		m.screen = editing
+	}
 	return m, nil
 }
 
 func (m model) View() string {
-	if m.quitting {
+	if m.exiting {
+		if m.hasChanges && m.err != nil {
+			return "Couldn't save your changes: " + m.err.Error() + "\n"
+		}
 		if m.hasChanges {
-			text := lipgloss.JoinHorizontal(lipgloss.Top, "You have unsaved changes. Quit without saving?", choiceStyle.Render("[yn]"))
-			return quitViewStyle.Render(text)
+			return "Your changes are saved for next time.\n"
 		}
 		return "Very important, thank you\n"
 	}
 
+	var errText string
+	if m.err != nil {
+		errText = "\n " + errTextStyle.Render(m.err.Error())
+	}
+
+	switch m.screen {
+	case recovering:
+		lines := fmt.Sprintf("%d lines", strings.Count(m.recovered, "\n")+1)
+		if lines == "1 lines" {
+			lines = "1 line"
+		}
+		text := fmt.Sprintf(
+			"Found unsaved changes to %s from %s (%s).\nRecover them?",
+			m.path, m.recoveredAt.Format("Jan 2 15:04"), lines,
+		)
+		text = lipgloss.JoinHorizontal(lipgloss.Top, text, choiceStyle.Render("[rd]"))
+		return quitViewStyle.Render(text) + errText + "\n " +
+			m.help.ShortHelpView([]key.Binding{m.keymap.recover, m.keymap.discard}) + "\n"
+
+	case quitting:
+		buttons := make([]string, len(choices))
+		for i, c := range choices {
+			style := buttonStyle
+			if i == m.choice {
+				style = activeStyle
+			}
+			buttons[i] = style.Render(c)
+		}
+		text := lipgloss.JoinVertical(lipgloss.Left,
+			"You have unsaved changes. Save them before quitting?",
+			"",
+			lipgloss.JoinHorizontal(lipgloss.Top, buttons...),
+		)
+		return quitViewStyle.Render(text) + errText + "\n " +
+			m.help.ShortHelpView([]key.Binding{m.keymap.left, m.keymap.choose}) + "\n"
+	}
+
 	helpView := m.help.ShortHelpView([]key.Binding{
 		m.keymap.save,
 		m.keymap.quit,
 	})
 
 	return fmt.Sprintf(
-		"\nType some important things.\n\n%s\n\n %s\n %s",
+		"\nType some important things.\n\n%s\n\n %s%s\n %s",
 		m.textarea.View(),
 		saveTextStyle.Render(m.saveText),
+		errText,
 		helpView,
 	) + "\n\n"
 }
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func keyPress(s string) tea.KeyMsg {
+	switch s {
+	case "enter":
+		return tea.KeyMsg{Type: tea.KeyEnter}
+	case "esc":
+		return tea.KeyMsg{Type: tea.KeyEsc}
+	case "right":
+		return tea.KeyMsg{Type: tea.KeyRight}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+// run sends msgs through the filter and the model, like the program would,
+// and reports whether the program quit. Of the commands the model returns,
+// only tea.Quit is run; the rest would wait on timers.
+func run(t *testing.T, m *model, msgs ...tea.Msg) (quit bool) {
+	t.Helper()
+	for len(msgs) > 0 {
+		msg := filter(*m, msgs[0])
+		msgs = msgs[1:]
+		if _, ok := msg.(tea.QuitMsg); ok {
+			return true
+		}
+		next, cmd := m.Update(msg)
+		*m = next.(model)
+		if cmd != nil && reflect.ValueOf(cmd).Pointer() == reflect.ValueOf(tea.Quit).Pointer() {
+			msgs = append([]tea.Msg{tea.QuitMsg{}}, msgs...)
+		}
+	}
+	return false
+}
+
+func newTestModel(t *testing.T, path string) model {
+	t.Helper()
+	m, err := initialModel(path, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func TestSaveBeforeQuitting(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	rec := recoveryFor(path).path
+	m := newTestModel(t, path)
+
+	run(t, &m, keyPress("hi"), autosaveMsg{})
+	if b, err := os.ReadFile(rec); err != nil || string(b) != "hi" {
+		t.Fatalf("recovery file = %q, %v", b, err)
+	}
+
+	// Quitting asks first, and cancelling goes back to editing.
+	if run(t, &m, keyPress("esc")) || m.screen != quitting {
+		t.Fatal("quit without asking")
+	}
+	if run(t, &m, keyPress("c")) || m.screen != editing {
+		t.Fatal("cancel didn't go back to editing")
+	}
+
+	// Saving writes the file and throws the recovery file away.
+	run(t, &m, keyPress("esc"))
+	if !run(t, &m, keyPress("enter")) {
+		t.Fatal("didn't quit after saving")
+	}
+	if b, err := os.ReadFile(path); err != nil || string(b) != "hi" {
+		t.Errorf("file = %q, %v", b, err)
+	}
+	if exists(rec) {
+		t.Error("recovery file left behind after saving")
+	}
+}
+
+func TestSaveKeepsMode(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(path, []byte("hi"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(path, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	m := newTestModel(t, path)
+	run(t, &m, keyPress("!"), keyPress("esc"), keyPress("enter"))
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0o644 {
+		t.Errorf("saving changed the mode to %v", fi.Mode().Perm())
+	}
+}
+
+func TestSignalWhenAutosaveFails(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	m := newTestModel(t, path)
+
+	// Something in the way of the recovery file's temporary file.
+	if err := os.Mkdir(recoveryFor(path).path+".tmp", 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if !run(t, &m, keyPress("hi"), signalMsg{syscall.SIGTERM}) {
+		t.Fatal("didn't quit on SIGTERM")
+	}
+	if v := m.View(); !strings.Contains(v, "Couldn't save your changes") || strings.Contains(v, "saved for next time") {
+		t.Errorf("view after a failed autosave = %q", v)
+	}
+}
+
+func TestDiscard(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	m := newTestModel(t, path)
+
+	run(t, &m, keyPress("hi"), autosaveMsg{}, keyPress("esc"), keyPress("right"))
+	if m.choice != choiceDiscard {
+		t.Fatalf("choice = %d, want discard", m.choice)
+	}
+	if !run(t, &m, keyPress("enter")) {
+		t.Fatal("didn't quit after discarding")
+	}
+	if exists(path) || exists(recoveryFor(path).path) {
+		t.Error("discarding left files behind")
+	}
+}
+
+func TestRecover(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(path, []byte("saved"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	m := newTestModel(t, path)
+
+	// Terminal goes away with unsaved changes.
+	if !run(t, &m, keyPress("!"), signalMsg{syscall.SIGHUP}) {
+		t.Fatal("didn't quit on SIGHUP")
+	}
+
+	m = newTestModel(t, path)
+	if m.screen != recovering {
+		t.Fatal("didn't offer to recover")
+	}
+	run(t, &m, keyPress("r"))
+	if got := m.textarea.Value(); got != "saved!" || !m.hasChanges {
+		t.Errorf("recovered %q, changes: %v", got, m.hasChanges)
+	}
+
+	// Undoing the changes throws the recovery file away.
+	run(t, &m, tea.KeyMsg{Type: tea.KeyBackspace}, autosaveMsg{})
+	if exists(recoveryFor(path).path) {
+		t.Error("recovery file kept after the changes were undone")
+	}
+	if !run(t, &m, keyPress("esc")) {
+		t.Error("didn't quit without changes")
+	}
+}
+
+func TestAutosaveWhileAskingToRecover(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	rec := recoveryFor(path).path
+	if err := recoveryFor(path).write("unsaved"); err != nil {
+		t.Fatal(err)
+	}
+	m := newTestModel(t, path)
+	if m.screen != recovering {
+		t.Fatal("didn't offer to recover")
+	}
+
+	// Neither an autosave nor the terminal going away throws it out before
+	// the user has decided.
+	run(t, &m, autosaveMsg{})
+	if !exists(rec) {
+		t.Fatal("autosave removed the recovery file")
+	}
+	if !run(t, &m, signalMsg{syscall.SIGTERM}) || !exists(rec) {
+		t.Fatal("SIGTERM removed the recovery file")
+	}
+
+	m = newTestModel(t, path)
+	if !run(t, &m, autosaveMsg{}, keyPress("esc")) {
+		t.Fatal("esc didn't quit")
+	}
+	if b, err := os.ReadFile(rec); err != nil || string(b) != "unsaved" {
+		t.Errorf("recovery file = %q, %v", b, err)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// recovery is where unsaved changes to a file are autosaved, so they
+// survive the terminal going away.
+type recovery struct {
+	path string
+}
+
+func recoveryFor(path string) recovery {
+	dir, base := filepath.Split(path)
+	return recovery{filepath.Join(dir, "."+base+".recovery")}
+}
+
+// read returns the recovered text and when it was saved. ok is false when
+// there's nothing to recover.
+func (r recovery) read() (text string, saved time.Time, ok bool, err error) {
+	fi, err := os.Stat(r.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", time.Time{}, false, nil
+	}
+	if err != nil {
+		return "", time.Time{}, false, err
+	}
+	b, err := os.ReadFile(r.path)
+	if err != nil {
+		return "", time.Time{}, false, err
+	}
+	return string(b), fi.ModTime(), true, nil
+}
+
+func (r recovery) write(text string) error {
+	return writeFile(r.path, text)
+}
+
+// remove throws the recovery file away, once the changes are saved or
+// discarded.
+func (r recovery) remove() error {
+	if err := os.Remove(r.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// readFile reads the file being edited, which doesn't have to exist yet.
+func readFile(path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	return string(b), err
+}
+
+// writeFile writes to a temporary file first, so a crash halfway through
+// doesn't leave half a file behind. A file that's already there keeps its
+// permissions.
+func writeFile(path, text string) error {
+	mode := os.FileMode(0o600)
+	if fi, err := os.Stat(path); err == nil {
+		mode = fi.Mode().Perm()
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(text), mode); err != nil {
+		return err
+	}
+	// The umask may have taken some away.
+	if err := os.Chmod(tmp, mode); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,308 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// download is a single file to fetch.
+type download struct {
+	url    string
+	dest   string
+	sha256 string // optional, hex encoded
+}
+
+func newDownload(rawURL, sum string) (download, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return download{}, err
+	}
+	name := path.Base(u.Path)
+	if name == "/" || name == "." {
+		name = u.Host
+	}
+	return download{url: rawURL, dest: name, sha256: strings.ToLower(sum)}, nil
+}
+
+// readList reads downloads from a file with one URL per line, optionally
+// followed by the file's SHA-256. Blank lines and lines starting with # are
+// ignored.
+func readList(name string) ([]download, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() // nolint:errcheck
+
+	var dls []download
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		var sum string
+		if len(fields) > 1 {
+			sum = fields[1]
+		}
+		dl, err := newDownload(fields[0], sum)
+		if err != nil {
+			return nil, err
+		}
+		dls = append(dls, dl)
+	}
+	return dls, scanner.Err()
+}
+
+// checkDests makes sure no two downloads would be saved to the same file,
+// where they'd write over each other's .part files.
+func checkDests(dls []download) error {
+	seen := make(map[string]string, len(dls))
+	for _, dl := range dls {
+		if other, ok := seen[dl.dest]; ok {
+			return fmt.Errorf("%s and %s would both be saved as %s", other, dl.url, dl.dest)
+		}
+		seen[dl.dest] = dl.url
+	}
+	return nil
+}
+
+type eventKind int
+
+const (
+	eventProgress eventKind = iota
+	eventRetry
+	eventVerifying
+	eventDone
+	eventFailed
+)
+
+// event reports on the state of the download at index.
+type event struct {
+	index   int
+	kind    eventKind
+	written int64
+	total   int64 // -1 when the server didn't tell us
+	attempt int
+	err     error
+}
+
+// downloader fetches files over HTTP, resuming from partial .part files and
+// retrying with exponential backoff.
+type downloader struct {
+	client  *http.Client
+	retries int
+	backoff time.Duration
+	onEvent func(event)
+}
+
+// permanentError is an error that retrying won't fix.
+type permanentError struct{ error }
+
+func (d *downloader) emit(ev event) {
+	if d.onEvent != nil {
+		d.onEvent(ev)
+	}
+}
+
+// run fetches all downloads, at most concurrency at a time, and returns the
+// errors in the same order as the downloads.
+func (d *downloader) run(ctx context.Context, dls []download, concurrency int) []error {
+	errs := make([]error, len(dls))
+	sem := make(chan struct{}, max(concurrency, 1))
+	var wg sync.WaitGroup
+	for i, dl := range dls {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				errs[i] = ctx.Err()
+				d.emit(event{index: i, kind: eventFailed, err: errs[i]})
+				return
+			}
+			defer func() { <-sem }()
+
+			errs[i] = d.fetch(ctx, i, dl)
+			if errs[i] != nil {
+				d.emit(event{index: i, kind: eventFailed, err: errs[i]})
+				return
+			}
+			d.emit(event{index: i, kind: eventDone})
+		}()
+	}
+	wg.Wait()
+	return errs
+}
+
+// fetch downloads a single file, retrying on failure.
+func (d *downloader) fetch(ctx context.Context, i int, dl download) error {
+	var err error
+	for attempt := 0; attempt <= d.retries; attempt++ {
+		if attempt > 0 {
+			d.emit(event{index: i, kind: eventRetry, attempt: attempt, err: err})
+			select {
+			case <-time.After(d.backoff << (attempt - 1)):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		err = d.attempt(ctx, i, dl)
+		var perm permanentError
+		switch {
+		case err == nil:
+			return d.finish(i, dl)
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case errors.As(err, &perm):
+			return perm.error
+		}
+	}
+	return err
+}
+
+// attempt makes one request for the file, picking up where the .part file
+// left off.
+func (d *downloader) attempt(ctx context.Context, i int, dl download) error {
+	part := dl.dest + ".part"
+	f, err := os.OpenFile(part, os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return permanentError{err}
+	}
+	defer f.Close() // nolint:errcheck
+
+	offset, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return permanentError{err}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, dl.url, nil)
+	if err != nil {
+		return permanentError{err}
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() // nolint:errcheck
+
+	total := resp.ContentLength
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		if total >= 0 {
+			total += offset
+		}
+		if t := rangeTotal(resp.Header.Get("Content-Range")); t >= 0 {
+			total = t
+		}
+	case http.StatusOK:
+		// The server ignored our range, so start over.
+		if err := f.Truncate(0); err != nil {
+			return permanentError{err}
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return permanentError{err}
+		}
+		offset = 0
+	case http.StatusRequestedRangeNotSatisfiable:
+		// Most likely the .part file is already complete.
+		if t := rangeTotal(resp.Header.Get("Content-Range")); t == offset {
+			d.emit(event{index: i, kind: eventProgress, written: offset, total: offset})
+			return nil
+		}
+		if err := f.Truncate(0); err != nil {
+			return permanentError{err}
+		}
+		return fmt.Errorf("range not satisfiable, restarting")
+	default:
+		err := fmt.Errorf("receiving status of %d for url: %s", resp.StatusCode, dl.url)
+		if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+			return permanentError{err}
+		}
+		return err
+	}
+
+	pw := &progressWriter{
+		downloaded: offset,
+		total:      total,
+		onProgress: func(written, total int64) {
+			d.emit(event{index: i, kind: eventProgress, written: written, total: total})
+		},
+	}
+	pw.onProgress(offset, total)
+
+	// TeeReader calls pw.Write() each time a new response is received
+	if _, err := io.Copy(f, io.TeeReader(resp.Body, pw)); err != nil {
+		return err
+	}
+	if total >= 0 && pw.downloaded != total {
+		return fmt.Errorf("short download: got %d of %d bytes", pw.downloaded, total)
+	}
+	return nil
+}
+
+// finish verifies the checksum, if we have one, and moves the .part file
+// into place.
+func (d *downloader) finish(i int, dl download) error {
+	part := dl.dest + ".part"
+	if dl.sha256 != "" {
+		d.emit(event{index: i, kind: eventVerifying})
+		sum, err := fileSHA256(part)
+		if err != nil {
+			return err
+		}
+		if sum != dl.sha256 {
+			// The data is bad, don't resume from it next time.
+			_ = os.Remove(part)
+			return fmt.Errorf("checksum mismatch: expected %s, got %s", dl.sha256, sum)
+		}
+	}
+	return os.Rename(part, dl.dest)
+}
+
+func fileSHA256(name string) (string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close() // nolint:errcheck
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// rangeTotal returns the complete length from a Content-Range header such
+// as "bytes 200-999/1000", or -1 if it's unknown.
+func rangeTotal(header string) int64 {
+	i := strings.LastIndexByte(header, '/')
+	if i < 0 {
+		return -1
+	}
+	total, err := strconv.ParseInt(header[i+1:], 10, 64)
+	if err != nil {
+		return -1
+	}
+	return total
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var content = bytes.Repeat([]byte("bubble tea "), 10_000)
+
+func contentSum() string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+func testDownloader() *downloader {
+	return &downloader{
+		client:  http.DefaultClient,
+		retries: 2,
+		backoff: time.Millisecond,
+	}
+}
+
+func testDownload(t *testing.T, url, sum string) download {
+	t.Helper()
+	dl, err := newDownload(url, sum)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dl.dest = filepath.Join(t.TempDir(), dl.dest)
+	return dl
+}
+
+func requireContent(t *testing.T, name string) {
+	t.Helper()
+	got, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Fatalf("expected %d bytes of content, got %d", len(content), len(got))
+	}
+	if _, err := os.Stat(name + ".part"); !os.IsNotExist(err) {
+		t.Fatalf("expected .part file to be gone, got %v", err)
+	}
+}
+
+func serveContent(w http.ResponseWriter, r *http.Request) {
+	http.ServeContent(w, r, "file.txt", time.Time{}, bytes.NewReader(content))
+}
+
+func TestDownload(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(serveContent))
+	defer srv.Close()
+
+	dls := []download{
+		testDownload(t, srv.URL+"/a.txt", contentSum()),
+		testDownload(t, srv.URL+"/b.txt", ""),
+	}
+	for i, err := range testDownloader().run(context.Background(), dls, 2) {
+		if err != nil {
+			t.Fatalf("download %d: %v", i, err)
+		}
+		requireContent(t, dls[i].dest)
+	}
+}
+
+func TestDownloadResume(t *testing.T) {
+	var gotRange string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotRange = r.Header.Get("Range")
+		serveContent(w, r)
+	}))
+	defer srv.Close()
+
+	dl := testDownload(t, srv.URL+"/file.txt", contentSum())
+	if err := os.WriteFile(dl.dest+".part", content[:1000], 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var first event
+	d := testDownloader()
+	d.onEvent = func(ev event) {
+		if ev.kind == eventProgress && first.total == 0 {
+			first = ev
+		}
+	}
+	if err := d.run(context.Background(), []download{dl}, 1)[0]; err != nil {
+		t.Fatal(err)
+	}
+	if gotRange != "bytes=1000-" {
+		t.Errorf("expected a range request, got %q", gotRange)
+	}
+	if first.written != 1000 || first.total != int64(len(content)) {
+		t.Errorf("expected to start at 1000/%d, got %d/%d", len(content), first.written, first.total)
+	}
+	requireContent(t, dl.dest)
+}
+
+func TestDownloadRetry(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		serveContent(w, r)
+	}))
+	defer srv.Close()
+
+	dl := testDownload(t, srv.URL+"/file.txt", "")
+	if err := testDownloader().run(context.Background(), []download{dl}, 1)[0]; err != nil {
+		t.Fatal(err)
+	}
+	if n := calls.Load(); n != 3 {
+		t.Errorf("expected 3 requests, got %d", n)
+	}
+	requireContent(t, dl.dest)
+}
+
+func TestDownloadNotFound(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		http.NotFound(w, r)
+	}))
+	defer srv.Close()
+
+	dl := testDownload(t, srv.URL+"/missing.txt", "")
+	err := testDownloader().run(context.Background(), []download{dl}, 1)[0]
+	if err == nil || !strings.Contains(err.Error(), "404") {
+		t.Fatalf("expected a 404 error, got %v", err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("expected client errors not to be retried, got %d requests", n)
+	}
+}
+
+func TestDownloadChecksumMismatch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(serveContent))
+	defer srv.Close()
+
+	dl := testDownload(t, srv.URL+"/file.txt", strings.Repeat("0", 64))
+	err := testDownloader().run(context.Background(), []download{dl}, 1)[0]
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("expected a checksum error, got %v", err)
+	}
+	if _, err := os.Stat(dl.dest); !os.IsNotExist(err) {
+		t.Errorf("expected no file to be written, got %v", err)
+	}
+}
+
+func TestDownloadUnknownLength(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		// Flushing before we're done forces a chunked response with no
+		// Content-Length.
+		_, _ = w.Write(content[:10])
+		w.(http.Flusher).Flush()
+		_, _ = w.Write(content[10:])
+	}))
+	defer srv.Close()
+
+	var total atomic.Int64
+	d := testDownloader()
+	d.onEvent = func(ev event) {
+		if ev.kind == eventProgress {
+			total.Store(ev.total)
+		}
+	}
+	dl := testDownload(t, srv.URL+"/file.txt", "")
+	if err := d.run(context.Background(), []download{dl}, 1)[0]; err != nil {
+		t.Fatal(err)
+	}
+	if n := total.Load(); n != -1 {
+		t.Errorf("expected an unknown total, got %d", n)
+	}
+	requireContent(t, dl.dest)
+}
+
+func TestCheckDests(t *testing.T) {
+	var dls []download
+	for _, u := range []string{
+		"https://example.com/v1/app.tar.gz",
+		"https://example.com/v1/app.zip",
+		"https://example.com/v2/app.tar.gz",
+	} {
+		dl, err := newDownload(u, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		dls = append(dls, dl)
+	}
+	if err := checkDests(dls[:2]); err != nil {
+		t.Errorf("different names: %v", err)
+	}
+	err := checkDests(dls)
+	if err == nil || !strings.Contains(err.Error(), "saved as app.tar.gz") {
+		t.Errorf("same names: %v", err)
+	}
+}
@@ -1,107 +1,113 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
-	"io"
-	"log"
 	"net/http"
 	"os"
-	"path/filepath"
+	"time"
 
-	"github.com/charmbracelet/bubbles/progress"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
 var p *tea.Program
 
-type progressWriter struct {
-	total      int
-	downloaded int
-	file       *os.File
-	reader     io.Reader
-	onProgress func(float64)
-}
+// progressInterval limits how often we report progress for a single file.
+const progressInterval = 50 * time.Millisecond
 
-func (pw *progressWriter) Start() {
-	// TeeReader calls pw.Write() each time a new response is received
-	_, err := io.Copy(pw.file, io.TeeReader(pw.reader, pw))
-	if err != nil {
-		p.Send(progressErrMsg{err})
-	}
+type progressWriter struct {
+	total      int64
+	downloaded int64
+	last       time.Time
+	onProgress func(written, total int64)
 }
 
 func (pw *progressWriter) Write(p []byte) (int, error) {
-	pw.downloaded += len(p)
-	if pw.total > 0 && pw.onProgress != nil {
-		pw.onProgress(float64(pw.downloaded) / float64(pw.total))
+	pw.downloaded += int64(len(p))
+	if pw.onProgress != nil && (time.Since(pw.last) >= progressInterval || pw.downloaded == pw.total) {
+		pw.last = time.Now()
+		pw.onProgress(pw.downloaded, pw.total)
 	}
 	return len(p), nil
 }
 
-func getResponse(url string) (*http.Response, error) {
-	resp, err := http.Get(url) // nolint:gosec
-	if err != nil {
-		log.Fatal(err)
-	}
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("receiving status of %d for url: %s", resp.StatusCode, url)
-	}
-	return resp, nil
-}
-
 func main() {
-	url := flag.String("url", "", "url for the file to download")
+	var (
+		url         = flag.String("url", "", "url for the file to download")
+		list        = flag.String("list", "", "file with one url per line, optionally followed by its SHA-256")
+		sum         = flag.String("sha256", "", "expected SHA-256 of the file given with -url")
+		concurrency = flag.Int("c", 3, "number of files to download at once")
+		retries     = flag.Int("retries", 3, "number of times to retry a failed download")
+	)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [url...]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
 	flag.Parse()
 
-	if *url == "" {
-		flag.Usage()
-		os.Exit(1)
+	var dls []download
+	if *url != "" {
+		dl, err := newDownload(*url, *sum)
+		if err != nil {
+			fmt.Println("invalid url:", err)
+			os.Exit(1)
+		}
+		dls = append(dls, dl)
 	}
-
-	resp, err := getResponse(*url)
-	if err != nil {
-		fmt.Println("could not get response", err)
-		os.Exit(1)
+	for _, arg := range flag.Args() {
+		dl, err := newDownload(arg, "")
+		if err != nil {
+			fmt.Println("invalid url:", err)
+			os.Exit(1)
+		}
+		dls = append(dls, dl)
+	}
+	if *list != "" {
+		fromList, err := readList(*list)
+		if err != nil {
+			fmt.Println("could not read list:", err)
+			os.Exit(1)
+		}
+		dls = append(dls, fromList...)
 	}
-	defer resp.Body.Close() // nolint:errcheck
 
-	// Don't add TUI if the header doesn't include content size
-	// it's impossible see progress without total
-	if resp.ContentLength <= 0 {
-		fmt.Println("can't parse content length, aborting download")
+	if len(dls) == 0 {
+		flag.Usage()
 		os.Exit(1)
 	}
-
-	filename := filepath.Base(*url)
-	file, err := os.Create(filename)
-	if err != nil {
-		fmt.Println("could not create file:", err)
+	if err := checkDests(dls); err != nil {
+		fmt.Println(err)
 		os.Exit(1)
 	}
-	defer file.Close() // nolint:errcheck
 
-	pw := &progressWriter{
-		total:  int(resp.ContentLength),
-		file:   file,
-		reader: resp.Body,
-		onProgress: func(ratio float64) {
-			p.Send(progressMsg(ratio))
+	d := &downloader{
+		client:  http.DefaultClient,
+		retries: *retries,
+		backoff: time.Second,
+		onEvent: func(ev event) {
+			p.Send(ev)
 		},
 	}
 
-	m := model{
-		pw:       pw,
-		progress: progress.New(progress.WithDefaultGradient()),
-	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
 	// Start Bubble Tea
-	p = tea.NewProgram(m)
+	p = tea.NewProgram(newModel(dls, cancel))
 
-	// Start the download
-	go pw.Start()
+	// Start the downloads
+	go func() {
+		d.run(ctx, dls, *concurrency)
+		p.Send(allDoneMsg{})
+	}()
 
-	if _, err := p.Run(); err != nil {
+	m, err := p.Run()
+	if err != nil {
 		fmt.Println("error running program:", err)
 		os.Exit(1)
 	}
+	if m.(model).failed() {
+		os.Exit(1)
+	}
 }
@@ -1,24 +1,33 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"strings"
 	"time"
 
 	"github.com/charmbracelet/bubbles/progress"
+	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
 
-var helpStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#626262")).Render
+var (
+	helpStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#626262")).Render
+	nameStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("211"))
+	errStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+	checkMark = lipgloss.NewStyle().Foreground(lipgloss.Color("42")).SetString("✓")
+	crossMark = lipgloss.NewStyle().Foreground(lipgloss.Color("196")).SetString("✗")
+)
 
 const (
-	padding  = 2
-	maxWidth = 80
+	padding    = 2
+	maxWidth   = 80
+	nameWidth  = 24
+	statusSize = 22
 )
 
-type progressMsg float64
-
-type progressErrMsg struct{ err error }
+type allDoneMsg struct{}
 
 func finalPause() tea.Cmd {
 	return tea.Tick(time.Millisecond*750, func(_ time.Time) tea.Msg {
@@ -26,19 +35,80 @@ func finalPause() tea.Cmd {
 	})
 }
 
+// file is the state of a single download.
+type file struct {
+	name     string
+	written  int64
+	total    int64
+	attempt  int
+	verified bool
+	done     bool
+	err      error
+}
+
+func (f file) percent() float64 {
+	switch {
+	case f.done && f.err == nil:
+		return 1
+	case f.total > 0:
+		return float64(f.written) / float64(f.total)
+	default:
+		return 0
+	}
+}
+
 type model struct {
-	pw       *progressWriter
+	files    []file
+	cancel   context.CancelFunc
+	bar      progress.Model
 	progress progress.Model
-	err      error
+	spinner  spinner.Model
+	finished bool
+}
+
+func newModel(dls []download, cancel context.CancelFunc) model {
+	files := make([]file, len(dls))
+	for i, dl := range dls {
+		files[i] = file{name: dl.dest, total: -1}
+	}
+	bar := progress.New(progress.WithDefaultGradient(), progress.WithoutPercentage())
+	bar.Width = 30
+	return model{
+		files:    files,
+		cancel:   cancel,
+		bar:      bar,
+		progress: progress.New(progress.WithDefaultGradient()),
+		spinner:  spinner.New(spinner.WithSpinner(spinner.MiniDot)),
+	}
 }
 
 func (m model) Init() tea.Cmd {
-	return nil
+	return m.spinner.Tick
+}
+
+// aggregate is the overall progress. Files of unknown size count as zero
+// until they're finished.
+func (m model) aggregate() float64 {
+	var sum float64
+	for _, f := range m.files {
+		sum += f.percent()
+	}
+	return sum / float64(len(m.files))
+}
+
+func (m model) failed() bool {
+	for _, f := range m.files {
+		if f.err != nil || !f.done {
+			return true
+		}
+	}
+	return false
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		m.cancel()
 		return m, tea.Quit
 
 	case tea.WindowSizeMsg:
@@ -46,21 +116,36 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if m.progress.Width > maxWidth {
 			m.progress.Width = maxWidth
 		}
+		m.bar.Width = max(10, min(m.progress.Width, maxWidth)-nameWidth-statusSize-2)
 		return m, nil
 
-	case progressErrMsg:
-		m.err = msg.err
-		return m, tea.Quit
-
-	case progressMsg:
-		var cmds []tea.Cmd
-
-		if msg >= 1.0 {
-			cmds = append(cmds, tea.Sequence(finalPause(), tea.Quit))
+	case event:
+		f := &m.files[msg.index]
+		switch msg.kind {
+		case eventProgress:
+			f.written, f.total = msg.written, msg.total
+		case eventRetry:
+			f.attempt = msg.attempt
+			f.err = msg.err
+		case eventVerifying:
+			f.verified = true
+		case eventDone:
+			f.done = true
+			f.err = nil
+		case eventFailed:
+			f.done = true
+			f.err = msg.err
 		}
+		return m, m.progress.SetPercent(m.aggregate())
 
-		cmds = append(cmds, m.progress.SetPercent(float64(msg)))
-		return m, tea.Batch(cmds...)
+	case allDoneMsg:
+		m.finished = true
+		return m, tea.Sequence(finalPause(), tea.Quit)
+
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
 
 	// FrameMsg is sent when the progress bar wants to animate itself
 	case progress.FrameMsg:
@@ -73,13 +158,68 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	}
 }
 
-func (m model) View() string {
-	if m.err != nil {
-		return "Error downloading: " + m.err.Error() + "\n"
+func (m model) fileView(f file) string {
+	name := nameStyle.Render(truncate(f.name, nameWidth))
+	name += strings.Repeat(" ", max(0, nameWidth-lipgloss.Width(name)))
+
+	var bar, status string
+	switch {
+	case f.done && f.err != nil:
+		bar = crossMark.String()
+		status = errStyle.Render(f.err.Error())
+		return name + " " + bar + " " + status
+	case f.done:
+		bar = m.bar.ViewAs(1)
+		status = checkMark.String() + " " + formatBytes(f.written)
+		if f.verified {
+			status += " sha256 ok"
+		}
+	case f.total < 0:
+		// We don't know how big the file is, so just show that it's moving.
+		bar = m.spinner.View() + strings.Repeat(" ", max(0, m.bar.Width-1))
+		status = formatBytes(f.written)
+	default:
+		bar = m.bar.ViewAs(f.percent())
+		status = fmt.Sprintf("%s / %s", formatBytes(f.written), formatBytes(f.total))
+	}
+	if f.attempt > 0 && !f.done {
+		status += fmt.Sprintf(" (retry %d)", f.attempt)
 	}
+	return name + " " + bar + " " + status
+}
 
+func (m model) View() string {
 	pad := strings.Repeat(" ", padding)
-	return "\n" +
-		pad + m.progress.View() + "\n\n" +
-		pad + helpStyle("Press any key to quit")
+
+	var b strings.Builder
+	b.WriteString("\n")
+	for _, f := range m.files {
+		b.WriteString(pad + m.fileView(f) + "\n")
+	}
+	b.WriteString("\n" + pad + m.progress.View() + "\n\n")
+	if !m.finished {
+		b.WriteString(pad + helpStyle("Press any key to quit"))
+	}
+	return b.String()
+}
+
+func truncate(s string, w int) string {
+	r := []rune(s)
+	if len(r) <= w {
+		return s
+	}
+	return string(r[:w-1]) + "…"
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
 }
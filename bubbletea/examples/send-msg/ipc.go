@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// envelope is what goes over the socket, one per line:
+//
+//	{"type": "status", "data": {"text": "building", "level": "info"}}
+//
+// The type picks a decoder from the registry, which turns data into a
+// tea.Msg.
+type envelope struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+// reply is sent back for every envelope so that senders know whether it
+// made it.
+type reply struct {
+	OK    bool   `json:"ok,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+type decoder func(data json.RawMessage) (tea.Msg, error)
+
+var registry = map[string]decoder{}
+
+// register adds a decoder for a message type.
+func register(name string, d decoder) {
+	if _, ok := registry[name]; ok {
+		panic("send-msg: message type registered twice: " + name)
+	}
+	registry[name] = d
+}
+
+// registerJSON registers a message type that decodes straight from JSON.
+// Unknown fields are an error, so typos don't go unnoticed.
+func registerJSON[T tea.Msg](name string) {
+	register(name, func(data json.RawMessage) (tea.Msg, error) {
+		var v T
+		if len(data) == 0 {
+			return v, nil
+		}
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&v); err != nil {
+			return nil, err
+		}
+		return v, nil
+	})
+}
+
+// messageTypes returns the registered type names, for help and errors.
+func messageTypes() []string {
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func decode(env envelope) (tea.Msg, error) {
+	d, ok := registry[env.Type]
+	if !ok {
+		return nil, fmt.Errorf("unknown message type %q, want one of: %s",
+			env.Type, strings.Join(messageTypes(), ", "))
+	}
+	msg, err := d(env.Data)
+	if err != nil {
+		return nil, fmt.Errorf("bad %s message: %w", env.Type, err)
+	}
+	return msg, nil
+}
+
+// listen opens the socket. A socket file left behind by a program that
+// didn't shut down cleanly is removed, but not one that's still in use.
+func listen(path string) (net.Listener, error) {
+	if c, err := net.Dial("unix", path); err == nil {
+		_ = c.Close()
+		return nil, fmt.Errorf("%s is already in use", path)
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	return net.Listen("unix", path)
+}
+
+// serve accepts connections until the listener is closed, handing every
+// message it decodes to send. Once running is canceled the program is on its
+// way out, and senders are told their messages went nowhere.
+func serve(l net.Listener, send func(tea.Msg), running context.Context) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		go handle(conn, send, running)
+	}
+}
+
+func handle(conn net.Conn, send func(tea.Msg), running context.Context) {
+	defer conn.Close() //nolint:errcheck
+	enc := json.NewEncoder(conn)
+	sc := bufio.NewScanner(conn)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		var env envelope
+		if err := json.Unmarshal([]byte(line), &env); err != nil {
+			_ = enc.Encode(reply{Error: "invalid JSON: " + err.Error()})
+			continue
+		}
+		msg, err := decode(env)
+		if err != nil {
+			_ = enc.Encode(reply{Error: err.Error()})
+			continue
+		}
+		// Sending to a program that's quitting doesn't block, or fail, so
+		// check whether it's still there afterwards.
+		send(msg)
+		if running.Err() != nil {
+			_ = enc.Encode(reply{Error: "send-msg has exited"})
+			continue
+		}
+		_ = enc.Encode(reply{OK: true})
+	}
+}
+
+// sendAll writes envelopes to the program listening at path and returns the
+// first error it reports.
+func sendAll(path string, envs []envelope) error {
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return fmt.Errorf("is send-msg running? %w", err)
+	}
+	defer conn.Close() //nolint:errcheck
+
+	enc := json.NewEncoder(conn)
+	dec := json.NewDecoder(conn)
+	for _, env := range envs {
+		if err := enc.Encode(env); err != nil {
+			return err
+		}
+		var r reply
+		if err := dec.Decode(&r); err != nil {
+			if errors.Is(err, io.EOF) {
+				return errors.New("connection closed")
+			}
+			return err
+		}
+		if r.Error != "" {
+			return errors.New(r.Error)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		env     envelope
+		want    tea.Msg
+		wantErr string
+	}{
+		{
+			env:  envelope{Type: "status", Data: json.RawMessage(`{"text": "building", "level": "ok"}`)},
+			want: statusMsg{Text: "building", Level: "ok"},
+		},
+		{
+			env:  envelope{Type: "quit"},
+			want: quitMsg{},
+		},
+		{
+			env:  envelope{Type: "result", Data: json.RawMessage(`{"food": "a taco", "duration": "1.5s"}`)},
+			want: resultMsg{food: "a taco", duration: 1500 * time.Millisecond},
+		},
+		{
+			env:     envelope{Type: "status", Data: json.RawMessage(`{"txet": "typo"}`)},
+			wantErr: "unknown field",
+		},
+		{
+			env:     envelope{Type: "result", Data: json.RawMessage(`{"food": "a taco", "duration": "soon"}`)},
+			wantErr: "bad result message",
+		},
+		{
+			env:     envelope{Type: "result", Data: json.RawMessage(`{"food": "a taco", "duraton": "1s"}`)},
+			wantErr: "unknown field",
+		},
+		{
+			env:     envelope{Type: "nope"},
+			wantErr: `unknown message type "nope"`,
+		},
+	}
+	for _, tt := range tests {
+		got, err := decode(tt.env)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("decode(%s) error = %v, want %q", tt.env.Type, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("decode(%s): %v", tt.env.Type, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("decode(%s) = %#v, want %#v", tt.env.Type, got, tt.want)
+		}
+	}
+}
+
+func TestSocket(t *testing.T) {
+	// Socket paths have to be short, so don't use t.TempDir.
+	dir, err := os.MkdirTemp("", "send-msg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir) //nolint:errcheck
+	path := filepath.Join(dir, "sock")
+
+	// A socket left behind by a crashed program doesn't get in the way.
+	if err := os.WriteFile(path, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	l, err := listen(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close() //nolint:errcheck
+
+	if _, err := listen(path); err == nil {
+		t.Error("listening on a socket that's in use succeeded")
+	}
+
+	msgs := make(chan tea.Msg, 10)
+	running, stop := context.WithCancel(context.Background())
+	go serve(l, func(msg tea.Msg) { msgs <- msg }, running)
+
+	err = sendAll(path, []envelope{
+		{Type: "status", Data: json.RawMessage(`{"text": "one"}`)},
+		{Type: "status", Data: json.RawMessage(`{"text": "two"}`)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"one", "two"} {
+		if got := (<-msgs).(statusMsg).Text; got != want {
+			t.Errorf("got status %q, want %q", got, want)
+		}
+	}
+
+	err = sendAll(path, []envelope{{Type: "nope"}, {Type: "quit"}})
+	if err == nil || !strings.Contains(err.Error(), "unknown message type") {
+		t.Errorf("sending a bad message: err = %v", err)
+	}
+	select {
+	case msg := <-msgs:
+		t.Errorf("got %#v after a bad message, want nothing", msg)
+	default:
+	}
+
+	// Once the program has exited, messages aren't OK'd any more.
+	stop()
+	err = sendAll(path, []envelope{{Type: "quit"}})
+	if err == nil || !strings.Contains(err.Error(), "exited") {
+		t.Errorf("sending after the program exited: err = %v", err)
+	}
+}
+
+// stuckWriter holds up the program's last render, and with it the end of
+// its shutdown, until released.
+type stuckWriter struct {
+	quitting context.Context
+	release  chan struct{}
+}
+
+func (w *stuckWriter) Write(b []byte) (int, error) {
+	if w.quitting.Err() != nil {
+		<-w.release
+	}
+	return len(b), nil
+}
+
+func TestSendWhileQuitting(t *testing.T) {
+	dir, err := os.MkdirTemp("", "send-msg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir) //nolint:errcheck
+	path := filepath.Join(dir, "sock")
+	l, err := listen(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close() //nolint:errcheck
+
+	w := &stuckWriter{release: make(chan struct{})}
+	p, running, stop := newProgram(path, tea.WithInput(nil), tea.WithOutput(w), tea.WithoutSignalHandler())
+	defer stop()
+	w.quitting = running
+	go serve(l, p.Send, running)
+	exited := make(chan error)
+	go func() {
+		_, err := p.Run()
+		exited <- err
+	}()
+
+	if err := sendAll(path, []envelope{{Type: "status", Data: json.RawMessage(`{"text": "hi"}`)}, {Type: "quit"}}); err != nil {
+		t.Fatal(err)
+	}
+	<-running.Done()
+
+	// The program has stopped taking messages but hasn't exited yet.
+	err = sendAll(path, []envelope{{Type: "status", Data: json.RawMessage(`{"text": "too late"}`)}})
+	if err == nil || !strings.Contains(err.Error(), "exited") {
+		t.Errorf("sending while quitting: err = %v", err)
+	}
+	select {
+	case err := <-exited:
+		t.Fatalf("program exited early: %v", err)
+	default:
+	}
+
+	close(w.release)
+	if err := <-exited; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSendWithoutProgram(t *testing.T) {
+	err := sendAll(filepath.Join(os.TempDir(), "send-msg-missing.sock"), []envelope{{Type: "quit"}})
+	if err == nil {
+		t.Error("sending with nobody listening succeeded")
+	}
+}
@@ -2,11 +2,26 @@ package main
 
 // A simple example that shows how to send messages to a Bubble Tea program
 // from outside the program using Program.Send(Msg).
+//
+// Messages can also come from other processes over a Unix socket, which is
+// handy for pushing status from scripts into a long-running TUI:
+//
+//	send-msg &
+//	send-msg send status '{"text": "building…"}'
+//	send-msg send result '{"food": "a taco", "duration": "1.5s"}'
+//	send-msg send quit
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"math/rand"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -21,13 +36,60 @@ var (
 	dotStyle      = helpStyle.UnsetMargins()
 	durationStyle = dotStyle
 	appStyle      = lipgloss.NewStyle().Margin(1, 2, 0, 2)
+
+	levelStyles = map[string]lipgloss.Style{
+		"info":  lipgloss.NewStyle().Foreground(lipgloss.Color("63")),
+		"ok":    lipgloss.NewStyle().Foreground(lipgloss.Color("42")),
+		"warn":  lipgloss.NewStyle().Foreground(lipgloss.Color("214")),
+		"error": lipgloss.NewStyle().Foreground(lipgloss.Color("196")),
+	}
 )
 
+var defaultSocket = filepath.Join(os.TempDir(), "send-msg.sock")
+
 type resultMsg struct {
 	duration time.Duration
 	food     string
 }
 
+// Messages that only come from other processes.
+type (
+	statusMsg struct {
+		Text  string `json:"text"`
+		Level string `json:"level"` // info, ok, warn or error
+	}
+	quitMsg struct{}
+)
+
+func init() {
+	// These are the messages other processes may send us. Types whose
+	// fields match their JSON can be registered as they are, others get a
+	// decoder of their own.
+	registerJSON[statusMsg]("status")
+	registerJSON[quitMsg]("quit")
+	register("result", decodeResult)
+}
+
+func decodeResult(data json.RawMessage) (tea.Msg, error) {
+	var v struct {
+		Food     string `json:"food"`
+		Duration string `json:"duration"`
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if v.Food == "" {
+		return nil, errors.New("food is required")
+	}
+	d, err := time.ParseDuration(v.Duration)
+	if err != nil {
+		return nil, err
+	}
+	return resultMsg{food: v.Food, duration: d}, nil
+}
+
 func (r resultMsg) String() string {
 	if r.duration == 0 {
 		return dotStyle.Render(strings.Repeat(".", 30))
@@ -39,16 +101,19 @@ func (r resultMsg) String() string {
 type model struct {
 	spinner  spinner.Model
 	results  []resultMsg
+	status   statusMsg
+	socket   string
 	quitting bool
 }
 
-func newModel() model {
+func newModel(socket string) model {
 	const numLastResults = 5
 	s := spinner.New()
 	s.Style = spinnerStyle
 	return model{
 		spinner: s,
 		results: make([]resultMsg, numLastResults),
+		socket:  socket,
 	}
 }
 
@@ -64,6 +129,12 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case resultMsg:
 		m.results = append(m.results[1:], msg)
 		return m, nil
+	case statusMsg:
+		m.status = msg
+		return m, nil
+	case quitMsg:
+		m.quitting = true
+		return m, tea.Quit
 	case spinner.TickMsg:
 		var cmd tea.Cmd
 		m.spinner, cmd = m.spinner.Update(msg)
@@ -82,6 +153,14 @@ func (m model) View() string {
 		s += m.spinner.View() + " Eating food..."
 	}
 
+	s += "\n"
+	if m.status.Text != "" {
+		style, ok := levelStyles[m.status.Level]
+		if !ok {
+			style = levelStyles["info"]
+		}
+		s += style.Render(m.status.Text)
+	}
 	s += "\n\n"
 
 	for _, res := range m.results {
@@ -89,7 +168,7 @@ func (m model) View() string {
 	}
 
 	if !m.quitting {
-		s += helpStyle.Render("Press any key to exit")
+		s += helpStyle.Render("Press any key to exit\nListening on " + m.socket)
 	}
 
 	if m.quitting {
@@ -100,20 +179,42 @@ func (m model) View() string {
 }
 
 func main() {
-	p := tea.NewProgram(newModel())
+	if len(os.Args) > 1 && os.Args[1] == "send" {
+		os.Exit(runSend(os.Args[2:]))
+	}
+
+	socket := flag.String("socket", defaultSocket, "socket to listen on for messages")
+	simulate := flag.Bool("simulate", true, "eat some food while we wait")
+	flag.Parse()
+
+	l, err := listen(*socket)
+	if err != nil {
+		fmt.Println("Error listening:", err)
+		os.Exit(1)
+	}
+	defer l.Close() //nolint:errcheck
+
+	p, running, stop := newProgram(*socket)
+	defer stop()
+
+	// Messages from other processes are sent to the program just like the
+	// ones below.
+	go serve(l, p.Send, running)
 
 	// Simulate activity
-	go func() {
-		for {
-			pause := time.Duration(rand.Int63n(899)+100) * time.Millisecond // nolint:gosec
-			time.Sleep(pause)
-
-			// Send the Bubble Tea program a message from outside the
-			// tea.Program. This will block until it is ready to receive
-			// messages.
-			p.Send(resultMsg{food: randomFood(), duration: pause})
-		}
-	}()
+	if *simulate {
+		go func() {
+			for {
+				pause := time.Duration(rand.Int63n(899)+100) * time.Millisecond // nolint:gosec
+				time.Sleep(pause)
+
+				// Send the Bubble Tea program a message from outside the
+				// tea.Program. This will block until it is ready to receive
+				// messages.
+				p.Send(resultMsg{food: randomFood(), duration: pause})
+			}
+		}()
+	}
 
 	if _, err := p.Run(); err != nil {
 		fmt.Println("Error running program:", err)
@@ -121,6 +222,76 @@ func main() {
 	}
 }
 
+// newProgram returns the program along with a context that's canceled as
+// soon as it starts to quit. From then on, messages sent to it are dropped.
+func newProgram(socket string, opts ...tea.ProgramOption) (*tea.Program, context.Context, context.CancelFunc) {
+	ctx, stop := context.WithCancel(context.Background())
+	opts = append(opts, tea.WithFilter(func(_ tea.Model, msg tea.Msg) tea.Msg {
+		// The program stops taking messages once it sees one of these,
+		// whoever sent it.
+		switch msg.(type) {
+		case tea.QuitMsg, tea.InterruptMsg:
+			stop()
+		}
+		return msg
+	}))
+	return tea.NewProgram(newModel(socket), opts...), ctx, stop
+}
+
+// runSend is the send subcommand. It sends a single message given on the
+// command line, or envelopes read from stdin, one per line.
+func runSend(args []string) int {
+	fs := flag.NewFlagSet("send", flag.ExitOnError)
+	socket := fs.String("socket", defaultSocket, "socket the program is listening on")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage:\n  send-msg send [flags] TYPE [JSON]\n  send-msg send [flags] < messages.jsonl\n\n")
+		fmt.Fprintf(fs.Output(), "Types: %s\n\nFlags:\n", strings.Join(messageTypes(), ", "))
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+
+	var envs []envelope
+	switch fs.NArg() {
+	case 0:
+		sc := bufio.NewScanner(os.Stdin)
+		for sc.Scan() {
+			line := strings.TrimSpace(sc.Text())
+			if line == "" {
+				continue
+			}
+			var env envelope
+			if err := json.Unmarshal([]byte(line), &env); err != nil {
+				fmt.Fprintln(os.Stderr, "Error reading message:", err)
+				return 1
+			}
+			envs = append(envs, env)
+		}
+		if err := sc.Err(); err != nil {
+			fmt.Fprintln(os.Stderr, "Error reading messages:", err)
+			return 1
+		}
+	case 1, 2:
+		env := envelope{Type: fs.Arg(0)}
+		if data := fs.Arg(1); data != "" {
+			if !json.Valid([]byte(data)) {
+				fmt.Fprintln(os.Stderr, "Error: message data isn't valid JSON")
+				return 1
+			}
+			env.Data = json.RawMessage(data)
+		}
+		envs = append(envs, env)
+	default:
+		fs.Usage()
+		return 2
+	}
+
+	if err := sendAll(*socket, envs); err != nil {
+		fmt.Fprintln(os.Stderr, "Error sending message:", err)
+		return 1
+	}
+	return 0
+}
+
 func randomFood() string {
 	food := []string{
 		"an apple", "a pear", "a gherkin", "a party gherkin",
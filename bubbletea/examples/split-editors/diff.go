@@ -0,0 +1,273 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aymanbagabas/go-udiff"
+	"github.com/aymanbagabas/go-udiff/myers"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	removedStyle = lipgloss.NewStyle().Background(lipgloss.Color("52"))
+	addedStyle   = lipgloss.NewStyle().Background(lipgloss.Color("22"))
+	changedStyle = lipgloss.NewStyle().Background(lipgloss.Color("58"))
+	fillerStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("236"))
+	lineNoStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	hunkStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("212")).Bold(true)
+	titleStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("99")).Bold(true)
+)
+
+type rowKind int
+
+const (
+	same    rowKind = iota
+	changed         // the line differs on each side
+	removed         // the line is only on the left
+	added           // the line is only on the right
+)
+
+// row is a line of the side-by-side view. left and right index into the
+// lines on each side, or are -1 where that side has no line.
+type row struct {
+	kind        rowKind
+	left, right int
+}
+
+// hunk is a run of rows that differ, from start up to end.
+type hunk struct {
+	start, end int
+}
+
+// alignLines lines a and b up side by side, pairing the lines that were
+// changed rather than just removed or added.
+func alignLines(a, b []string) []row {
+	ta := joinLines(a)
+	u, err := udiff.ToUnifiedDiff("a", "b", ta, myers.ComputeEdits(ta, joinLines(b)), 0)
+	if err != nil {
+		// Can't happen: the edits come from a and b.
+		return nil
+	}
+
+	var rows []row
+	i, j := 0, 0
+	for _, h := range u.Hunks {
+		// Everything up to the hunk is the same on both sides.
+		for ; i < h.FromLine-1; i, j = i+1, j+1 {
+			rows = append(rows, row{same, i, j})
+		}
+		var dels, adds int
+		for _, l := range h.Lines {
+			switch l.Kind {
+			case udiff.Delete:
+				dels++
+			case udiff.Insert:
+				adds++
+			}
+		}
+		for k := range max(dels, adds) {
+			switch {
+			case k < dels && k < adds:
+				rows = append(rows, row{changed, i + k, j + k})
+			case k < dels:
+				rows = append(rows, row{removed, i + k, -1})
+			default:
+				rows = append(rows, row{added, -1, j + k})
+			}
+		}
+		i, j = i+dels, j+adds
+	}
+	for ; i < len(a); i, j = i+1, j+1 {
+		rows = append(rows, row{same, i, j})
+	}
+	return rows
+}
+
+// joinLines puts lines back together with a newline after each, which is
+// how the diff package expects them.
+func joinLines(lines []string) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func findHunks(rows []row) []hunk {
+	var hunks []hunk
+	for i := 0; i < len(rows); i++ {
+		if rows[i].kind == same {
+			continue
+		}
+		h := hunk{start: i}
+		for i < len(rows) && rows[i].kind != same {
+			i++
+		}
+		h.end = i
+		hunks = append(hunks, h)
+	}
+	return hunks
+}
+
+// applyHunk returns dst with the lines of hunk h replaced by the ones from
+// src. fromLeft says which side of the rows src is.
+func applyHunk(src, dst []string, rows []row, h hunk, fromLeft bool) []string {
+	side := func(r row) (s, d int) {
+		if fromLeft {
+			return r.left, r.right
+		}
+		return r.right, r.left
+	}
+	// Where the hunk starts in dst is the number of dst lines before it.
+	at := 0
+	for _, r := range rows[:h.start] {
+		if _, d := side(r); d >= 0 {
+			at++
+		}
+	}
+	var take []string
+	n := 0
+	for _, r := range rows[h.start:h.end] {
+		s, d := side(r)
+		if s >= 0 {
+			take = append(take, src[s])
+		}
+		if d >= 0 {
+			n++
+		}
+	}
+	out := make([]string, 0, len(dst)-n+len(take))
+	out = append(out, dst[:at]...)
+	out = append(out, take...)
+	return append(out, dst[at+n:]...)
+}
+
+// diffView compares two editors side by side.
+type diffView struct {
+	left, right int // editors being compared
+	a, b        []string
+	rows        []row
+	hunks       []hunk
+	hunk        int // the current hunk
+	offset      int // first row on screen
+}
+
+func newDiffView(left, right int, a, b string) diffView {
+	d := diffView{left: left, right: right}
+	d.update(a, b)
+	return d
+}
+
+// update recomputes the diff, keeping the current hunk where possible.
+func (d *diffView) update(a, b string) {
+	d.a, d.b = splitLines(a), splitLines(b)
+	d.rows = alignLines(d.a, d.b)
+	d.hunks = findHunks(d.rows)
+	d.hunk = max(0, min(d.hunk, len(d.hunks)-1))
+	d.offset = max(0, min(d.offset, len(d.rows)-1))
+}
+
+// move goes to another hunk and scrolls it into view.
+func (d *diffView) move(n, height int) {
+	if len(d.hunks) == 0 {
+		return
+	}
+	d.hunk = (d.hunk + n + len(d.hunks)) % len(d.hunks)
+	h := d.hunks[d.hunk]
+	if h.start < d.offset || h.end > d.offset+height {
+		// Leave a few lines of context above.
+		d.offset = max(0, h.start-3)
+	}
+}
+
+func (d *diffView) scroll(n, height int) {
+	d.offset = max(0, min(d.offset+n, len(d.rows)-height))
+}
+
+// take copies the current hunk from one side to the other and returns the
+// new text of the side that changed.
+func (d diffView) take(fromLeft bool) (string, bool) {
+	if len(d.hunks) == 0 {
+		return "", false
+	}
+	h := d.hunks[d.hunk]
+	if fromLeft {
+		return strings.Join(applyHunk(d.a, d.b, d.rows, h, true), "\n"), true
+	}
+	return strings.Join(applyHunk(d.b, d.a, d.rows, h, false), "\n"), true
+}
+
+func (d diffView) summary() string {
+	if len(d.hunks) == 0 {
+		return "no differences"
+	}
+	return fmt.Sprintf("hunk %d of %d", d.hunk+1, len(d.hunks))
+}
+
+func (d diffView) View(leftTitle, rightTitle string, width, height int) string {
+	col := width / 2
+	var b strings.Builder
+	b.WriteString(pad(titleStyle.Render(leftTitle), col) + pad(titleStyle.Render(rightTitle), col))
+
+	var cur hunk
+	if len(d.hunks) > 0 {
+		cur = d.hunks[d.hunk]
+	}
+	end := min(d.offset+height-1, len(d.rows))
+	for i := d.offset; i < end; i++ {
+		r := d.rows[i]
+		gutter := " "
+		if i >= cur.start && i < cur.end {
+			gutter = hunkStyle.Render("▌")
+		}
+		b.WriteString("\n" + gutter + d.cell(d.a, r.left, r.kind, col-1) + " " + d.cell(d.b, r.right, r.kind, col-1))
+	}
+	return b.String()
+}
+
+// cell renders one side of a row.
+func (d diffView) cell(lines []string, i int, kind rowKind, width int) string {
+	if i < 0 {
+		return fillerStyle.Render(strings.Repeat("╱", max(width-1, 0)))
+	}
+	num := lineNoStyle.Render(fmt.Sprintf("%4d ", i+1))
+	text := truncate(strings.ReplaceAll(lines[i], "\t", "    "), width-6)
+	text += strings.Repeat(" ", max(0, width-6-lipgloss.Width(text)))
+	switch kind {
+	case changed:
+		text = changedStyle.Render(text)
+	case removed:
+		text = removedStyle.Render(text)
+	case added:
+		text = addedStyle.Render(text)
+	}
+	return num + text
+}
+
+func truncate(s string, width int) string {
+	if lipgloss.Width(s) <= width {
+		return s
+	}
+	var b strings.Builder
+	w := 0
+	for _, r := range s {
+		rw := lipgloss.Width(string(r))
+		if w+rw > width-1 {
+			break
+		}
+		b.WriteRune(r)
+		w += rw
+	}
+	return b.String() + "…"
+}
+
+func pad(s string, width int) string {
+	return s + strings.Repeat(" ", max(0, width-lipgloss.Width(s)))
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
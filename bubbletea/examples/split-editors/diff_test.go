@@ -0,0 +1,135 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAlignLines(t *testing.T) {
+	a := []string{"one", "two", "three", "four"}
+	b := []string{"one", "2", "three", "four", "five"}
+	want := []row{
+		{same, 0, 0},
+		{changed, 1, 1},
+		{same, 2, 2},
+		{same, 3, 3},
+		{added, -1, 4},
+	}
+	rows := alignLines(a, b)
+	if !reflect.DeepEqual(rows, want) {
+		t.Fatalf("alignLines = %v, want %v", rows, want)
+	}
+	if got, want := findHunks(rows), []hunk{{1, 2}, {4, 5}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("findHunks = %v, want %v", got, want)
+	}
+}
+
+func TestAlignLinesRemoved(t *testing.T) {
+	rows := alignLines([]string{"a", "b", "c", "d"}, []string{"a", "d"})
+	want := []row{{same, 0, 0}, {removed, 1, -1}, {removed, 2, -1}, {same, 3, 1}}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("alignLines = %v, want %v", rows, want)
+	}
+}
+
+func TestApplyHunk(t *testing.T) {
+	a := []string{"a", "b", "c", "d"}
+	b := []string{"a", "x", "y", "d", "e"}
+	rows := alignLines(a, b)
+	hunks := findHunks(rows)
+	if len(hunks) != 2 {
+		t.Fatalf("got %d hunks, want 2", len(hunks))
+	}
+
+	// Taking the first hunk from the left puts b and c back on the right.
+	if got, want := applyHunk(a, b, rows, hunks[0], true), []string{"a", "b", "c", "d", "e"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("first hunk to the right = %q, want %q", got, want)
+	}
+	// Taking the second hunk from the right adds e on the left.
+	if got, want := applyHunk(b, a, rows, hunks[1], false), []string{"a", "b", "c", "d", "e"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("second hunk to the left = %q, want %q", got, want)
+	}
+	// Taking it from the left drops e on the right.
+	if got, want := applyHunk(a, b, rows, hunks[1], true), []string{"a", "x", "y", "d"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("second hunk to the right = %q, want %q", got, want)
+	}
+}
+
+func TestDiffViewTake(t *testing.T) {
+	d := newDiffView(0, 1, "a\nb\nc", "a\nB\nc\nd")
+	if d.summary() != "hunk 1 of 2" {
+		t.Errorf("summary = %q", d.summary())
+	}
+	d.move(1, 10)
+	text, ok := d.take(false)
+	if !ok || text != "a\nb\nc\nd" {
+		t.Errorf("take = %q, want the left side with d added", text)
+	}
+	d.update(text, "a\nB\nc\nd")
+	if d.hunk != 0 || len(d.hunks) != 1 {
+		t.Errorf("after update: hunk %d of %d, want the one left", d.hunk, len(d.hunks))
+	}
+}
+
+func TestEditorOpenSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(path, []byte("one\r\ntwo\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	e := newEditor()
+	if err := e.open(path); err != nil {
+		t.Fatal(err)
+	}
+	if e.Value() != "one\ntwo" || e.dirty() {
+		t.Fatalf("opened %q, dirty %v", e.Value(), e.dirty())
+	}
+
+	e.Focus()
+	e.InsertString("!")
+	if !e.dirty() || !strings.Contains(e.title(), "●") {
+		t.Errorf("title = %q after an edit, want a dirty marker", e.title())
+	}
+	if err := e.save(""); err != nil {
+		t.Fatal(err)
+	}
+	if e.dirty() {
+		t.Error("dirty after saving")
+	}
+	b, _ := os.ReadFile(path)
+	if string(b) != "one\r\ntwo!\r\n" {
+		t.Errorf("saved %q, want the line endings kept", b)
+	}
+
+	// So is a missing newline at the end.
+	if err := os.WriteFile(path, []byte("one\ntwo"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.open(path); err != nil {
+		t.Fatal(err)
+	}
+	e.InsertString("!")
+	if err := e.save(""); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(path); string(b) != "one\ntwo!" {
+		t.Errorf("saved %q, want no newline at the end", b)
+	}
+
+	// A new file opens empty and is created on save, with a newline at the
+	// end.
+	other := filepath.Join(filepath.Dir(path), "new.txt")
+	if err := e.open(other); err != nil || e.Value() != "" {
+		t.Fatalf("opening a new file: %q, %v", e.Value(), err)
+	}
+	e.InsertString("new")
+	if err := e.save(""); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(other); string(b) != "new\n" {
+		t.Errorf("saved a new file as %q", b)
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	dirtyStyle        = lipgloss.NewStyle().Foreground(lipgloss.Color("212"))
+	blurredTitleStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+)
+
+// editor is a textarea that may be backed by a file.
+type editor struct {
+	textarea.Model
+	path  string // empty until the editor is opened or saved
+	saved string // the text as it was last opened or saved
+
+	// How the file ends its lines, so it's saved the way it was found.
+	crlf    bool
+	newline bool // whether the last line ends with one
+}
+
+func newEditor() editor {
+	return editor{Model: newTextarea(), newline: true}
+}
+
+func (e editor) dirty() bool {
+	return e.Value() != e.saved
+}
+
+func (e editor) name() string {
+	if e.path == "" {
+		return "untitled"
+	}
+	return filepath.Base(e.path)
+}
+
+// title is the name with a marker when there are unsaved changes.
+func (e editor) title() string {
+	if e.dirty() {
+		return e.name() + " ●"
+	}
+	return e.name()
+}
+
+// open loads a file into the editor. A file that doesn't exist yet opens
+// empty, and is created when it's saved.
+func (e *editor) open(path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	text := string(b)
+	e.crlf = strings.Contains(text, "\r\n")
+	e.newline = err != nil || strings.HasSuffix(text, "\n")
+
+	// The textarea would show a trailing newline as an extra empty line.
+	text = strings.TrimSuffix(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	e.SetValue(text)
+	e.path, e.saved = path, text
+	return nil
+}
+
+// save writes the editor to its file, or to path if it's given.
+func (e *editor) save(path string) error {
+	if path == "" {
+		path = e.path
+	}
+	text := e.Value()
+	data := text
+	if data != "" && e.newline {
+		data += "\n"
+	}
+	if e.crlf {
+		data = strings.ReplaceAll(data, "\n", "\r\n")
+	}
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil { //nolint:gosec
+		return err
+	}
+	e.path, e.saved = path, text
+	return nil
+}
+
+// View draws the textarea with the title set into the top of its border.
+func (e editor) View() string {
+	view := e.Model.View()
+	width := lipgloss.Width(view)
+
+	title := " " + e.name() + " "
+	if e.dirty() {
+		title += dirtyStyle.Render("●") + " "
+	}
+	if !e.Focused() {
+		// Blurred editors have no border to put the title in.
+		line := " " + blurredTitleStyle.Render(title)
+		return pad(truncateTitle(line, width), width) + "\n" + view
+	}
+
+	border := lipgloss.RoundedBorder()
+	bs := lipgloss.NewStyle().Foreground(focusedBorderStyle.GetBorderTopForeground())
+	line := bs.Render(border.TopLeft+border.Top) + title
+	line = truncateTitle(line, width-1)
+	fill := max(0, width-1-lipgloss.Width(line))
+	line += bs.Render(strings.Repeat(border.Top, fill) + border.TopRight)
+	return line + "\n" + view
+}
+
+// truncateTitle keeps long file names from breaking the border.
+func truncateTitle(s string, width int) string {
+	if lipgloss.Width(s) <= width {
+		return s
+	}
+	return lipgloss.NewStyle().MaxWidth(width).Render(s)
+}
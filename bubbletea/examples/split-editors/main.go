@@ -7,6 +7,7 @@ import (
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
@@ -34,18 +35,37 @@ var (
 	focusedPlaceholderStyle = lipgloss.NewStyle().
 				Foreground(lipgloss.Color("99"))
 
+	// The top of the border is drawn by the editor, with the file name in
+	// it.
 	focusedBorderStyle = lipgloss.NewStyle().
 				Border(lipgloss.RoundedBorder()).
-				BorderForeground(lipgloss.Color("238"))
+				BorderForeground(lipgloss.Color("238")).
+				BorderTop(false)
 
 	blurredBorderStyle = lipgloss.NewStyle().
-				Border(lipgloss.HiddenBorder())
+				Border(lipgloss.HiddenBorder()).
+				BorderTop(false)
+
+	statusStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	errorStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("203"))
 )
 
 type keymap = struct {
-	next, prev, add, remove, quit key.Binding
+	next, prev, add, remove, open, save, diff, quit key.Binding
+
+	// In the diff view.
+	nextHunk, prevHunk, takeLeft, takeRight, up, down, closeDiff key.Binding
 }
 
+// promptKind is what the path prompt is asking for.
+type promptKind int
+
+const (
+	noPrompt promptKind = iota
+	openPrompt
+	savePrompt
+)
+
 func newTextarea() textarea.Model {
 	t := textarea.New()
 	t.Prompt = ""
@@ -62,6 +82,7 @@ func newTextarea() textarea.Model {
 	t.KeyMap.DeleteWordBackward.SetEnabled(false)
 	t.KeyMap.LineNext = key.NewBinding(key.WithKeys("down"))
 	t.KeyMap.LinePrevious = key.NewBinding(key.WithKeys("up"))
+	t.MaxHeight = 0 // files can be long
 	t.Blur()
 	return t
 }
@@ -71,14 +92,28 @@ type model struct {
 	height int
 	keymap keymap
 	help   help.Model
-	inputs []textarea.Model
+	inputs []editor
 	focus  int
+
+	prompt     textinput.Model
+	promptKind promptKind
+
+	// diff is set while comparing the focused editor with the next one.
+	diff *diffView
+
+	status    string
+	statusErr bool
+
+	// armed is a key that was pressed once when it would have lost unsaved
+	// changes. Pressing it again goes ahead.
+	armed string
 }
 
-func newModel() model {
+func newModel(paths []string) model {
 	m := model{
-		inputs: make([]textarea.Model, initialInputs),
+		inputs: make([]editor, max(initialInputs, min(len(paths), maxInputs))),
 		help:   help.New(),
+		prompt: textinput.New(),
 		keymap: keymap{
 			next: key.NewBinding(
 				key.WithKeys("tab"),
@@ -96,14 +131,59 @@ func newModel() model {
 				key.WithKeys("ctrl+w"),
 				key.WithHelp("ctrl+w", "remove an editor"),
 			),
+			open: key.NewBinding(
+				key.WithKeys("ctrl+o"),
+				key.WithHelp("ctrl+o", "open"),
+			),
+			save: key.NewBinding(
+				key.WithKeys("ctrl+s"),
+				key.WithHelp("ctrl+s", "save"),
+			),
+			diff: key.NewBinding(
+				key.WithKeys("ctrl+d"),
+				key.WithHelp("ctrl+d", "diff with next"),
+			),
 			quit: key.NewBinding(
 				key.WithKeys("esc", "ctrl+c"),
 				key.WithHelp("esc", "quit"),
 			),
+			nextHunk: key.NewBinding(
+				key.WithKeys("n", "]"),
+				key.WithHelp("n", "next hunk"),
+			),
+			prevHunk: key.NewBinding(
+				key.WithKeys("p", "["),
+				key.WithHelp("p", "prev hunk"),
+			),
+			takeLeft: key.NewBinding(
+				key.WithKeys(">"),
+				key.WithHelp(">", "copy hunk right"),
+			),
+			takeRight: key.NewBinding(
+				key.WithKeys("<"),
+				key.WithHelp("<", "copy hunk left"),
+			),
+			up: key.NewBinding(
+				key.WithKeys("up", "k"),
+				key.WithHelp("↑/k", "scroll up"),
+			),
+			down: key.NewBinding(
+				key.WithKeys("down", "j"),
+				key.WithHelp("↓/j", "scroll down"),
+			),
+			closeDiff: key.NewBinding(
+				key.WithKeys("esc", "ctrl+d"),
+				key.WithHelp("esc", "back to editing"),
+			),
 		},
 	}
-	for i := 0; i < initialInputs; i++ {
-		m.inputs[i] = newTextarea()
+	for i := range m.inputs {
+		m.inputs[i] = newEditor()
+	}
+	for i, path := range paths[:min(len(paths), maxInputs)] {
+		if err := m.inputs[i].open(path); err != nil {
+			m.setStatus(err.Error(), true)
+		}
 	}
 	m.inputs[m.focus].Focus()
 	m.updateKeybindings()
@@ -117,14 +197,46 @@ func (m model) Init() tea.Cmd {
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 
+	var armed string
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		if m.promptKind != noPrompt {
+			return m.updatePrompt(msg)
+		}
+		if m.diff != nil {
+			return m.updateDiff(msg)
+		}
+		armed, m.armed = m.armed, ""
+	}
+
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch {
 		case key.Matches(msg, m.keymap.quit):
+			if n := m.unsaved(); n > 0 && armed != msg.String() && msg.String() != "ctrl+c" {
+				m.armed = msg.String()
+				m.setStatus(fmt.Sprintf("%d %s unsaved changes, press esc again to quit", n, plural(n, "editor has", "editors have")), true)
+				return m, nil
+			}
 			for i := range m.inputs {
 				m.inputs[i].Blur()
 			}
 			return m, tea.Quit
+		case key.Matches(msg, m.keymap.open):
+			if e := m.inputs[m.focus]; e.dirty() && armed != msg.String() {
+				m.armed = msg.String()
+				m.setStatus(e.name()+" has unsaved changes, press ctrl+o again to open over it", true)
+				return m, nil
+			}
+			return m, m.startPrompt(openPrompt, "")
+		case key.Matches(msg, m.keymap.save):
+			if m.inputs[m.focus].path == "" {
+				return m, m.startPrompt(savePrompt, "")
+			}
+			m.save("")
+			return m, nil
+		case key.Matches(msg, m.keymap.diff):
+			m.openDiff()
+			return m, nil
 		case key.Matches(msg, m.keymap.next):
 			m.inputs[m.focus].Blur()
 			m.focus++
@@ -142,8 +254,13 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			cmd := m.inputs[m.focus].Focus()
 			cmds = append(cmds, cmd)
 		case key.Matches(msg, m.keymap.add):
-			m.inputs = append(m.inputs, newTextarea())
+			m.inputs = append(m.inputs, newEditor())
 		case key.Matches(msg, m.keymap.remove):
+			if e := m.inputs[len(m.inputs)-1]; e.dirty() && armed != msg.String() {
+				m.armed = msg.String()
+				m.setStatus(e.name()+" has unsaved changes, press ctrl+w again to remove it", true)
+				return m, nil
+			}
 			m.inputs = m.inputs[:len(m.inputs)-1]
 			if m.focus > len(m.inputs)-1 {
 				m.focus = len(m.inputs) - 1
@@ -152,6 +269,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		m.height = msg.Height
 		m.width = msg.Width
+		m.help.Width = msg.Width
 	}
 
 	m.updateKeybindings()
@@ -160,31 +278,185 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	// Update all textareas
 	for i := range m.inputs {
 		newModel, cmd := m.inputs[i].Update(msg)
-		m.inputs[i] = newModel
+		m.inputs[i].Model = newModel
 		cmds = append(cmds, cmd)
 	}
 
 	return m, tea.Batch(cmds...)
 }
 
+func (m model) updatePrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc":
+		m.closePrompt()
+		return m, nil
+	case "enter":
+		path := m.prompt.Value()
+		kind := m.promptKind
+		m.closePrompt()
+		if path == "" {
+			return m, nil
+		}
+		if kind == savePrompt {
+			m.save(path)
+			return m, nil
+		}
+		if err := m.inputs[m.focus].open(path); err != nil {
+			m.setStatus(err.Error(), true)
+			return m, nil
+		}
+		m.setStatus("Opened "+path, false)
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.prompt, cmd = m.prompt.Update(msg)
+	return m, cmd
+}
+
+func (m *model) startPrompt(kind promptKind, value string) tea.Cmd {
+	m.promptKind = kind
+	m.prompt.Prompt = "Open: "
+	if kind == savePrompt {
+		m.prompt.Prompt = "Save as: "
+	}
+	m.prompt.SetValue(value)
+	m.inputs[m.focus].Blur()
+	return m.prompt.Focus()
+}
+
+func (m *model) closePrompt() {
+	m.promptKind = noPrompt
+	m.prompt.Blur()
+	m.prompt.Reset()
+	m.inputs[m.focus].Focus()
+}
+
+func (m *model) save(path string) {
+	e := &m.inputs[m.focus]
+	if err := e.save(path); err != nil {
+		m.setStatus(err.Error(), true)
+		return
+	}
+	m.setStatus("Saved "+e.path, false)
+}
+
+// unsaved counts the editors with unsaved changes.
+func (m model) unsaved() int {
+	n := 0
+	for _, e := range m.inputs {
+		if e.dirty() {
+			n++
+		}
+	}
+	return n
+}
+
+func (m *model) setStatus(s string, isErr bool) {
+	m.status, m.statusErr = s, isErr
+}
+
+// openDiff compares the focused editor with the one after it.
+func (m *model) openDiff() {
+	if len(m.inputs) < 2 {
+		m.setStatus("Add another editor to compare with", true)
+		return
+	}
+	left, right := m.focus, (m.focus+1)%len(m.inputs)
+	d := newDiffView(left, right, m.inputs[left].Value(), m.inputs[right].Value())
+	d.move(0, m.diffHeight())
+	m.diff = &d
+	m.setStatus("", false)
+}
+
+func (m model) updateDiff(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	d := *m.diff
+	m.diff = &d
+	switch {
+	case msg.String() == "ctrl+c":
+		return m, tea.Quit
+	case key.Matches(msg, m.keymap.closeDiff):
+		m.diff = nil
+	case key.Matches(msg, m.keymap.nextHunk):
+		d.move(1, m.diffHeight())
+	case key.Matches(msg, m.keymap.prevHunk):
+		d.move(-1, m.diffHeight())
+	case key.Matches(msg, m.keymap.up):
+		d.scroll(-1, m.diffHeight())
+	case key.Matches(msg, m.keymap.down):
+		d.scroll(1, m.diffHeight())
+	case key.Matches(msg, m.keymap.takeLeft), key.Matches(msg, m.keymap.takeRight):
+		fromLeft := key.Matches(msg, m.keymap.takeLeft)
+		text, ok := d.take(fromLeft)
+		if !ok {
+			break
+		}
+		dst := d.right
+		if !fromLeft {
+			dst = d.left
+		}
+		m.inputs[dst].SetValue(text)
+		d.update(m.inputs[d.left].Value(), m.inputs[d.right].Value())
+		d.move(0, m.diffHeight())
+	}
+	return m, nil
+}
+
+// diffHeight is how many rows of the diff fit on screen, under the titles.
+func (m model) diffHeight() int {
+	return max(1, m.height-helpHeight)
+}
+
 func (m *model) sizeInputs() {
 	for i := range m.inputs {
 		m.inputs[i].SetWidth(m.width / len(m.inputs))
-		m.inputs[i].SetHeight(m.height - helpHeight)
+		// Leave a line for the title.
+		m.inputs[i].SetHeight(m.height - helpHeight - 1)
 	}
 }
 
 func (m *model) updateKeybindings() {
 	m.keymap.add.SetEnabled(len(m.inputs) < maxInputs)
 	m.keymap.remove.SetEnabled(len(m.inputs) > minInputs)
+	m.keymap.diff.SetEnabled(len(m.inputs) > 1)
 }
 
 func (m model) View() string {
+	var status string
+	switch {
+	case m.promptKind != noPrompt:
+		status = m.prompt.View()
+	case m.statusErr:
+		status = errorStyle.Render(m.status)
+	default:
+		status = statusStyle.Render(m.status)
+	}
+
+	if m.diff != nil {
+		d := m.diff
+		help := m.help.ShortHelpView([]key.Binding{
+			m.keymap.nextHunk,
+			m.keymap.prevHunk,
+			m.keymap.takeLeft,
+			m.keymap.takeRight,
+			m.keymap.closeDiff,
+		})
+		if status == "" {
+			status = statusStyle.Render(d.summary())
+		}
+		view := d.View(m.inputs[d.left].title(), m.inputs[d.right].title(), m.width, m.diffHeight()+1)
+		return view + "\n\n" + status + "\n" + help
+	}
+
 	help := m.help.ShortHelpView([]key.Binding{
 		m.keymap.next,
 		m.keymap.prev,
 		m.keymap.add,
 		m.keymap.remove,
+		m.keymap.open,
+		m.keymap.save,
+		m.keymap.diff,
 		m.keymap.quit,
 	})
 
@@ -193,11 +465,19 @@ func (m model) View() string {
 		views = append(views, m.inputs[i].View())
 	}
 
-	return lipgloss.JoinHorizontal(lipgloss.Top, views...) + "\n\n" + help
+	return lipgloss.JoinHorizontal(lipgloss.Top, views...) + "\n" + status + "\n" + help
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
 }
 
 func main() {
-	if _, err := tea.NewProgram(newModel(), tea.WithAltScreen()).Run(); err != nil {
+	// Files to open can be given on the command line, one per editor.
+	if _, err := tea.NewProgram(newModel(os.Args[1:]), tea.WithAltScreen()).Run(); err != nil {
 		fmt.Println("Error while running program:", err)
 		os.Exit(1)
 	}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestDirtyEditorsAsk(t *testing.T) {
+	var m tea.Model = newModel(nil)
+	m, _ = m.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+	press := func(k tea.KeyType) {
+		m, _ = m.Update(tea.KeyMsg{Type: k})
+	}
+
+	// Type in the second editor, which is the one ctrl+w removes.
+	press(tea.KeyTab)
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("hi")})
+
+	press(tea.KeyCtrlW)
+	if got := m.(model); len(got.inputs) != 2 || !strings.Contains(got.status, "press ctrl+w again") {
+		t.Fatalf("removed a dirty editor: %d left, status %q", len(got.inputs), got.status)
+	}
+	press(tea.KeyCtrlO)
+	if got := m.(model); got.promptKind != noPrompt || !strings.Contains(got.status, "press ctrl+o again") {
+		t.Fatalf("opened over a dirty editor: status %q", got.status)
+	}
+
+	// The ctrl+o in between means ctrl+w has to be pressed twice again.
+	press(tea.KeyCtrlW)
+	if len(m.(model).inputs) != 2 {
+		t.Fatal("removed the editor without asking again")
+	}
+	press(tea.KeyCtrlW)
+	if got := m.(model); len(got.inputs) != 1 || got.focus != 0 {
+		t.Errorf("after asking: %d editors, focus on %d", len(got.inputs), got.focus)
+	}
+}
@@ -1,14 +1,30 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/stopwatch"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// lapRows is how many laps fit in the table before it scrolls.
+const lapRows = 8
+
+var (
+	headerStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Bold(true)
+	bestStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	worstStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("203"))
+	dimStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	errorStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("203"))
 )
 
 type model struct {
@@ -16,13 +32,32 @@ type model struct {
 	keymap    keymap
 	help      help.Model
 	quitting  bool
+
+	session  session
+	file     string // where sessions are saved
+	stored   string // the name the session is saved under, if it is
+	replace  string // a saved session to replace when saving again
+	base     time.Duration
+	unsaved  bool
+	offset   int // first lap shown, counting from the newest
+	naming   bool
+	saveNext bool // save once the session has a name
+	input    textinput.Model
+	status   string
+	err      error
 }
 
 type keymap struct {
-	start key.Binding
-	stop  key.Binding
-	reset key.Binding
-	quit  key.Binding
+	start  key.Binding
+	stop   key.Binding
+	lap    key.Binding
+	reset  key.Binding
+	name   key.Binding
+	save   key.Binding
+	export key.Binding
+	up     key.Binding
+	down   key.Binding
+	quit   key.Binding
 }
 
 func (m model) Init() tea.Cmd {
@@ -30,22 +65,92 @@ func (m model) Init() tea.Cmd {
 }
 
 func (m model) View() string {
-	// Note: you could further customize the time output by getting the
-	// duration from m.stopwatch.Elapsed(), which returns a time.Duration, and
-	// skip m.stopwatch.View() altogether.
-	s := m.stopwatch.View() + "\n"
+	// A session that's picked up again carries on from its last lap, so
+	// rather than m.stopwatch.View() we show the time that includes it.
+	s := m.elapsed().String() + "\n"
 	if !m.quitting {
-		s = "Elapsed: " + s
+		s = m.sessionView() + "Elapsed: " + s
+		s += m.lapsView()
+		s += m.statusView()
 		s += m.helpView()
 	}
 	return s
 }
 
+// elapsed is the time on the stopwatch, counting from the start of the
+// session.
+func (m model) elapsed() time.Duration {
+	return m.base + m.stopwatch.Elapsed()
+}
+
+func (m model) sessionView() string {
+	name := m.session.Name
+	if name == "" {
+		name = dimStyle.Render("unnamed")
+	}
+	if m.unsaved {
+		name += dimStyle.Render(" (unsaved)")
+	}
+	return "Session: " + name + "\n"
+}
+
+// lapsView shows the laps newest first, with the best and worst laps
+// highlighted.
+func (m model) lapsView() string {
+	n := len(m.session.Splits)
+	if n == 0 {
+		return ""
+	}
+	best, worst := m.session.bestWorst()
+
+	var b strings.Builder
+	b.WriteString("\n" + headerStyle.Render(fmt.Sprintf("%4s  %-12s  %-12s", "#", "Lap", "Split")) + "\n")
+	end := min(n, m.offset+lapRows)
+	for row := m.offset; row < end; row++ {
+		i := n - 1 - row
+		lap, split := m.session.lap(i)
+		line := fmt.Sprintf("%4d  %-12s  %-12s", i+1, formatDuration(lap), formatDuration(split))
+		switch i {
+		case best:
+			line = bestStyle.Render(line + "  best")
+		case worst:
+			line = worstStyle.Render(line + "  worst")
+		}
+		b.WriteString(line + "\n")
+	}
+	if n > lapRows {
+		b.WriteString(dimStyle.Render(fmt.Sprintf("      %d-%d of %d laps", m.offset+1, end, n)) + "\n")
+	}
+	return b.String()
+}
+
+func (m model) statusView() string {
+	switch {
+	case m.naming:
+		return "\n" + m.input.View() + "\n"
+	case m.err != nil:
+		return "\n" + errorStyle.Render(m.err.Error()) + "\n"
+	case m.status != "":
+		return "\n" + dimStyle.Render(m.status) + "\n"
+	}
+	return ""
+}
+
 func (m model) helpView() string {
+	if m.naming {
+		return "\n" + m.help.ShortHelpView([]key.Binding{
+			key.NewBinding(key.WithHelp("enter", "ok")),
+			key.NewBinding(key.WithHelp("esc", "cancel")),
+		})
+	}
 	return "\n" + m.help.ShortHelpView([]key.Binding{
 		m.keymap.start,
 		m.keymap.stop,
+		m.keymap.lap,
 		m.keymap.reset,
+		m.keymap.name,
+		m.keymap.save,
+		m.keymap.export,
 		m.keymap.quit,
 	})
 }
@@ -53,16 +158,46 @@ func (m model) helpView() string {
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if m.naming {
+			return m.updateName(msg)
+		}
+		m.status, m.err = "", nil
+		replace := m.replace
+		m.replace = ""
 		switch {
 		case key.Matches(msg, m.keymap.quit):
 			m.quitting = true
 			return m, tea.Quit
 		case key.Matches(msg, m.keymap.reset):
+			m.session.Splits = nil
+			m.offset, m.base, m.unsaved = 0, 0, false
 			return m, m.stopwatch.Reset()
 		case key.Matches(msg, m.keymap.start, m.keymap.stop):
 			m.keymap.stop.SetEnabled(!m.stopwatch.Running())
 			m.keymap.start.SetEnabled(m.stopwatch.Running())
+			m.keymap.lap.SetEnabled(!m.stopwatch.Running())
 			return m, m.stopwatch.Toggle()
+		case key.Matches(msg, m.keymap.lap):
+			m.session.Splits = append(m.session.Splits, duration(m.elapsed()))
+			m.offset, m.unsaved = 0, true
+			return m, nil
+		case key.Matches(msg, m.keymap.up):
+			m.offset = max(0, m.offset-1)
+			return m, nil
+		case key.Matches(msg, m.keymap.down):
+			m.offset = max(0, min(m.offset+1, len(m.session.Splits)-lapRows))
+			return m, nil
+		case key.Matches(msg, m.keymap.name):
+			return m, m.startNaming(false)
+		case key.Matches(msg, m.keymap.save):
+			if m.session.Name == "" {
+				return m, m.startNaming(true)
+			}
+			m.save(replace == m.session.Name)
+			return m, nil
+		case key.Matches(msg, m.keymap.export):
+			m.export()
+			return m, nil
 		}
 	}
 	var cmd tea.Cmd
@@ -70,7 +205,91 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
-func main() {
+func (m *model) startNaming(thenSave bool) tea.Cmd {
+	m.naming, m.saveNext = true, thenSave
+	m.input.SetValue(m.session.Name)
+	m.input.CursorEnd()
+	return m.input.Focus()
+}
+
+func (m model) updateName(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		m.quitting = true
+		return m, tea.Quit
+	case "esc":
+		m.naming = false
+		m.input.Blur()
+		return m, nil
+	case "enter":
+		name := strings.TrimSpace(m.input.Value())
+		if name == "" {
+			return m, nil
+		}
+		m.naming = false
+		m.input.Blur()
+		if name != m.session.Name {
+			m.session.Name, m.unsaved = name, true
+		}
+		if m.saveNext {
+			m.save(false)
+		}
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+// save writes the session to the file. If another session is already saved
+// under its name, it's only replaced when the user has been asked.
+func (m *model) save(replace bool) {
+	s := m.session
+	if s.Name != m.stored && !replace {
+		if _, err := findSession(m.file, s.Name); err == nil {
+			m.replace = s.Name
+			m.status = fmt.Sprintf("%q is already saved in %s, press w again to replace it", s.Name, m.file)
+			return
+		}
+	}
+	s.Saved = time.Now()
+	if err := saveSession(m.file, s); err != nil {
+		m.err = err
+		return
+	}
+	m.session, m.stored, m.unsaved = s, s.Name, false
+	m.status = fmt.Sprintf("Saved %q to %s", s.Name, m.file)
+}
+
+func (m *model) export() {
+	if len(m.session.Splits) == 0 {
+		m.status = "No laps to export yet"
+		return
+	}
+	path := csvName(m.session.Name)
+	f, err := os.Create(path)
+	if err != nil {
+		m.err = err
+		return
+	}
+	err = exportCSV(f, m.session)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		m.err = err
+		return
+	}
+	m.status = "Exported laps to " + path
+}
+
+// newModel starts a session. A session that was loaded from the file carries
+// on from its last lap.
+func newModel(file string, s session) model {
+	input := textinput.New()
+	input.Prompt = "Session name: "
+	input.CharLimit = 64
+
 	m := model{
 		stopwatch: stopwatch.NewWithInterval(time.Millisecond),
 		keymap: keymap{
@@ -82,21 +301,81 @@ func main() {
 				key.WithKeys("s"),
 				key.WithHelp("s", "stop"),
 			),
+			lap: key.NewBinding(
+				key.WithKeys("l", " "),
+				key.WithHelp("l", "lap"),
+			),
 			reset: key.NewBinding(
 				key.WithKeys("r"),
 				key.WithHelp("r", "reset"),
 			),
+			name: key.NewBinding(
+				key.WithKeys("n"),
+				key.WithHelp("n", "name"),
+			),
+			save: key.NewBinding(
+				key.WithKeys("w"),
+				key.WithHelp("w", "save"),
+			),
+			export: key.NewBinding(
+				key.WithKeys("e"),
+				key.WithHelp("e", "export csv"),
+			),
+			up: key.NewBinding(
+				key.WithKeys("up", "k"),
+			),
+			down: key.NewBinding(
+				key.WithKeys("down", "j"),
+			),
 			quit: key.NewBinding(
 				key.WithKeys("ctrl+c", "q"),
 				key.WithHelp("q", "quit"),
 			),
 		},
-		help: help.New(),
+		help:    help.New(),
+		input:   input,
+		file:    file,
+		session: s,
+	}
+	if !s.Saved.IsZero() {
+		m.stored, m.base = s.Name, s.elapsed()
 	}
 
 	m.keymap.start.SetEnabled(false)
+	return m
+}
+
+func main() {
+	file := flag.String("file", "stopwatch-sessions.json", "file to save sessions in")
+	name := flag.String("name", "", "name of the session, which is loaded if it's been saved")
+	export := flag.String("export", "", "write the laps of a saved session as CSV and exit")
+	flag.Parse()
+
+	if *export != "" {
+		s, err := findSession(*file, *export)
+		if err == nil {
+			err = exportCSV(os.Stdout, s)
+		}
+		if err != nil {
+			fmt.Println("Couldn't export session:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	s := session{Name: *name}
+	if *name != "" {
+		saved, err := findSession(*file, *name)
+		switch {
+		case err == nil:
+			s = saved
+		case !errors.Is(err, errNoSession):
+			fmt.Println("Couldn't load session:", err)
+			os.Exit(1)
+		}
+	}
 
-	if _, err := tea.NewProgram(m).Run(); err != nil {
+	if _, err := tea.NewProgram(newModel(*file, s)).Run(); err != nil {
 		fmt.Println("Oh no, it didn't work:", err)
 		os.Exit(1)
 	}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// duration is a time.Duration that reads and writes as a string like
+// "1m2.5s" in JSON, so the sessions file is easy to read.
+type duration time.Duration
+
+func (d duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = duration(v)
+	return nil
+}
+
+// session is a named set of laps. Only the splits, the time on the
+// stopwatch when each lap was recorded, are stored. Lap times follow from
+// them.
+type session struct {
+	Name   string     `json:"name"`
+	Saved  time.Time  `json:"saved"`
+	Splits []duration `json:"splits"`
+}
+
+// elapsed is the time on the stopwatch at the end of the last lap.
+func (s session) elapsed() time.Duration {
+	if len(s.Splits) == 0 {
+		return 0
+	}
+	return time.Duration(s.Splits[len(s.Splits)-1])
+}
+
+// lap returns the time of lap i and the split at its end.
+func (s session) lap(i int) (lap, split time.Duration) {
+	split = time.Duration(s.Splits[i])
+	if i == 0 {
+		return split, split
+	}
+	return split - time.Duration(s.Splits[i-1]), split
+}
+
+// bestWorst returns the indexes of the fastest and slowest laps, or -1 if
+// there aren't enough laps to compare.
+func (s session) bestWorst() (best, worst int) {
+	if len(s.Splits) < 2 {
+		return -1, -1
+	}
+	for i := range s.Splits {
+		lap, _ := s.lap(i)
+		if b, _ := s.lap(best); lap < b {
+			best = i
+		}
+		if w, _ := s.lap(worst); lap > w {
+			worst = i
+		}
+	}
+	return best, worst
+}
+
+// loadSessions reads the sessions file. A missing file has no sessions.
+func loadSessions(path string) ([]session, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var sessions []session
+	if err := json.Unmarshal(b, &sessions); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return sessions, nil
+}
+
+// saveSession adds s to the sessions file, replacing a session with the same
+// name.
+func saveSession(path string, s session) error {
+	sessions, err := loadSessions(path)
+	if err != nil {
+		return err
+	}
+	i := slices.IndexFunc(sessions, func(o session) bool { return o.Name == s.Name })
+	if i >= 0 {
+		sessions[i] = s
+	} else {
+		sessions = append(sessions, s)
+	}
+	b, err := json.MarshalIndent(sessions, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so a crash can't cost us the
+	// sessions we already have.
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".sessions-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) //nolint:errcheck
+	if _, err := tmp.Write(append(b, '\n')); err != nil {
+		tmp.Close() //nolint:errcheck,gosec
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// errNoSession is returned by findSession when there's no session by that
+// name.
+var errNoSession = errors.New("no such session")
+
+// findSession returns the session with the given name from the sessions
+// file.
+func findSession(path, name string) (session, error) {
+	sessions, err := loadSessions(path)
+	if err != nil {
+		return session{}, err
+	}
+	for _, s := range sessions {
+		if s.Name == name {
+			return s, nil
+		}
+	}
+	return session{}, fmt.Errorf("%w: %q in %s", errNoSession, name, path)
+}
+
+// exportCSV writes the laps as CSV, with times in seconds so spreadsheets
+// can work with them.
+func exportCSV(w io.Writer, s session) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"lap", "lap_seconds", "split_seconds"})
+	for i := range s.Splits {
+		lap, split := s.lap(i)
+		_ = cw.Write([]string{
+			fmt.Sprint(i + 1),
+			fmt.Sprintf("%.3f", lap.Seconds()),
+			fmt.Sprintf("%.3f", split.Seconds()),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvName is the file a session is exported to, named after the session.
+func csvName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == ':' || r < ' ' {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(name))
+	if name == "" {
+		name = "laps"
+	}
+	return name + ".csv"
+}
+
+// formatDuration shows a duration like a stopwatch: 01:02.345, with hours
+// when there are any.
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Millisecond)
+	h := d / time.Hour
+	m := d % time.Hour / time.Minute
+	s := d % time.Minute / time.Second
+	ms := d % time.Second / time.Millisecond
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d.%03d", h, m, s, ms)
+	}
+	return fmt.Sprintf("%02d:%02d.%03d", m, s, ms)
+}
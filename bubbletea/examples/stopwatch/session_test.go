@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func splits(ds ...time.Duration) []duration {
+	out := make([]duration, len(ds))
+	for i, d := range ds {
+		out[i] = duration(d)
+	}
+	return out
+}
+
+func TestLaps(t *testing.T) {
+	s := session{Splits: splits(3*time.Second, 5*time.Second, 9*time.Second, 12*time.Second)}
+	if lap, split := s.lap(2); lap != 4*time.Second || split != 9*time.Second {
+		t.Errorf("lap 3 = %v, %v, want 4s, 9s", lap, split)
+	}
+	// Laps are 3s, 2s, 4s and 3s.
+	if best, worst := s.bestWorst(); best != 1 || worst != 2 {
+		t.Errorf("best, worst = %d, %d, want 1, 2", best, worst)
+	}
+	if best, worst := (session{Splits: splits(time.Second)}).bestWorst(); best != -1 || worst != -1 {
+		t.Errorf("one lap has best %d, worst %d, want none", best, worst)
+	}
+}
+
+func TestSaveSession(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.json")
+	if sessions, err := loadSessions(path); err != nil || len(sessions) != 0 {
+		t.Fatalf("loading a missing file = %v, %v", sessions, err)
+	}
+
+	a := session{Name: "login tests", Splits: splits(1500 * time.Millisecond)}
+	b := session{Name: "checkout", Splits: splits(time.Minute)}
+	for _, s := range []session{a, b} {
+		if err := saveSession(path, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Saving again under the same name replaces the session.
+	a.Splits = splits(1500*time.Millisecond, 2*time.Second)
+	if err := saveSession(path, a); err != nil {
+		t.Fatal(err)
+	}
+
+	sessions, err := loadSessions(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("got %d sessions, want 2", len(sessions))
+	}
+	got, err := findSession(path, "login tests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Splits) != 2 || got.Splits[1] != duration(2*time.Second) {
+		t.Errorf("login tests has splits %v", got.Splits)
+	}
+	if _, err := findSession(path, "nope"); !errors.Is(err, errNoSession) {
+		t.Errorf("finding a session that was never saved: %v", err)
+	}
+}
+
+func TestLoadAndReplace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.json")
+	if err := saveSession(path, session{Name: "run", Saved: time.Now(), Splits: splits(time.Minute, 3*time.Minute)}); err != nil {
+		t.Fatal(err)
+	}
+	press := func(m model, key string) model {
+		next, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)})
+		return next.(model)
+	}
+
+	// A saved session carries on from its last lap.
+	s, err := findSession(path, "run")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := newModel(path, s)
+	if m.elapsed() != 3*time.Minute || !strings.Contains(m.View(), "02:00.000     03:00.000") {
+		t.Errorf("loaded session at %v:\n%s", m.elapsed(), m.View())
+	}
+	m = press(m, "l")
+	if m = press(m, "w"); m.unsaved {
+		t.Fatalf("didn't save the loaded session: %q", m.status)
+	}
+
+	// A new session with the same name asks before replacing it.
+	m = newModel(path, session{Name: "run"})
+	m = press(m, "l")
+	m = press(m, "w")
+	if !m.unsaved || !strings.Contains(m.status, "press w again") {
+		t.Fatalf("replaced without asking: %q", m.status)
+	}
+	if s, _ := findSession(path, "run"); len(s.Splits) != 3 {
+		t.Fatalf("saved session has %d laps", len(s.Splits))
+	}
+	m = press(m, "w")
+	if s, _ := findSession(path, "run"); m.unsaved || len(s.Splits) != 1 {
+		t.Errorf("after asking, saved %d laps: %q", len(s.Splits), m.status)
+	}
+}
+
+func TestExportCSV(t *testing.T) {
+	var buf bytes.Buffer
+	s := session{Splits: splits(1500*time.Millisecond, 4*time.Second)}
+	if err := exportCSV(&buf, s); err != nil {
+		t.Fatal(err)
+	}
+	want := "lap,lap_seconds,split_seconds\n1,1.500,1.500\n2,2.500,4.000\n"
+	if buf.String() != want {
+		t.Errorf("csv =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := map[time.Duration]string{
+		1234 * time.Millisecond:                   "00:01.234",
+		61*time.Second + 5*time.Millisecond:       "01:01.005",
+		time.Hour + 2*time.Minute + 3*time.Second: "1:02:03.000",
+	}
+	for d, want := range tests {
+		if got := formatDuration(d); got != want {
+			t.Errorf("formatDuration(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
@@ -1,169 +1,182 @@
 package main
 
+// A data browser built on the table component from the Bubbles component
+// library. It reads CSV, TSV, JSON, JSON lines or a SQLite query, a page at
+// a time, and lets you sort, filter and export what you're looking at.
+//
+// Usage:
+//
+//	table [file.csv|file.tsv|file.json|file.jsonl]
+//	table -query 'SELECT * FROM users' file.db
+
 import (
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
 
-var baseStyle = lipgloss.NewStyle().
-	BorderStyle(lipgloss.NormalBorder()).
-	BorderForeground(lipgloss.Color("240"))
+var (
+	baseStyle = lipgloss.NewStyle().
+			BorderStyle(lipgloss.NormalBorder()).
+			BorderForeground(lipgloss.Color("240"))
 
-type model struct {
-	table table.Model
+	statusStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	errorStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+	labelStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("212")).Bold(true)
+)
+
+type keyMap struct {
+	Left   key.Binding
+	Right  key.Binding
+	Sort   key.Binding
+	Filter key.Binding
+	Next   key.Binding
+	Prev   key.Binding
+	Detail key.Binding
+	Export key.Binding
+	Quit   key.Binding
 }
 
-func (m model) Init() tea.Cmd { return nil }
+func (k keyMap) ShortHelp() []key.Binding {
+	// Right and Next share their help with Left and Prev.
+	return []key.Binding{k.Left, k.Sort, k.Filter, k.Prev, k.Detail, k.Export, k.Quit}
+}
 
-func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	var cmd tea.Cmd
-	switch msg := msg.(type) {
-	case tea.KeyMsg:
-		switch msg.String() {
-		case "esc":
-			if m.table.Focused() {
-				m.table.Blur()
-			} else {
-				m.table.Focus()
-			}
-		case "q", "ctrl+c":
-			return m, tea.Quit
-		case "enter":
-			return m, tea.Batch(
-				tea.Printf("Let's go to %s!", m.table.SelectedRow()[1]),
-			)
+func (k keyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{k.ShortHelp()}
+}
+
+var keys = keyMap{
+	Left: key.NewBinding(
+		key.WithKeys("left", "h"),
+		key.WithHelp("←/→", "column"),
+	),
+	Right: key.NewBinding(
+		key.WithKeys("right", "l"),
+	),
+	Sort: key.NewBinding(
+		key.WithKeys("s"),
+		key.WithHelp("s", "sort"),
+	),
+	Filter: key.NewBinding(
+		key.WithKeys("/"),
+		key.WithHelp("/", "filter"),
+	),
+	Prev: key.NewBinding(
+		key.WithKeys("["),
+		key.WithHelp("[/]", "page"),
+	),
+	Next: key.NewBinding(
+		key.WithKeys("]"),
+	),
+	Detail: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "details"),
+	),
+	Export: key.NewBinding(
+		key.WithKeys("e"),
+		key.WithHelp("e", "export"),
+	),
+	Quit: key.NewBinding(
+		key.WithKeys("q", "ctrl+c"),
+		key.WithHelp("q", "quit"),
+	),
+}
+
+type (
+	rowsMsg struct {
+		columns []string
+		rows    [][]string
+		eof     bool
+	}
+	readErrMsg  struct{ err error }
+	exportedMsg struct {
+		path string
+		rows int
+		err  error
+	}
+)
+
+// readRows reads the next n rows from the source.
+func readRows(src source, n int) tea.Cmd {
+	return func() tea.Msg {
+		rows, err := src.Read(n)
+		msg := rowsMsg{
+			// The source may still add columns while we're looking at
+			// these, so take a copy.
+			columns: append([]string(nil), src.Columns()...),
+			rows:    rows,
+		}
+		switch {
+		case errors.Is(err, io.EOF):
+			msg.eof = true
+		case err != nil:
+			return readErrMsg{err}
 		}
+		return msg
 	}
-	m.table, cmd = m.table.Update(msg)
-	return m, cmd
 }
 
-func (m model) View() string {
-	return baseStyle.Render(m.table.View()) + "\n"
+func export(path string, columns []string, rows [][]string) tea.Cmd {
+	return func() tea.Msg {
+		err := exportCSV(path, columns, rows)
+		return exportedMsg{path: path, rows: len(rows), err: err}
+	}
 }
 
-func main() {
-	columns := []table.Column{
-		{Title: "Rank", Width: 4},
-		{Title: "City", Width: 10},
-		{Title: "Country", Width: 10},
-		{Title: "Population", Width: 10},
-	}
-
-	rows := []table.Row{
-		{"1", "Tokyo", "Japan", "37,274,000"},
-		{"2", "Delhi", "India", "32,065,760"},
-		{"3", "Shanghai", "China", "28,516,904"},
-		{"4", "Dhaka", "Bangladesh", "22,478,116"},
-		{"5", "São Paulo", "Brazil", "22,429,800"},
-		{"6", "Mexico City", "Mexico", "22,085,140"},
-		{"7", "Cairo", "Egypt", "21,750,020"},
-		{"8", "Beijing", "China", "21,333,332"},
-		{"9", "Mumbai", "India", "20,961,472"},
-		{"10", "Osaka", "Japan", "19,059,856"},
-		{"11", "Chongqing", "China", "16,874,740"},
-		{"12", "Karachi", "Pakistan", "16,839,950"},
-		{"13", "Istanbul", "Turkey", "15,636,243"},
-		{"14", "Kinshasa", "DR Congo", "15,628,085"},
-		{"15", "Lagos", "Nigeria", "15,387,639"},
-		{"16", "Buenos Aires", "Argentina", "15,369,919"},
-		{"17", "Kolkata", "India", "15,133,888"},
-		{"18", "Manila", "Philippines", "14,406,059"},
-		{"19", "Tianjin", "China", "14,011,828"},
-		{"20", "Guangzhou", "China", "13,964,637"},
-		{"21", "Rio De Janeiro", "Brazil", "13,634,274"},
-		{"22", "Lahore", "Pakistan", "13,541,764"},
-		{"23", "Bangalore", "India", "13,193,035"},
-		{"24", "Shenzhen", "China", "12,831,330"},
-		{"25", "Moscow", "Russia", "12,640,818"},
-		{"26", "Chennai", "India", "11,503,293"},
-		{"27", "Bogota", "Colombia", "11,344,312"},
-		{"28", "Paris", "France", "11,142,303"},
-		{"29", "Jakarta", "Indonesia", "11,074,811"},
-		{"30", "Lima", "Peru", "11,044,607"},
-		{"31", "Bangkok", "Thailand", "10,899,698"},
-		{"32", "Hyderabad", "India", "10,534,418"},
-		{"33", "Seoul", "South Korea", "9,975,709"},
-		{"34", "Nagoya", "Japan", "9,571,596"},
-		{"35", "London", "United Kingdom", "9,540,576"},
-		{"36", "Chengdu", "China", "9,478,521"},
-		{"37", "Nanjing", "China", "9,429,381"},
-		{"38", "Tehran", "Iran", "9,381,546"},
-		{"39", "Ho Chi Minh City", "Vietnam", "9,077,158"},
-		{"40", "Luanda", "Angola", "8,952,496"},
-		{"41", "Wuhan", "China", "8,591,611"},
-		{"42", "Xi An Shaanxi", "China", "8,537,646"},
-		{"43", "Ahmedabad", "India", "8,450,228"},
-		{"44", "Kuala Lumpur", "Malaysia", "8,419,566"},
-		{"45", "New York City", "United States", "8,177,020"},
-		{"46", "Hangzhou", "China", "8,044,878"},
-		{"47", "Surat", "India", "7,784,276"},
-		{"48", "Suzhou", "China", "7,764,499"},
-		{"49", "Hong Kong", "Hong Kong", "7,643,256"},
-		{"50", "Riyadh", "Saudi Arabia", "7,538,200"},
-		{"51", "Shenyang", "China", "7,527,975"},
-		{"52", "Baghdad", "Iraq", "7,511,920"},
-		{"53", "Dongguan", "China", "7,511,851"},
-		{"54", "Foshan", "China", "7,497,263"},
-		{"55", "Dar Es Salaam", "Tanzania", "7,404,689"},
-		{"56", "Pune", "India", "6,987,077"},
-		{"57", "Santiago", "Chile", "6,856,939"},
-		{"58", "Madrid", "Spain", "6,713,557"},
-		{"59", "Haerbin", "China", "6,665,951"},
-		{"60", "Toronto", "Canada", "6,312,974"},
-		{"61", "Belo Horizonte", "Brazil", "6,194,292"},
-		{"62", "Khartoum", "Sudan", "6,160,327"},
-		{"63", "Johannesburg", "South Africa", "6,065,354"},
-		{"64", "Singapore", "Singapore", "6,039,577"},
-		{"65", "Dalian", "China", "5,930,140"},
-		{"66", "Qingdao", "China", "5,865,232"},
-		{"67", "Zhengzhou", "China", "5,690,312"},
-		{"68", "Ji Nan Shandong", "China", "5,663,015"},
-		{"69", "Barcelona", "Spain", "5,658,472"},
-		{"70", "Saint Petersburg", "Russia", "5,535,556"},
-		{"71", "Abidjan", "Ivory Coast", "5,515,790"},
-		{"72", "Yangon", "Myanmar", "5,514,454"},
-		{"73", "Fukuoka", "Japan", "5,502,591"},
-		{"74", "Alexandria", "Egypt", "5,483,605"},
-		{"75", "Guadalajara", "Mexico", "5,339,583"},
-		{"76", "Ankara", "Turkey", "5,309,690"},
-		{"77", "Chittagong", "Bangladesh", "5,252,842"},
-		{"78", "Addis Ababa", "Ethiopia", "5,227,794"},
-		{"79", "Melbourne", "Australia", "5,150,766"},
-		{"80", "Nairobi", "Kenya", "5,118,844"},
-		{"81", "Hanoi", "Vietnam", "5,067,352"},
-		{"82", "Sydney", "Australia", "5,056,571"},
-		{"83", "Monterrey", "Mexico", "5,036,535"},
-		{"84", "Changsha", "China", "4,809,887"},
-		{"85", "Brasilia", "Brazil", "4,803,877"},
-		{"86", "Cape Town", "South Africa", "4,800,954"},
-		{"87", "Jiddah", "Saudi Arabia", "4,780,740"},
-		{"88", "Urumqi", "China", "4,710,203"},
-		{"89", "Kunming", "China", "4,657,381"},
-		{"90", "Changchun", "China", "4,616,002"},
-		{"91", "Hefei", "China", "4,496,456"},
-		{"92", "Shantou", "China", "4,490,411"},
-		{"93", "Xinbei", "Taiwan", "4,470,672"},
-		{"94", "Kabul", "Afghanistan", "4,457,882"},
-		{"95", "Ningbo", "China", "4,405,292"},
-		{"96", "Tel Aviv", "Israel", "4,343,584"},
-		{"97", "Yaounde", "Cameroon", "4,336,670"},
-		{"98", "Rome", "Italy", "4,297,877"},
-		{"99", "Shijiazhuang", "China", "4,285,135"},
-		{"100", "Montreal", "Canada", "4,276,526"},
-	}
-
-	t := table.New(
-		table.WithColumns(columns),
-		table.WithRows(rows),
-		table.WithFocused(true),
-		table.WithHeight(7),
-	)
+type model struct {
+	name     string
+	src      source
+	columns  []string
+	widths   []int
+	rows     [][]string // everything we've read so far, in order
+	loading  bool
+	eof      bool
+	pageSize int
 
+	// wantAll is set once we need every row, to sort, filter or export.
+	wantAll bool
+
+	view  [][]string // rows after filtering and sorting
+	page  int
+	query string
+
+	filter  filter
+	sortCol int
+	order   sortOrder
+
+	// selected is the column sort acts on. first is the leftmost column
+	// on screen, for when they don't all fit.
+	selected, first int
+
+	// prompt is '/' while editing the filter, 'e' while asking where to
+	// export to, and 0 otherwise.
+	prompt rune
+	input  textinput.Model
+	// exportPath is an export waiting for the rest of the rows to load.
+	exportPath string
+
+	detail bool
+	table  table.Model
+	help   help.Model
+	width  int
+	height int
+	status string
+	err    error
+}
+
+func newModel(name string, src source, pageSize int) model {
+	t := table.New(table.WithFocused(true))
 	s := table.DefaultStyles()
 	s.Header = s.Header.
 		BorderStyle(lipgloss.NormalBorder()).
@@ -176,8 +189,391 @@ func main() {
 		Bold(false)
 	t.SetStyles(s)
 
-	m := model{t}
-	if _, err := tea.NewProgram(m).Run(); err != nil {
+	ti := textinput.New()
+	ti.Prompt = ""
+
+	return model{
+		name:     name,
+		src:      src,
+		pageSize: pageSize,
+		table:    t,
+		input:    ti,
+		help:     help.New(),
+	}
+}
+
+func (m model) Init() tea.Cmd {
+	return m.loadMore()
+}
+
+// loadMore reads another page of rows, unless we're already reading or
+// there's nothing left.
+func (m *model) loadMore() tea.Cmd {
+	if m.loading || m.eof {
+		return nil
+	}
+	m.loading = true
+	return readRows(m.src, m.pageSize)
+}
+
+// needMore reports whether we should keep reading: either we need all of it
+// or we haven't read enough to fill the current page yet.
+func (m model) needMore() bool {
+	return !m.eof && (m.wantAll || len(m.rows) < (m.page+1)*m.pageSize)
+}
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.help.Width = msg.Width
+		m.input.Width = msg.Width / 2
+		m.refresh()
+		return m, nil
+
+	case rowsMsg:
+		m.loading = false
+		m.columns = msg.columns
+		m.rows = append(m.rows, msg.rows...)
+		m.widths = fitWidths(m.widths, m.columns, msg.rows)
+		if msg.eof {
+			m.eof = true
+			_ = m.src.Close()
+		}
+
+		var cmd tea.Cmd
+		if m.needMore() {
+			cmd = m.loadMore()
+		}
+		// Filtering and sorting every batch would be slow for big files, so
+		// wait until we've got everything, even if nothing's showing yet.
+		if m.wantAll && !m.eof {
+			return m, cmd
+		}
+		m.refresh()
+		if m.eof && m.exportPath != "" {
+			path := m.exportPath
+			m.exportPath = ""
+			return m, tea.Batch(cmd, export(path, m.columns, m.view))
+		}
+		return m, cmd
+
+	case readErrMsg:
+		m.loading = false
+		m.eof = true
+		m.err = msg.err
+		_ = m.src.Close()
+		m.refresh()
+		return m, nil
+
+	case exportedMsg:
+		if msg.err != nil {
+			m.err = msg.err
+		} else {
+			m.status = fmt.Sprintf("exported %d rows to %s", msg.rows, msg.path)
+		}
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.prompt != 0 {
+			return m.updatePrompt(msg)
+		}
+		if m.detail {
+			switch msg.String() {
+			case "ctrl+c":
+				return m, tea.Quit
+			case "esc", "enter", "q":
+				m.detail = false
+			}
+			return m, nil
+		}
+		m.status, m.err = "", nil
+
+		switch {
+		case key.Matches(msg, keys.Quit):
+			return m, tea.Quit
+		case msg.String() == "esc":
+			if m.query != "" {
+				m.setFilter("")
+				return m, nil
+			}
+			return m, tea.Quit
+		case key.Matches(msg, keys.Left):
+			m.selected = max(m.selected-1, 0)
+			m.refresh()
+			return m, nil
+		case key.Matches(msg, keys.Right):
+			m.selected = min(m.selected+1, len(m.columns)-1)
+			m.refresh()
+			return m, nil
+		case key.Matches(msg, keys.Sort):
+			if m.sortCol == m.selected {
+				m.order = m.order.next()
+			} else {
+				m.sortCol, m.order = m.selected, ascending
+			}
+			m.page = 0
+			return m, m.needAll()
+		case key.Matches(msg, keys.Filter):
+			m.prompt = '/'
+			m.input.SetValue(m.query)
+			m.input.CursorEnd()
+			return m, m.input.Focus()
+		case key.Matches(msg, keys.Export):
+			m.prompt = 'e'
+			m.input.SetValue("export.csv")
+			m.input.CursorEnd()
+			return m, m.input.Focus()
+		case key.Matches(msg, keys.Next):
+			if !m.eof || (m.page+1)*m.pageSize < len(m.view) {
+				m.page++
+				m.table.GotoTop()
+				m.refresh()
+				if m.needMore() {
+					return m, m.loadMore()
+				}
+			}
+			return m, nil
+		case key.Matches(msg, keys.Prev):
+			if m.page > 0 {
+				m.page--
+				m.table.GotoTop()
+				m.refresh()
+			}
+			return m, nil
+		case key.Matches(msg, keys.Detail):
+			m.detail = m.table.SelectedRow() != nil
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.table, cmd = m.table.Update(msg)
+	return m, cmd
+}
+
+// updatePrompt handles keys while we're editing the filter or the export
+// path.
+func (m model) updatePrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc, tea.KeyCtrlC:
+		m.prompt = 0
+		m.input.Blur()
+		return m, nil
+	case tea.KeyEnter:
+		prompt, value := m.prompt, strings.TrimSpace(m.input.Value())
+		m.prompt = 0
+		m.input.Blur()
+		if prompt == '/' {
+			return m, m.setFilter(value)
+		}
+		if value == "" {
+			return m, nil
+		}
+		if !m.eof {
+			// Export everything that matches, not just what we've read.
+			m.exportPath = value
+			m.status = "loading all rows to export…"
+			return m, m.needAll()
+		}
+		return m, export(value, m.columns, m.view)
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+func (m *model) setFilter(query string) tea.Cmd {
+	m.query = query
+	m.filter = parseFilter(query, m.columns)
+	m.page = 0
+	m.table.GotoTop()
+	if query == "" {
+		m.refresh()
+		return nil
+	}
+	return m.needAll()
+}
+
+// needAll starts reading the rest of the rows, since sorting and filtering
+// only make sense once we have them all.
+func (m *model) needAll() tea.Cmd {
+	if m.eof {
+		m.refresh()
+		return nil
+	}
+	m.wantAll = true
+	return m.loadMore()
+}
+
+// visibleColumns returns the range of columns that fit on screen, making
+// sure the selected one is among them.
+func (m *model) visibleColumns() (int, int) {
+	avail := m.width - 2 // the border
+	fits := func(first int) int {
+		last, used := first, 0
+		for last < len(m.widths) {
+			w := m.widths[last] + 2 // cell padding
+			if used+w > avail && last > first {
+				break
+			}
+			used += w
+			last++
+		}
+		return last
+	}
+
+	m.first = min(m.first, m.selected)
+	for m.first < m.selected && fits(m.first) <= m.selected {
+		m.first++
+	}
+	return m.first, fits(m.first)
+}
+
+// refresh filters and sorts the rows and puts the current page in the
+// table.
+func (m *model) refresh() {
+	if m.width == 0 || len(m.columns) == 0 {
+		return
+	}
+	m.filter = parseFilter(m.query, m.columns)
+	m.view = applyView(m.rows, m.filter, m.sortCol, m.order)
+
+	pages := max(1, (len(m.view)+m.pageSize-1)/m.pageSize)
+	if m.eof {
+		m.page = min(m.page, pages-1)
+	}
+
+	first, last := m.visibleColumns()
+	cols := make([]table.Column, 0, last-first)
+	for i := first; i < last; i++ {
+		title := m.columns[i]
+		if i == m.sortCol && m.order != unsorted {
+			title += " " + m.order.String()
+		}
+		if i == m.selected {
+			title = "›" + title
+		}
+		cols = append(cols, table.Column{Title: title, Width: m.widths[i]})
+	}
+
+	start := min(m.page*m.pageSize, len(m.view))
+	end := min(start+m.pageSize, len(m.view))
+	rows := make([]table.Row, 0, end-start)
+	for _, r := range m.view[start:end] {
+		r = fit(r, len(m.columns))
+		rows = append(rows, table.Row(r[first:last]))
+	}
+
+	// Swap the rows out first so the table never renders rows with the
+	// wrong number of columns.
+	m.table.SetRows(nil)
+	m.table.SetColumns(cols)
+	m.table.SetRows(rows)
+	m.table.SetHeight(max(3, m.height-4)) // border, status and help
+	m.table.SetCursor(min(m.table.Cursor(), max(len(rows)-1, 0)))
+}
+
+func (m model) View() string {
+	if m.width == 0 {
+		return ""
+	}
+	if m.detail {
+		return m.detailView()
+	}
+	return baseStyle.Render(m.table.View()) + "\n" + m.statusView() + "\n" + m.footerView()
+}
+
+func (m model) statusView() string {
+	if m.err != nil {
+		return errorStyle.Render("Error: " + m.err.Error())
+	}
+
+	parts := []string{m.name}
+	total := fmt.Sprint(len(m.view))
+	pages := fmt.Sprint(max(1, (len(m.view)+m.pageSize-1)/m.pageSize))
+	if !m.eof {
+		total += "+"
+		pages += "+"
+	}
+	if len(m.view) > 0 {
+		start := min(m.page*m.pageSize, len(m.view))
+		end := min(start+m.pageSize, len(m.view))
+		parts = append(parts, fmt.Sprintf("rows %d–%d of %s", start+1, end, total))
+	} else if m.eof {
+		parts = append(parts, "no rows")
+	}
+	parts = append(parts, fmt.Sprintf("page %d/%s", m.page+1, pages))
+	if m.query != "" {
+		parts = append(parts, "filter: "+m.query)
+	}
+	if m.loading {
+		parts = append(parts, fmt.Sprintf("loading… %d read", len(m.rows)))
+	}
+	if m.status != "" {
+		parts = append(parts, m.status)
+	}
+	return statusStyle.Render(strings.Join(parts, " · "))
+}
+
+func (m model) footerView() string {
+	switch m.prompt {
+	case '/':
+		return labelStyle.Render("Filter: ") + m.input.View()
+	case 'e':
+		return labelStyle.Render("Export to: ") + m.input.View()
+	}
+	return m.help.View(keys)
+}
+
+// detailView shows every column of the selected row, for values that don't
+// fit in the table.
+func (m model) detailView() string {
+	var row []string
+	start := min(m.page*m.pageSize, len(m.view))
+	if i := start + m.table.Cursor(); i < len(m.view) {
+		row = fit(m.view[i], len(m.columns))
+	}
+
+	width := 0
+	for _, c := range m.columns {
+		width = max(width, lipgloss.Width(c))
+	}
+	valueStyle := lipgloss.NewStyle().Width(max(10, m.width-width-6))
+
+	var b strings.Builder
+	for i, c := range m.columns {
+		label := labelStyle.Render(fmt.Sprintf("%*s", width, c))
+		b.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, label, "  ", valueStyle.Render(cell(row, i))))
+		b.WriteByte('\n')
+	}
+	return baseStyle.Padding(0, 1).Render(strings.TrimSuffix(b.String(), "\n")) + "\n" +
+		statusStyle.Render("esc back")
+}
+
+func main() {
+	query := flag.String("query", "", "SQL query to run against a SQLite database (default: the first table)")
+	pageSize := flag.Int("page", 1000, "rows per page")
+	flag.Parse()
+
+	path := "cities.csv"
+	if flag.NArg() > 0 {
+		path = flag.Arg(0)
+	}
+	if *pageSize < 1 {
+		fmt.Println("Error: -page must be at least 1")
+		os.Exit(1)
+	}
+
+	src, err := openSource(path, *query)
+	if err != nil {
+		fmt.Println("Error opening data:", err)
+		os.Exit(1)
+	}
+
+	m := newModel(path, src, *pageSize)
+	if _, err := tea.NewProgram(m, tea.WithAltScreen()).Run(); err != nil {
 		fmt.Println("Error running program:", err)
 		os.Exit(1)
 	}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"encoding/csv"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	minColumnWidth = 4
+	maxColumnWidth = 30
+)
+
+// filter is a parsed filter query. Plain terms match any column, and
+// column:term only matches that column. Every term has to match, and case
+// doesn't matter.
+type filter struct {
+	terms []filterTerm
+}
+
+type filterTerm struct {
+	column int // -1 for any column
+	text   string
+}
+
+func parseFilter(query string, columns []string) filter {
+	var f filter
+	for _, field := range strings.Fields(strings.ToLower(query)) {
+		term := filterTerm{column: -1, text: field}
+		if name, text, ok := strings.Cut(field, ":"); ok && text != "" {
+			for i, c := range columns {
+				if strings.ToLower(c) == name {
+					term = filterTerm{column: i, text: text}
+					break
+				}
+			}
+		}
+		f.terms = append(f.terms, term)
+	}
+	return f
+}
+
+func (f filter) match(row []string) bool {
+	for _, t := range f.terms {
+		if t.column >= 0 {
+			if t.column >= len(row) || !strings.Contains(strings.ToLower(row[t.column]), t.text) {
+				return false
+			}
+			continue
+		}
+		if !slices.ContainsFunc(row, func(cell string) bool {
+			return strings.Contains(strings.ToLower(cell), t.text)
+		}) {
+			return false
+		}
+	}
+	return true
+}
+
+// sortOrder is how a column is sorted. Pressing the sort key cycles through
+// them.
+type sortOrder int
+
+const (
+	unsorted sortOrder = iota
+	ascending
+	descending
+)
+
+func (o sortOrder) next() sortOrder { return (o + 1) % 3 }
+
+func (o sortOrder) String() string {
+	switch o {
+	case ascending:
+		return "▲"
+	case descending:
+		return "▼"
+	}
+	return ""
+}
+
+// applyView filters and sorts rows. It returns rows as they are when there's
+// nothing to do, and a new slice otherwise, though the rows themselves are
+// never copied.
+func applyView(rows [][]string, f filter, column int, order sortOrder) [][]string {
+	if len(f.terms) == 0 && order == unsorted {
+		return rows
+	}
+	view := make([][]string, 0, len(rows))
+	for _, row := range rows {
+		if f.match(row) {
+			view = append(view, row)
+		}
+	}
+	if order == unsorted {
+		return view
+	}
+	slices.SortStableFunc(view, func(a, b []string) int {
+		c := compareCells(cell(a, column), cell(b, column))
+		if order == descending {
+			return -c
+		}
+		return c
+	})
+	return view
+}
+
+func cell(row []string, i int) string {
+	if i < len(row) {
+		return row[i]
+	}
+	return ""
+}
+
+// compareCells compares numbers as numbers, so "9" sorts before "10" and
+// "1,000" counts as a thousand. Numbers sort before text, and empty cells go
+// last.
+func compareCells(a, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return 1
+	case b == "":
+		return -1
+	}
+	x, aNum := parseNumber(a)
+	y, bNum := parseNumber(b)
+	switch {
+	case aNum && bNum:
+		if x < y {
+			return -1
+		} else if x > y {
+			return 1
+		}
+		return 0
+	case aNum:
+		return -1
+	case bNum:
+		return 1
+	}
+	return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+}
+
+func parseNumber(s string) (float64, bool) {
+	f, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(s), ",", ""), 64)
+	return f, err == nil
+}
+
+// fitWidths grows widths so that each column fits its title and the widest
+// value in rows, within reason. We call it with each batch of rows as they're
+// read rather than measuring everything again.
+func fitWidths(widths []int, columns []string, rows [][]string) []int {
+	for i := len(widths); i < len(columns); i++ {
+		widths = append(widths, min(max(minColumnWidth, lipgloss.Width(columns[i])+3), maxColumnWidth)) // room for the marker and sort arrow
+	}
+	for _, row := range rows {
+		for i := range min(len(row), len(widths)) {
+			widths[i] = min(max(widths[i], lipgloss.Width(row[i])), maxColumnWidth)
+		}
+	}
+	return widths
+}
+
+// exportCSV writes the columns and rows to a CSV file at path.
+func exportCSV(path string, columns []string, rows [][]string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(f)
+	_ = w.Write(columns)
+	for _, row := range rows {
+		_ = w.Write(fit(row, len(columns)))
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
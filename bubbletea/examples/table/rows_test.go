@@ -0,0 +1,116 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var testRows = [][]string{
+	{"Tokyo", "Japan", "37,274,000"},
+	{"Delhi", "India", "32,065,760"},
+	{"Osaka", "Japan", "19,059,856"},
+	{"Pune", "India", "6,987,077"},
+	{"Atlantis", "", ""},
+}
+
+func names(rows [][]string) []string {
+	var s []string
+	for _, r := range rows {
+		s = append(s, r[0])
+	}
+	return s
+}
+
+func TestFilter(t *testing.T) {
+	columns := []string{"City", "Country", "Population"}
+	for query, want := range map[string][]string{
+		"":                    {"Tokyo", "Delhi", "Osaka", "Pune", "Atlantis"},
+		"japan":               {"Tokyo", "Osaka"},
+		"JAPAN o":             {"Tokyo", "Osaka"},
+		"india pu":            {"Pune"},
+		"country:india":       {"Delhi", "Pune"},
+		"city:india":          nil,
+		"nope:india":          nil, // not a column, so it's a plain term
+		"population:274":      {"Tokyo"},
+		"Country:ind city:de": {"Delhi"},
+	} {
+		got := names(applyView(testRows, parseFilter(query, columns), 0, unsorted))
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("filter %q = %q, want %q", query, got, want)
+		}
+	}
+}
+
+func TestSort(t *testing.T) {
+	tests := []struct {
+		column int
+		order  sortOrder
+		want   []string
+	}{
+		{0, ascending, []string{"Atlantis", "Delhi", "Osaka", "Pune", "Tokyo"}},
+		{0, descending, []string{"Tokyo", "Pune", "Osaka", "Delhi", "Atlantis"}},
+		// Numbers compare as numbers, and empty cells go last.
+		{2, ascending, []string{"Pune", "Osaka", "Delhi", "Tokyo", "Atlantis"}},
+		// Equal values keep their order.
+		{1, ascending, []string{"Delhi", "Pune", "Tokyo", "Osaka", "Atlantis"}},
+		{1, unsorted, []string{"Tokyo", "Delhi", "Osaka", "Pune", "Atlantis"}},
+	}
+	for _, tt := range tests {
+		got := names(applyView(testRows, filter{}, tt.column, tt.order))
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("sort column %d %v = %q, want %q", tt.column, tt.order, got, tt.want)
+		}
+	}
+	if testRows[0][0] != "Tokyo" {
+		t.Error("sorting changed the original rows")
+	}
+}
+
+func TestCompareCells(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"9", "10", -1},
+		{"1,000", "999", 1},
+		{"-2.5", "1e3", -1},
+		{"10", "apple", -1},
+		{"apple", "Banana", -1},
+		{"", "a", 1},
+		{"a", "", -1},
+		{"x", "x", 0},
+	}
+	for _, tt := range tests {
+		if got := compareCells(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareCells(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestFitWidths(t *testing.T) {
+	widths := fitWidths(nil, []string{"a", "Country"}, [][]string{{"xxxxxx", "y"}})
+	if want := []int{6, 10}; !reflect.DeepEqual(widths, want) {
+		t.Errorf("widths = %v, want %v", widths, want)
+	}
+	widths = fitWidths(widths, []string{"a", "Country", "new"}, [][]string{{"x", "a very long value that won't fit in any sensible column"}})
+	if want := []int{6, maxColumnWidth, 6}; !reflect.DeepEqual(widths, want) {
+		t.Errorf("widths = %v, want %v", widths, want)
+	}
+}
+
+func TestExportCSV(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.csv")
+	err := exportCSV(path, []string{"a", "b"}, [][]string{{"1", "x,y"}, {"2"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "a,b\n1,\"x,y\"\n2,\n"; string(got) != want {
+		t.Errorf("export = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,332 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	_ "modernc.org/sqlite"
+)
+
+// source streams rows from a data file so that we don't have to read all of
+// it before showing the first page.
+type source interface {
+	// Columns returns the column names seen so far. Sources that infer
+	// their columns from the data, like JSON lines, may add more as they
+	// read.
+	Columns() []string
+	// Read returns up to n rows, and io.EOF once there are no more.
+	Read(n int) ([][]string, error)
+	Close() error
+}
+
+// openSource picks a source based on the file extension. query is only used
+// for SQLite databases.
+func openSource(path, query string) (source, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return openCSV(path, ',')
+	case ".tsv":
+		return openCSV(path, '\t')
+	case ".json":
+		return openJSON(path)
+	case ".jsonl", ".ndjson":
+		return openJSONL(path)
+	case ".db", ".sqlite", ".sqlite3":
+		return openSQLite(path, query)
+	}
+	return nil, fmt.Errorf("don't know how to read %s, use .csv, .tsv, .json, .jsonl or .db", path)
+}
+
+type csvSource struct {
+	f       *os.File
+	r       *csv.Reader
+	columns []string
+}
+
+func openCSV(path string, comma rune) (*csvSource, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	r := csv.NewReader(f)
+	r.Comma = comma
+	r.FieldsPerRecord = -1 // we pad or trim rows ourselves
+
+	// The first record is the header.
+	header, err := r.Read()
+	if errors.Is(err, io.EOF) {
+		_ = f.Close()
+		return nil, fmt.Errorf("%s is empty", path)
+	}
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return &csvSource{f: f, r: r, columns: header}, nil
+}
+
+func (s *csvSource) Columns() []string { return s.columns }
+
+func (s *csvSource) Read(n int) ([][]string, error) {
+	var rows [][]string
+	for len(rows) < n {
+		rec, err := s.r.Read()
+		if err != nil {
+			return rows, err
+		}
+		rows = append(rows, fit(rec, len(s.columns)))
+	}
+	return rows, nil
+}
+
+func (s *csvSource) Close() error { return s.f.Close() }
+
+// jsonSource reads JSON objects, one per line from JSON lines files or the
+// elements of an array from .json files. Columns are the keys in the order
+// we first see them.
+type jsonSource struct {
+	f *os.File
+	// next returns the next object and where it was for errors, or io.EOF
+	// once there are no more.
+	next    func() (data []byte, where string, err error)
+	columns []string
+	index   map[string]int
+}
+
+func openJSONL(path string) (*jsonSource, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	line := 0
+	next := func() ([]byte, string, error) {
+		for sc.Scan() {
+			line++
+			if data := bytes.TrimSpace(sc.Bytes()); len(data) > 0 {
+				return data, fmt.Sprintf("line %d", line), nil
+			}
+		}
+		if err := sc.Err(); err != nil {
+			return nil, "", err
+		}
+		return nil, "", io.EOF
+	}
+	return &jsonSource{f: f, next: next, index: map[string]int{}}, nil
+}
+
+// openJSON reads a file holding an array of objects. The array is decoded
+// an element at a time, like the other sources, rather than all at once.
+func openJSON(path string) (*jsonSource, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(f)
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+		_ = f.Close()
+		return nil, fmt.Errorf("%s: expected a JSON array of objects", path)
+	}
+	item := 0
+	next := func() ([]byte, string, error) {
+		if !dec.More() {
+			return nil, "", io.EOF
+		}
+		item++
+		where := fmt.Sprintf("item %d", item)
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, where, fmt.Errorf("%s: %w", where, err)
+		}
+		return raw, where, nil
+	}
+	return &jsonSource{f: f, next: next, index: map[string]int{}}, nil
+}
+
+func (s *jsonSource) Columns() []string { return s.columns }
+
+func (s *jsonSource) Read(n int) ([][]string, error) {
+	var rows [][]string
+	for len(rows) < n {
+		data, where, err := s.next()
+		if err != nil {
+			return rows, err
+		}
+		keys, values, err := decodeObject(data)
+		if err != nil {
+			return rows, fmt.Errorf("%s: %w", where, err)
+		}
+		row := make([]string, len(s.columns))
+		for i, k := range keys {
+			col, ok := s.index[k]
+			if !ok {
+				col = len(s.columns)
+				s.index[k] = col
+				s.columns = append(s.columns, k)
+				row = append(row, "")
+			}
+			row[col] = values[i]
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func (s *jsonSource) Close() error { return s.f.Close() }
+
+// decodeObject decodes a JSON object, keeping its keys in order. Nested
+// values are shown as compact JSON.
+func decodeObject(data []byte) (keys, values []string, err error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, nil, errors.New("expected a JSON object")
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+		key, _ := tok.(string)
+
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, nil, err
+		}
+		var v any
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return nil, nil, err
+		}
+		var value string
+		switch v := v.(type) {
+		case nil:
+		case string:
+			value = v
+		case bool, float64:
+			value = string(raw)
+		default:
+			var b bytes.Buffer
+			_ = json.Compact(&b, raw)
+			value = b.String()
+		}
+		keys = append(keys, key)
+		values = append(values, value)
+	}
+	return keys, values, nil
+}
+
+type sqliteSource struct {
+	db      *sql.DB
+	rows    *sql.Rows
+	columns []string
+}
+
+// sqliteDSN is a read-only URI for the database at path. The path is
+// escaped, so names with ? or # in them work, and made absolute, since a
+// relative one would be taken for a host.
+func sqliteDSN(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	abs = filepath.ToSlash(abs)
+	if !strings.HasPrefix(abs, "/") {
+		abs = "/" + abs // C:/data.db
+	}
+	u := url.URL{Scheme: "file", Path: abs, RawQuery: "mode=ro"}
+	return u.String(), nil
+}
+
+// openSQLite runs query against the database at path. Without a query we
+// show the first table.
+func openSQLite(path, query string) (*sqliteSource, error) {
+	if _, err := os.Stat(path); err != nil {
+		// Opening a database that doesn't exist would create it.
+		return nil, err
+	}
+	dsn, err := sqliteDSN(path)
+	if err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+	if query == "" {
+		var table string
+		err := db.QueryRow(`SELECT name FROM sqlite_master WHERE type = 'table' ORDER BY name LIMIT 1`).Scan(&table)
+		if err != nil {
+			_ = db.Close()
+			return nil, fmt.Errorf("%s has no tables: %w", path, err)
+		}
+		query = fmt.Sprintf(`SELECT * FROM "%s"`, strings.ReplaceAll(table, `"`, `""`))
+	}
+	rows, err := db.Query(query)
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	columns, err := rows.Columns()
+	if err != nil {
+		_ = rows.Close()
+		_ = db.Close()
+		return nil, err
+	}
+	return &sqliteSource{db: db, rows: rows, columns: columns}, nil
+}
+
+func (s *sqliteSource) Columns() []string { return s.columns }
+
+func (s *sqliteSource) Read(n int) ([][]string, error) {
+	var rows [][]string
+	values := make([]any, len(s.columns))
+	ptrs := make([]any, len(s.columns))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	for len(rows) < n {
+		if !s.rows.Next() {
+			if err := s.rows.Err(); err != nil {
+				return rows, err
+			}
+			return rows, io.EOF
+		}
+		if err := s.rows.Scan(ptrs...); err != nil {
+			return rows, err
+		}
+		row := make([]string, len(values))
+		for i, v := range values {
+			switch v := v.(type) {
+			case nil:
+			case []byte:
+				row[i] = string(v)
+			default:
+				row[i] = fmt.Sprint(v)
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func (s *sqliteSource) Close() error {
+	return errors.Join(s.rows.Close(), s.db.Close())
+}
+
+// fit pads or trims a record to n fields.
+func fit(rec []string, n int) []string {
+	if len(rec) >= n {
+		return rec[:n]
+	}
+	return append(rec, make([]string, n-len(rec))...)
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, name, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// readAll reads src in small batches to exercise the paging.
+func readAll(t *testing.T, src source) [][]string {
+	t.Helper()
+	defer src.Close() //nolint:errcheck
+	var all [][]string
+	for {
+		rows, err := src.Read(2)
+		all = append(all, rows...)
+		if errors.Is(err, io.EOF) {
+			return all
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(rows) != 2 {
+			t.Fatalf("got %d rows without EOF, want 2", len(rows))
+		}
+	}
+}
+
+func TestCSVSource(t *testing.T) {
+	path := writeFile(t, "data.csv", "name,pop\nTokyo,\"37,274,000\"\nDelhi\nParis,1,extra\n")
+	src, err := openSource(path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := src.Columns(); !reflect.DeepEqual(got, []string{"name", "pop"}) {
+		t.Errorf("columns = %q", got)
+	}
+	want := [][]string{{"Tokyo", "37,274,000"}, {"Delhi", ""}, {"Paris", "1"}}
+	if got := readAll(t, src); !reflect.DeepEqual(got, want) {
+		t.Errorf("rows = %q, want %q", got, want)
+	}
+}
+
+func TestTSVSource(t *testing.T) {
+	path := writeFile(t, "data.tsv", "a\tb\n1\t2\n")
+	src, err := openSource(path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := readAll(t, src); !reflect.DeepEqual(got, [][]string{{"1", "2"}}) {
+		t.Errorf("rows = %q", got)
+	}
+}
+
+func TestEmptyCSV(t *testing.T) {
+	if _, err := openSource(writeFile(t, "empty.csv", ""), ""); err == nil {
+		t.Error("expected an error for an empty file")
+	}
+}
+
+func TestJSONLSource(t *testing.T) {
+	path := writeFile(t, "data.jsonl", `{"name":"Tokyo","pop":37274000,"big":true}
+
+{"pop":1.5,"name":"Delhi","tags":["a", "b"],"note":null}
+{"name":"Paris"}
+`)
+	src, err := openSource(path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := readAll(t, src)
+	if got, want := src.Columns(), []string{"name", "pop", "big", "tags", "note"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("columns = %q, want %q", got, want)
+	}
+	want := [][]string{
+		{"Tokyo", "37274000", "true"},
+		{"Delhi", "1.5", "", `["a","b"]`, ""},
+		{"Paris", "", "", "", ""},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %q, want %q", rows, want)
+	}
+}
+
+func TestJSONLBadLine(t *testing.T) {
+	src, err := openSource(writeFile(t, "bad.jsonl", "{\"a\":1}\n[1,2]\n"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close() //nolint:errcheck
+	rows, err := src.Read(10)
+	if err == nil || errors.Is(err, io.EOF) {
+		t.Fatalf("err = %v, want a decoding error", err)
+	}
+	if len(rows) != 1 {
+		t.Errorf("got %d rows before the error, want 1", len(rows))
+	}
+}
+
+func TestJSONSource(t *testing.T) {
+	path := writeFile(t, "data.json", `[
+  {"name": "Tokyo", "pop": 37274000},
+  {"name": "Delhi", "tags": ["a", "b"]}
+]`)
+	src, err := openSource(path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := readAll(t, src)
+	if got, want := src.Columns(), []string{"name", "pop", "tags"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("columns = %q, want %q", got, want)
+	}
+	want := [][]string{{"Tokyo", "37274000"}, {"Delhi", "", `["a","b"]`}}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %q, want %q", rows, want)
+	}
+
+	// JSON lines in a .json file aren't an array.
+	if _, err := openSource(writeFile(t, "lines.json", "{\"a\":1}\n{\"a\":2}\n"), ""); err == nil {
+		t.Error("expected an error for a .json file that isn't an array")
+	}
+	src, err = openSource(writeFile(t, "bad.json", `[{"a":1}, 2]`), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close() //nolint:errcheck
+	if rows, err := src.Read(10); len(rows) != 1 || err == nil || !strings.Contains(err.Error(), "item 2") {
+		t.Errorf("got %d rows and %v, want 1 row and an error about item 2", len(rows), err)
+	}
+}
+
+func TestSQLiteSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.db")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, q := range []string{
+		`CREATE TABLE cities (name TEXT, pop INTEGER, area REAL)`,
+		`INSERT INTO cities VALUES ('Tokyo', 37274000, 2194.07), ('Delhi', 32065760, NULL), ('Paris', 11142303, 105.4)`,
+	} {
+		if _, err := db.Exec(q); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("first table", func(t *testing.T) {
+		src, err := openSource(path, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := src.Columns(); !reflect.DeepEqual(got, []string{"name", "pop", "area"}) {
+			t.Errorf("columns = %q", got)
+		}
+		want := [][]string{
+			{"Tokyo", "37274000", "2194.07"},
+			{"Delhi", "32065760", ""},
+			{"Paris", "11142303", "105.4"},
+		}
+		if got := readAll(t, src); !reflect.DeepEqual(got, want) {
+			t.Errorf("rows = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("query", func(t *testing.T) {
+		src, err := openSource(path, "SELECT name FROM cities WHERE pop > 20000000 ORDER BY name")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := readAll(t, src); !reflect.DeepEqual(got, [][]string{{"Delhi"}, {"Tokyo"}}) {
+			t.Errorf("rows = %q", got)
+		}
+	})
+
+	t.Run("bad query", func(t *testing.T) {
+		if _, err := openSource(path, "SELECT nope FROM cities"); err == nil {
+			t.Error("expected an error")
+		}
+	})
+
+	t.Run("awkward path", func(t *testing.T) {
+		// Characters that mean something in a URI, and a relative path.
+		dir := filepath.Join(t.TempDir(), "a?b#c%d")
+		if err := os.Mkdir(dir, 0o700); err != nil {
+			t.Fatal(err)
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "data 1.db"), b, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		t.Chdir(filepath.Dir(dir))
+		src, err := openSource(filepath.Join("a?b#c%d", "data 1.db"), "SELECT count(*) FROM cities")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := readAll(t, src); !reflect.DeepEqual(got, [][]string{{"3"}}) {
+			t.Errorf("rows = %q", got)
+		}
+	})
+
+	t.Run("missing database", func(t *testing.T) {
+		missing := filepath.Join(t.TempDir(), "missing.db")
+		if _, err := openSource(missing, ""); err == nil {
+			t.Error("expected an error")
+		}
+		if _, err := os.Stat(missing); err == nil {
+			t.Error("opening a missing database created it")
+		}
+	})
+}
+
+func TestUnknownFormat(t *testing.T) {
+	if _, err := openSource("data.xlsx", ""); err == nil {
+		t.Error("expected an error")
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+// The models that live in the tabs. Any tea.Model will do; these are just
+// a few to play with.
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/bubbles/stopwatch"
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var faintStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+
+// page shows some text in the middle of the tab, and how big the tab is.
+type page struct {
+	text          string
+	width, height int
+}
+
+func (p page) Init() tea.Cmd { return nil }
+
+func (p page) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.WindowSizeMsg); ok {
+		p.width, p.height = msg.Width, msg.Height
+	}
+	return p, nil
+}
+
+func (p page) View() string {
+	size := faintStyle.Render(fmt.Sprintf("%d×%d", p.width, p.height))
+	return lipgloss.Place(p.width, p.height, lipgloss.Center, lipgloss.Center,
+		lipgloss.JoinVertical(lipgloss.Center, p.text, "", size))
+}
+
+// notes is somewhere to type, which fills the tab.
+type notes struct {
+	textarea textarea.Model
+}
+
+func newNotes() notes {
+	ta := textarea.New()
+	ta.Placeholder = "Type away…"
+	ta.ShowLineNumbers = false
+	return notes{ta}
+}
+
+func (n notes) Init() tea.Cmd {
+	return n.textarea.Focus()
+}
+
+func (n notes) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.WindowSizeMsg); ok {
+		n.textarea.SetWidth(msg.Width)
+		n.textarea.SetHeight(msg.Height)
+		return n, nil
+	}
+	var cmd tea.Cmd
+	n.textarea, cmd = n.textarea.Update(msg)
+	return n, cmd
+}
+
+func (n notes) View() string {
+	return n.textarea.View()
+}
+
+// clock is a stopwatch that starts and stops with space.
+type clock struct {
+	stopwatch     stopwatch.Model
+	width, height int
+}
+
+func newClock() clock {
+	return clock{stopwatch: stopwatch.NewWithInterval(100 * time.Millisecond)}
+}
+
+func (c clock) Init() tea.Cmd {
+	return c.stopwatch.Init()
+}
+
+func (c clock) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		c.width, c.height = msg.Width, msg.Height
+		return c, nil
+	case tea.KeyMsg:
+		if msg.String() == " " {
+			return c, c.stopwatch.Toggle()
+		}
+	}
+	var cmd tea.Cmd
+	c.stopwatch, cmd = c.stopwatch.Update(msg)
+	return c, cmd
+}
+
+func (c clock) View() string {
+	return lipgloss.Place(c.width, c.height, lipgloss.Center, lipgloss.Center,
+		lipgloss.JoinVertical(lipgloss.Center, c.stopwatch.View(), "", faintStyle.Render("space: start/stop")))
+}
@@ -5,35 +5,210 @@ import (
 	"os"
 	"strings"
 
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
 
+// tab is a tab and the model that lives in it.
+type tab struct {
+	title string
+	model tea.Model
+}
+
 type model struct {
-	Tabs       []string
-	TabContent []string
-	activeTab  int
+	tabs      []tab
+	activeTab int
+	// offset is the first tab in view, when they don't all fit.
+	offset        int
+	width, height int
+	opened        int // tabs opened with ctrl+t, for naming them
+	keymap        keymap
+	help          help.Model
+}
+
+type keymap struct {
+	next, prev, open, close, moveLeft, moveRight, quit key.Binding
+}
+
+func newKeymap() keymap {
+	return keymap{
+		next:      key.NewBinding(key.WithKeys("tab"), key.WithHelp("tab/shift+tab", "switch")),
+		prev:      key.NewBinding(key.WithKeys("shift+tab")),
+		open:      key.NewBinding(key.WithKeys("ctrl+t"), key.WithHelp("ctrl+t", "new tab")),
+		close:     key.NewBinding(key.WithKeys("ctrl+w"), key.WithHelp("ctrl+w", "close")),
+		moveLeft:  key.NewBinding(key.WithKeys("alt+left", "alt+h"), key.WithHelp("alt+←/→", "move")),
+		moveRight: key.NewBinding(key.WithKeys("alt+right", "alt+l")),
+		quit:      key.NewBinding(key.WithKeys("ctrl+c"), key.WithHelp("ctrl+c", "quit")),
+	}
+}
+
+func newModel(tabs ...tab) model {
+	return model{
+		tabs:   tabs,
+		width:  80,
+		height: 24,
+		keymap: newKeymap(),
+		help:   help.New(),
+	}
 }
 
 func (m model) Init() tea.Cmd {
-	return nil
+	cmds := make([]tea.Cmd, len(m.tabs))
+	for i, t := range m.tabs {
+		cmds[i] = t.model.Init()
+	}
+	return tea.Batch(cmds...)
+}
+
+// innerSize is the room a tab's model has.
+func (m model) innerSize() (width, height int) {
+	width = m.width - docStyle.GetHorizontalFrameSize() - windowStyle.GetHorizontalFrameSize()
+	height = m.height - docStyle.GetVerticalFrameSize() - tabHeight - windowStyle.GetVerticalFrameSize() - 1 // help
+	return max(1, width), max(1, height)
+}
+
+func (m model) sizeMsg() tea.WindowSizeMsg {
+	w, h := m.innerSize()
+	return tea.WindowSizeMsg{Width: w, Height: h}
+}
+
+// open adds a tab after the active one and switches to it.
+func (m *model) open(t tab) tea.Cmd {
+	t.model, _ = t.model.Update(m.sizeMsg())
+	i := min(m.activeTab+1, len(m.tabs))
+	m.tabs = append(m.tabs[:i], append([]tab{t}, m.tabs[i:]...)...)
+	m.activeTab = i
+	return t.model.Init()
+}
+
+func (m *model) close(i int) {
+	m.tabs = append(m.tabs[:i], m.tabs[i+1:]...)
+	if m.activeTab > i || m.activeTab == len(m.tabs) {
+		m.activeTab = max(0, m.activeTab-1)
+	}
+}
+
+// move swaps the active tab with its neighbour, by is -1 or 1.
+func (m *model) move(by int) {
+	j := m.activeTab + by
+	if j < 0 || j >= len(m.tabs) {
+		return
+	}
+	m.tabs[m.activeTab], m.tabs[j] = m.tabs[j], m.tabs[m.activeTab]
+	m.activeTab = j
+}
+
+// newTab makes the next tab for ctrl+t.
+func (m *model) newTab() tab {
+	m.opened++
+	switch m.opened % 3 {
+	case 1:
+		return tab{fmt.Sprintf("Notes %d", m.opened), newNotes()}
+	case 2:
+		return tab{fmt.Sprintf("Stopwatch %d", m.opened), newClock()}
+	}
+	return tab{fmt.Sprintf("Page %d", m.opened), page{text: fmt.Sprintf("Page %d Tab", m.opened)}}
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	m, cmd := m.update(msg)
+	m.offset = m.bar().first
+	return m, cmd
+}
+
+func (m model) update(msg tea.Msg) (model, tea.Cmd) {
 	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.help.Width = msg.Width - docStyle.GetHorizontalFrameSize()
+		size := m.sizeMsg()
+		cmds := make([]tea.Cmd, len(m.tabs))
+		for i := range m.tabs {
+			m.tabs[i].model, cmds[i] = m.tabs[i].model.Update(size)
+		}
+		return m, tea.Batch(cmds...)
+
 	case tea.KeyMsg:
-		switch keypress := msg.String(); keypress {
-		case "ctrl+c", "q":
+		switch {
+		case key.Matches(msg, m.keymap.quit):
 			return m, tea.Quit
-		case "right", "l", "n", "tab":
-			m.activeTab = min(m.activeTab+1, len(m.Tabs)-1)
+		case key.Matches(msg, m.keymap.next):
+			if len(m.tabs) > 0 {
+				m.activeTab = (m.activeTab + 1) % len(m.tabs)
+			}
+			return m, nil
+		case key.Matches(msg, m.keymap.prev):
+			if len(m.tabs) > 0 {
+				m.activeTab = (m.activeTab + len(m.tabs) - 1) % len(m.tabs)
+			}
+			return m, nil
+		case key.Matches(msg, m.keymap.open):
+			return m, m.open(m.newTab())
+		case key.Matches(msg, m.keymap.close):
+			if len(m.tabs) > 0 {
+				m.close(m.activeTab)
+			}
+			return m, nil
+		case key.Matches(msg, m.keymap.moveLeft):
+			m.move(-1)
 			return m, nil
-		case "left", "h", "p", "shift+tab":
-			m.activeTab = max(m.activeTab-1, 0)
+		case key.Matches(msg, m.keymap.moveRight):
+			m.move(1)
 			return m, nil
 		}
+		return m.updateActive(msg)
+
+	case tea.MouseMsg:
+		return m.updateMouse(msg)
+	}
+
+	// Everything else, like ticks and blinks, goes to every tab.
+	cmds := make([]tea.Cmd, len(m.tabs))
+	for i := range m.tabs {
+		m.tabs[i].model, cmds[i] = m.tabs[i].model.Update(msg)
 	}
+	return m, tea.Batch(cmds...)
+}
 
+func (m model) updateActive(msg tea.Msg) (model, tea.Cmd) {
+	if len(m.tabs) == 0 {
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.tabs[m.activeTab].model, cmd = m.tabs[m.activeTab].model.Update(msg)
+	return m, cmd
+}
+
+func (m model) updateMouse(msg tea.MouseMsg) (model, tea.Cmd) {
+	top, left := docStyle.GetPaddingTop(), docStyle.GetPaddingLeft()
+	if msg.Y < top || msg.Y >= top+tabHeight {
+		// Below the tabs, it's for the active tab, relative to its corner.
+		msg.X -= left + windowStyle.GetBorderLeftSize()
+		msg.Y -= top + tabHeight
+		return m.updateActive(msg)
+	}
+	if msg.Action != tea.MouseActionPress {
+		return m, nil
+	}
+
+	b := m.bar()
+	switch i := b.hit(msg.X - left); {
+	case msg.Button == tea.MouseButtonWheelUp:
+		m.activeTab = max(0, m.activeTab-1)
+	case msg.Button == tea.MouseButtonWheelDown && len(m.tabs) > 0:
+		m.activeTab = min(len(m.tabs)-1, m.activeTab+1)
+	case i == hitLeft:
+		m.activeTab = b.first - 1
+	case i == hitRight:
+		m.activeTab = b.last + 1
+	case i < 0:
+	case msg.Button == tea.MouseButtonLeft:
+		m.activeTab = i
+	case msg.Button == tea.MouseButtonMiddle:
+		m.close(i)
+	}
 	return m, nil
 }
 
@@ -52,17 +227,129 @@ var (
 	highlightColor    = lipgloss.AdaptiveColor{Light: "#874BFD", Dark: "#7D56F4"}
 	inactiveTabStyle  = lipgloss.NewStyle().Border(inactiveTabBorder, true).BorderForeground(highlightColor).Padding(0, 1)
 	activeTabStyle    = inactiveTabStyle.Border(activeTabBorder, true)
-	windowStyle       = lipgloss.NewStyle().BorderForeground(highlightColor).Padding(2, 0).Align(lipgloss.Center).Border(lipgloss.NormalBorder()).UnsetBorderTop()
+	windowStyle       = lipgloss.NewStyle().BorderForeground(highlightColor).Border(lipgloss.NormalBorder()).UnsetBorderTop()
+	lineStyle         = lipgloss.NewStyle().Foreground(highlightColor)
 )
 
+const (
+	// tabHeight is how tall a rendered tab is, borders and all.
+	tabHeight = 3
+	// arrowWidth is how wide the arrows for tabs out of view are.
+	arrowWidth = 2
+)
+
+// bar is the layout of the tab bar: which tabs are in view, and whether
+// there are arrows for the ones that aren't.
+type bar struct {
+	first, last int
+	widths      []int
+	width       int // the width of the whole bar
+}
+
+// Hits on the arrows, as returned by bar.hit.
+const (
+	hitNone  = -1
+	hitLeft  = -2
+	hitRight = -3
+)
+
+func (m model) bar() bar {
+	widths := make([]int, len(m.tabs))
+	for i, t := range m.tabs {
+		widths[i] = lipgloss.Width(inactiveTabStyle.Render(t.title))
+	}
+	width := m.width - docStyle.GetHorizontalFrameSize()
+	first, last := layout(widths, m.activeTab, m.offset, width)
+	return bar{first, last, widths, width}
+}
+
+// layout works out which tabs fit in width, keeping the active one in view
+// and, where it can, the first one in view the same. It returns the first
+// and last tabs in view.
+func layout(widths []int, active, offset, width int) (first, last int) {
+	n := len(widths)
+	if n == 0 {
+		return 0, -1
+	}
+	fits := func(first, last int) bool {
+		used := 0
+		for _, w := range widths[first : last+1] {
+			used += w
+		}
+		if first > 0 {
+			used += arrowWidth
+		}
+		if last < n-1 {
+			used += arrowWidth
+		}
+		return used <= width
+	}
+
+	first = max(0, min(offset, active, n-1))
+	for {
+		last = first
+		for last+1 < n && fits(first, last+1) {
+			last++
+		}
+		if last >= active {
+			break
+		}
+		first++
+	}
+	// Fill any room left on the left, say after closing tabs.
+	for first > 0 && fits(first-1, last) {
+		first--
+	}
+	return first, last
+}
+
+func (b bar) left() bool  { return b.first > 0 }
+func (b bar) right() bool { return b.last < len(b.widths)-1 }
+
+// rest is the room after the last tab in view.
+func (b bar) rest() int {
+	used := 0
+	if b.left() {
+		used += arrowWidth
+	}
+	for _, w := range b.widths[b.first : b.last+1] {
+		used += w
+	}
+	return max(0, b.width-used)
+}
+
+// hit returns the tab at x, one of the arrows, or hitNone.
+func (b bar) hit(x int) int {
+	if b.left() {
+		if x < arrowWidth {
+			return hitLeft
+		}
+		x -= arrowWidth
+	}
+	for i := b.first; i <= b.last; i++ {
+		if x < b.widths[i] {
+			return i
+		}
+		x -= b.widths[i]
+	}
+	if b.right() && x < b.rest() {
+		return hitRight
+	}
+	return hitNone
+}
+
 func (m model) View() string {
 	doc := strings.Builder{}
+	b := m.bar()
 
 	var renderedTabs []string
-
-	for i, t := range m.Tabs {
+	if b.left() {
+		renderedTabs = append(renderedTabs, lineStyle.Render("  \n‹ \n┌─"))
+	}
+	rest := b.rest()
+	for i := b.first; i <= b.last; i++ {
 		var style lipgloss.Style
-		isFirst, isLast, isActive := i == 0, i == len(m.Tabs)-1, i == m.activeTab
+		isFirst, isLast, isActive := i == b.first && !b.left(), i == b.last && rest == 0, i == m.activeTab
 		if isActive {
 			style = activeTabStyle
 		} else {
@@ -71,29 +358,59 @@ func (m model) View() string {
 		border, _, _, _, _ := style.GetBorder()
 		if isFirst && isActive {
 			border.BottomLeft = "│"
-		} else if isFirst && !isActive {
+		} else if isFirst {
 			border.BottomLeft = "├"
-		} else if isLast && isActive {
+		}
+		if isLast && isActive {
 			border.BottomRight = "│"
-		} else if isLast && !isActive {
+		} else if isLast {
 			border.BottomRight = "┤"
 		}
 		style = style.Border(border)
-		renderedTabs = append(renderedTabs, style.Render(t))
+		renderedTabs = append(renderedTabs, style.Render(m.tabs[i].title))
+	}
+	if rest > 0 {
+		arrow := " "
+		if b.right() {
+			arrow = "›"
+		}
+		corner := "┐"
+		if len(m.tabs) == 0 {
+			corner = "┌" + strings.Repeat("─", max(0, rest-2)) + "┐"
+		}
+		renderedTabs = append(renderedTabs, lineStyle.Render(
+			strings.Repeat(" ", rest)+"\n"+
+				strings.Repeat(" ", rest-1)+arrow+"\n"+
+				strings.Repeat("─", max(0, rest-lipgloss.Width(corner)))+corner,
+		))
 	}
 
 	row := lipgloss.JoinHorizontal(lipgloss.Top, renderedTabs...)
 	doc.WriteString(row)
 	doc.WriteString("\n")
-	doc.WriteString(windowStyle.Width((lipgloss.Width(row) - windowStyle.GetHorizontalFrameSize())).Render(m.TabContent[m.activeTab]))
+
+	w, h := m.innerSize()
+	content := "No tabs open. Press ctrl+t for a new one."
+	if len(m.tabs) > 0 {
+		content = m.tabs[m.activeTab].model.View()
+	}
+	content = lipgloss.NewStyle().MaxWidth(w).MaxHeight(h).Render(content)
+	doc.WriteString(windowStyle.Width(w).Height(h).Render(content))
+	doc.WriteString("\n")
+	doc.WriteString(m.help.ShortHelpView([]key.Binding{
+		m.keymap.next, m.keymap.open, m.keymap.close, m.keymap.moveLeft, m.keymap.quit,
+	}))
 	return docStyle.Render(doc.String())
 }
 
 func main() {
-	tabs := []string{"Lip Gloss", "Blush", "Eye Shadow", "Mascara", "Foundation"}
-	tabContent := []string{"Lip Gloss Tab", "Blush Tab", "Eye Shadow Tab", "Mascara Tab", "Foundation Tab"}
-	m := model{Tabs: tabs, TabContent: tabContent}
-	if _, err := tea.NewProgram(m).Run(); err != nil {
+	var tabs []tab
+	for _, t := range []string{"Lip Gloss", "Blush", "Eye Shadow", "Mascara", "Foundation"} {
+		tabs = append(tabs, tab{t, page{text: t + " Tab"}})
+	}
+	tabs = append(tabs, tab{"Notes", newNotes()}, tab{"Stopwatch", newClock()})
+	m := newModel(tabs...)
+	if _, err := tea.NewProgram(m, tea.WithAltScreen(), tea.WithMouseCellMotion()).Run(); err != nil {
 		fmt.Println("Error running program:", err)
 		os.Exit(1)
 	}
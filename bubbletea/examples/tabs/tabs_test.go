@@ -0,0 +1,173 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// recorder remembers the last size and message it got.
+type recorder struct {
+	size tea.WindowSizeMsg
+	last tea.Msg
+}
+
+func (r recorder) Init() tea.Cmd { return nil }
+
+func (r recorder) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if size, ok := msg.(tea.WindowSizeMsg); ok {
+		r.size = size
+	}
+	r.last = msg
+	return r, nil
+}
+
+func (r recorder) View() string { return "" }
+
+func testModel(titles ...string) model {
+	tabs := make([]tab, len(titles))
+	for i, t := range titles {
+		tabs[i] = tab{t, recorder{}}
+	}
+	return newModel(tabs...)
+}
+
+func update(m model, msgs ...tea.Msg) model {
+	for _, msg := range msgs {
+		next, _ := m.Update(msg)
+		m = next.(model)
+	}
+	return m
+}
+
+func titles(m model) string {
+	var s []string
+	for _, t := range m.tabs {
+		s = append(s, t.title)
+	}
+	return strings.Join(s, " ")
+}
+
+func TestLayout(t *testing.T) {
+	widths := []int{10, 10, 10, 10, 10}
+	tests := []struct {
+		active, offset, width int
+		first, last           int
+	}{
+		{0, 0, 50, 0, 4},
+		{0, 0, 100, 0, 4},
+		{0, 0, 30, 0, 1},  // two tabs and the right arrow
+		{2, 0, 30, 1, 2},  // both arrows
+		{4, 0, 30, 3, 4},  // the left arrow
+		{2, 2, 30, 2, 3},  // the view stays put while the active tab is in it
+		{0, 4, 30, 0, 1},  // and moves when it isn't
+		{3, 4, 100, 0, 4}, // with room, everything's in view
+		{1, 0, 5, 1, 1},   // the active tab's shown even if it doesn't fit
+	}
+	for _, tt := range tests {
+		first, last := layout(widths, tt.active, tt.offset, tt.width)
+		if first != tt.first || last != tt.last {
+			t.Errorf("layout(active %d, offset %d, width %d) = %d, %d, want %d, %d",
+				tt.active, tt.offset, tt.width, first, last, tt.first, tt.last)
+		}
+	}
+}
+
+func TestOpenCloseMove(t *testing.T) {
+	m := testModel("a", "b", "c")
+	m = update(m, tea.WindowSizeMsg{Width: 60, Height: 20})
+	for _, tab := range m.tabs {
+		if got := tab.model.(recorder).size; got != (tea.WindowSizeMsg{Width: 54, Height: 13}) {
+			t.Fatalf("tab %s got size %v", tab.title, got)
+		}
+	}
+
+	// New tabs open after the active one, already sized.
+	m = update(m, tea.KeyMsg{Type: tea.KeyCtrlT})
+	if got := titles(m); got != "a Notes 1 b c" || m.activeTab != 1 {
+		t.Fatalf("tabs = %q, active %d", got, m.activeTab)
+	}
+	if w := lipgloss.Width(m.tabs[1].model.View()); w != 54 {
+		t.Errorf("new tab is %d wide, want 54", w)
+	}
+
+	m = update(m, tea.KeyMsg{Type: tea.KeyRight, Alt: true}, tea.KeyMsg{Type: tea.KeyRight, Alt: true})
+	if got := titles(m); got != "a b c Notes 1" || m.activeTab != 3 {
+		t.Errorf("after moving right: %q, active %d", got, m.activeTab)
+	}
+	m = update(m, tea.KeyMsg{Type: tea.KeyRight, Alt: true})
+	if got := titles(m); got != "a b c Notes 1" {
+		t.Errorf("moved past the end: %q", got)
+	}
+
+	m = update(m, tea.KeyMsg{Type: tea.KeyCtrlW})
+	if got := titles(m); got != "a b c" || m.activeTab != 2 {
+		t.Errorf("after closing: %q, active %d", got, m.activeTab)
+	}
+
+	// Keys the tabs don't use go to the active tab only.
+	m = update(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'x'}})
+	if _, ok := m.tabs[2].model.(recorder).last.(tea.KeyMsg); !ok {
+		t.Error("the active tab didn't get the key")
+	}
+	if _, ok := m.tabs[0].model.(recorder).last.(tea.KeyMsg); ok {
+		t.Error("an inactive tab got the key")
+	}
+
+	m = update(m, tea.KeyMsg{Type: tea.KeyCtrlW}, tea.KeyMsg{Type: tea.KeyCtrlW}, tea.KeyMsg{Type: tea.KeyCtrlW}, tea.KeyMsg{Type: tea.KeyCtrlW})
+	if len(m.tabs) != 0 || !strings.Contains(m.View(), "No tabs open") {
+		t.Errorf("closing everything left %q", titles(m))
+	}
+}
+
+func TestMouse(t *testing.T) {
+	// Each tab is 5 wide, and the bar is 16 wide, so there's room for two
+	// tabs and an arrow.
+	m := testModel("a", "b", "c", "d")
+	m = update(m, tea.WindowSizeMsg{Width: 20, Height: 12})
+	click := func(x, y int, button tea.MouseButton) tea.MouseMsg {
+		return tea.MouseMsg{X: x, Y: y, Button: button, Action: tea.MouseActionPress}
+	}
+	left := docStyle.GetPaddingLeft()
+
+	m = update(m, click(left+6, 2, tea.MouseButtonLeft))
+	if m.activeTab != 1 {
+		t.Fatalf("clicking b made %d active", m.activeTab)
+	}
+
+	// The right arrow brings the next tab into view.
+	m = update(m, click(left+15, 2, tea.MouseButtonLeft))
+	if b := m.bar(); m.activeTab != 2 || b.first != 1 || b.last != 2 {
+		t.Errorf("after the right arrow: active %d, showing %d-%d", m.activeTab, b.first, b.last)
+	}
+
+	// Middle click closes; with the left arrow, c starts at 7.
+	m = update(m, click(left+7, 1, tea.MouseButtonMiddle))
+	if got := titles(m); got != "a b d" {
+		t.Errorf("after middle click: %q", got)
+	}
+
+	// Clicks below the tabs go to the active tab, relative to its corner.
+	m = update(m, click(left+3, 6, tea.MouseButtonLeft))
+	got, ok := m.tabs[m.activeTab].model.(recorder).last.(tea.MouseMsg)
+	if !ok || got.X != 2 || got.Y != 2 {
+		t.Errorf("active tab got %#v", m.tabs[m.activeTab].model.(recorder).last)
+	}
+}
+
+func TestNoTabs(t *testing.T) {
+	m := testModel()
+	wheel := tea.MouseMsg{X: docStyle.GetPaddingLeft(), Y: 1, Button: tea.MouseButtonWheelDown, Action: tea.MouseActionPress}
+
+	// However narrow the window, the empty bar still draws, and there's
+	// nothing to scroll to.
+	for width := 1; width <= 12; width++ {
+		m = update(m, tea.WindowSizeMsg{Width: width, Height: 10}, wheel)
+		if m.activeTab != 0 {
+			t.Fatalf("scrolling with no tabs made %d active", m.activeTab)
+		}
+		m.View()
+	}
+}
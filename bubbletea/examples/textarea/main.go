@@ -4,6 +4,7 @@ package main
 // component library.
 
 import (
+	"flag"
 	"fmt"
 	"log"
 
@@ -12,7 +13,10 @@ import (
 )
 
 func main() {
-	p := tea.NewProgram(initialModel())
+	vimMode := flag.Bool("vim", false, "edit with vim keys")
+	flag.Parse()
+
+	p := tea.NewProgram(initialModel(*vimMode))
 
 	if _, err := p.Run(); err != nil {
 		log.Fatal(err)
@@ -24,17 +28,29 @@ type errMsg error
 type model struct {
 	textarea textarea.Model
 	err      error
+
+	// With -vim, the textarea lives in the vim editor instead.
+	vimMode bool
+	vim     vim
 }
 
-func initialModel() model {
+func initialModel(vimMode bool) model {
 	ti := textarea.New()
 	ti.Placeholder = "Once upon a time..."
 	ti.Focus()
 
-	return model{
+	m := model{
 		textarea: ti,
 		err:      nil,
+		vimMode:  vimMode,
 	}
+	if vimMode {
+		// Vim has its own idea of where lines end, so don't limit them.
+		ti.MaxHeight = 0
+		ti.Placeholder = "Once upon a time... (press i to start typing)"
+		m.vim = newVim(ti)
+	}
+	return m
 }
 
 func (m model) Init() tea.Cmd {
@@ -45,6 +61,14 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 	var cmd tea.Cmd
 
+	if m.vimMode {
+		if msg, ok := msg.(tea.KeyMsg); ok && msg.Type == tea.KeyCtrlC {
+			return m, tea.Quit
+		}
+		m.vim, cmd = m.vim.Update(msg)
+		return m, cmd
+	}
+
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.Type {
@@ -73,6 +97,14 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m model) View() string {
+	if m.vimMode {
+		return fmt.Sprintf(
+			"Tell me a story.\n\n%s\n%s\n\n%s",
+			m.vim.textarea.View(),
+			m.vim.StatusView(),
+			"(ctrl+c to quit)",
+		) + "\n\n"
+	}
 	return fmt.Sprintf(
 		"Tell me a story.\n\n%s\n\n%s",
 		m.textarea.View(),
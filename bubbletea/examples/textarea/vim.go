@@ -0,0 +1,789 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// maxUndo is how many changes we remember.
+const maxUndo = 1000
+
+var (
+	modeStyles = map[mode]lipgloss.Style{
+		normalMode:     lipgloss.NewStyle().Background(lipgloss.Color("63")).Foreground(lipgloss.Color("230")).Padding(0, 1),
+		insertMode:     lipgloss.NewStyle().Background(lipgloss.Color("42")).Foreground(lipgloss.Color("0")).Padding(0, 1),
+		visualMode:     lipgloss.NewStyle().Background(lipgloss.Color("205")).Foreground(lipgloss.Color("0")).Padding(0, 1),
+		visualLineMode: lipgloss.NewStyle().Background(lipgloss.Color("205")).Foreground(lipgloss.Color("0")).Padding(0, 1),
+	}
+	pendingStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	positionStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+)
+
+type mode int
+
+const (
+	normalMode mode = iota
+	insertMode
+	visualMode
+	visualLineMode
+)
+
+func (m mode) String() string {
+	return [...]string{"NORMAL", "INSERT", "VISUAL", "VISUAL LINE"}[m]
+}
+
+// motionKind says how a motion's range is taken when an operator uses it,
+// as in vim.
+type motionKind int
+
+const (
+	exclusive motionKind = iota // up to but not including the target
+	inclusive                   // including the character at the target
+	linewise                    // whole lines
+)
+
+// shorthands are keys that stand for an operator and a motion.
+var shorthands = map[string][2]string{
+	"x": {"d", "l"},
+	"X": {"d", "h"},
+	"D": {"d", "$"},
+	"C": {"c", "$"},
+	"Y": {"y", "y"},
+}
+
+type register struct {
+	text     string
+	linewise bool
+}
+
+type snapshot struct {
+	text string
+	off  int
+}
+
+// vim is a modal editing layer on top of a textarea. In insert mode keys go
+// straight to the textarea. In normal and visual mode we work on the text
+// ourselves and hand the result back to the textarea to display.
+type vim struct {
+	textarea textarea.Model
+	mode     mode
+
+	// text and off, the cursor as an offset into text, are only kept up
+	// to date outside of insert mode.
+	text   []rune
+	off    int
+	anchor int // where visual mode started
+	want   int // the column j and k try to stay in
+
+	registers  map[rune]register
+	undo, redo []snapshot
+	// insertUndo is set when entering insert mode saved an undo step of its
+	// own, which we can drop again if nothing was typed.
+	insertUndo bool
+
+	// The command typed so far.
+	keys     string
+	count    int
+	opCount  int
+	op       rune
+	reg      rune
+	awaitReg bool
+	g        bool
+
+	message string
+}
+
+func newVim(ta textarea.Model) vim {
+	v := vim{
+		textarea:  ta,
+		registers: map[rune]register{},
+	}
+	v.load()
+	v.off = 0
+	v.store(false)
+	return v
+}
+
+// Value returns the text being edited.
+func (v vim) Value() string {
+	return v.textarea.Value()
+}
+
+func (v vim) Update(msg tea.Msg) (vim, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		var cmd tea.Cmd
+		v.textarea, cmd = v.textarea.Update(msg)
+		return v, cmd
+	}
+
+	if v.mode == insertMode {
+		if key.Type != tea.KeyEsc {
+			var cmd tea.Cmd
+			v.textarea, cmd = v.textarea.Update(msg)
+			return v, cmd
+		}
+		v.leaveInsert()
+		return v, nil
+	}
+
+	v.message = ""
+	changed := v.handleKey(key.String())
+	if v.mode == insertMode {
+		return v, nil
+	}
+	v.clampCursor()
+	v.store(changed)
+	return v, nil
+}
+
+// handleKey runs a key in normal or visual mode. It returns whether the text
+// changed.
+func (v *vim) handleKey(k string) bool {
+	if v.awaitReg {
+		v.awaitReg = false
+		r := []rune(k)
+		if len(r) != 1 || !validRegister(r[0]) {
+			v.reset()
+			return false
+		}
+		v.reg = r[0]
+		v.keys += k
+		return false
+	}
+	if v.g {
+		v.g = false
+		if k != "g" {
+			v.reset()
+			return false
+		}
+		return v.motion("gg")
+	}
+
+	// Counts. A 0 on its own is a motion, not a count.
+	if len(k) == 1 && k[0] >= '0' && k[0] <= '9' && (k[0] != '0' || v.count > 0) {
+		v.count = v.count*10 + int(k[0]-'0')
+		v.keys += k
+		return false
+	}
+
+	visual := v.mode == visualMode || v.mode == visualLineMode
+	switch k {
+	case `"`:
+		v.awaitReg = true
+		v.keys += k
+		return false
+	case "g":
+		v.g = true
+		v.keys += k
+		return false
+	case "esc":
+		if visual {
+			v.mode = normalMode
+		}
+		v.reset()
+		return false
+	case "u":
+		defer v.reset()
+		return v.undoChange(&v.undo, &v.redo, "undo")
+	case "ctrl+r":
+		defer v.reset()
+		return v.undoChange(&v.redo, &v.undo, "redo")
+	case "v", "V":
+		defer v.reset()
+		m := visualMode
+		if k == "V" {
+			m = visualLineMode
+		}
+		switch {
+		case v.mode == m:
+			v.mode = normalMode
+		case visual:
+			v.mode = m
+		default:
+			v.mode, v.anchor = m, v.off
+		}
+		return false
+	case "p", "P":
+		defer v.reset()
+		if visual {
+			return false
+		}
+		return v.put(k == "P")
+	}
+
+	if visual {
+		return v.visualKey(k)
+	}
+
+	switch k {
+	case "i", "a", "I", "A", "o", "O":
+		if v.op != 0 {
+			v.reset()
+			return false
+		}
+		v.insert(k)
+		return false
+	case "x", "X", "D", "C", "Y":
+		if v.op != 0 {
+			v.reset()
+			return false
+		}
+		s := shorthands[k]
+		v.handleKey(s[0])
+		return v.handleKey(s[1])
+	case "d", "y", "c":
+		op := rune(k[0])
+		switch v.op {
+		case 0:
+			v.op, v.opCount, v.count = op, v.count, 0
+			v.keys += k
+			return false
+		case op:
+			// dd, yy and cc work on whole lines.
+			n := max(v.opCount, 1) * max(v.count, 1)
+			row, _ := v.pos(v.off)
+			last := min(row+n-1, v.lineCount()-1)
+			defer v.reset()
+			return v.operate(op, v.off, v.rowStart(last), linewise)
+		}
+		v.reset()
+		return false
+	}
+	return v.motion(k)
+}
+
+// visualKey handles keys in visual mode that aren't shared with normal
+// mode.
+func (v *vim) visualKey(k string) bool {
+	kind := inclusive
+	if v.mode == visualLineMode {
+		kind = linewise
+	}
+	switch k {
+	case "o":
+		v.anchor, v.off = v.off, v.anchor
+		v.reset()
+		return false
+	case "d", "x", "y", "c":
+		op := rune(k[0])
+		if op == 'x' {
+			op = 'd'
+		}
+		from := v.anchor
+		v.mode = normalMode
+		defer v.reset()
+		return v.operate(op, from, v.off, kind)
+	}
+	return v.motion(k)
+}
+
+// motion moves the cursor, or applies the pending operator to the text it
+// moves over.
+func (v *vim) motion(k string) bool {
+	defer v.reset()
+	n := max(v.opCount, 1) * max(v.count, 1)
+	target, kind, ok := v.target(k, n)
+	if !ok {
+		return false
+	}
+
+	if v.op == 0 {
+		v.off = target
+		if kind != linewise || k == "gg" || k == "G" {
+			_, v.want = v.pos(target)
+		}
+		return false
+	}
+
+	// Like in vim, cw on a word acts like ce, and dw doesn't go past the
+	// end of the line.
+	if k == "w" {
+		if v.op == 'c' && !isSpace(v.at(v.off)) {
+			// Start from the character before, so we stay in this word
+			// when the cursor is on its last character.
+			target, kind = v.off-1, inclusive
+			for range n {
+				target = v.wordEnd(target)
+			}
+		} else if end := v.lineEnd(v.off); target > end {
+			target = end
+		}
+	}
+	return v.operate(v.op, v.off, target, kind)
+}
+
+// target returns where a motion goes, n times over.
+func (v *vim) target(k string, n int) (int, motionKind, bool) {
+	off := v.off
+	row, _ := v.pos(off)
+	switch k {
+	case "h", "left", "backspace":
+		return max(v.lineStart(off), off-n), exclusive, true
+	case "l", "right", " ":
+		end := v.lineEnd(off)
+		if v.op == 0 {
+			end = v.lastCol(off)
+		}
+		return min(end, off+n), exclusive, true
+	case "j", "down", "k", "up":
+		if k == "k" || k == "up" {
+			n = -n
+		}
+		row = max(0, min(row+n, v.lineCount()-1))
+		start := v.rowStart(row)
+		return min(start+v.want, v.lastCol(start)), linewise, true
+	case "0", "home":
+		return v.lineStart(off), exclusive, true
+	case "^":
+		return v.firstNonBlank(off), exclusive, true
+	case "$", "end":
+		if n > 1 {
+			off = v.rowStart(min(row+n-1, v.lineCount()-1))
+		}
+		return max(v.lineStart(off), v.lineEnd(off)-1), inclusive, true
+	case "w":
+		for range n {
+			off = v.wordForward(off)
+		}
+		return off, exclusive, true
+	case "b":
+		for range n {
+			off = v.wordBackward(off)
+		}
+		return off, exclusive, true
+	case "e":
+		for range n {
+			off = v.wordEnd(off)
+		}
+		return off, inclusive, true
+	case "gg", "G":
+		row = v.lineCount() - 1
+		if k == "gg" {
+			row = 0
+		}
+		if v.count > 0 || v.opCount > 0 {
+			row = min(n, v.lineCount()) - 1
+		}
+		return v.firstNonBlank(v.rowStart(row)), linewise, true
+	}
+	return 0, exclusive, false
+}
+
+// operate applies op to the text between from and to.
+func (v *vim) operate(op rune, from, to int, kind motionKind) bool {
+	a, b := min(from, to), max(from, to)
+	if kind == linewise {
+		a, b = v.lineStart(a), v.lineEnd(b)
+	} else if kind == inclusive {
+		b = min(b+1, len(v.text))
+	}
+
+	reg := register{text: string(v.text[a:b]), linewise: kind == linewise}
+	if reg.linewise {
+		reg.text += "\n"
+	}
+
+	if op == 'y' {
+		v.setRegister(reg, true)
+		v.off = a
+		if kind == linewise {
+			v.off = min(from, to)
+		}
+		if n := strings.Count(reg.text, "\n"); n > 1 {
+			v.message = fmt.Sprintf("%d lines yanked", n)
+		}
+		return false
+	}
+
+	if a == b && kind != linewise {
+		return false
+	}
+	v.save()
+	v.setRegister(reg, false)
+
+	switch {
+	case op == 'c' && kind == linewise:
+		// Keep an empty line to type into.
+		v.replace(a, b, "")
+		v.off = a
+	case kind == linewise:
+		// Take a newline with the lines, the one after them if there is
+		// one.
+		switch {
+		case b < len(v.text):
+			b++
+		case a > 0:
+			a--
+		}
+		v.replace(a, b, "")
+		v.off = v.firstNonBlank(min(a, len(v.text)))
+		if n := strings.Count(reg.text, "\n"); n > 2 {
+			v.message = fmt.Sprintf("%d fewer lines", n)
+		}
+	default:
+		v.replace(a, b, "")
+		v.off = a
+	}
+
+	if op == 'c' {
+		v.enterInsert(false)
+	}
+	return true
+}
+
+// put pastes a register after the cursor, or before it.
+func (v *vim) put(before bool) bool {
+	name := v.reg
+	if name == 0 {
+		name = '"'
+	}
+	r, ok := v.registers[unicode.ToLower(name)]
+	if !ok || r.text == "" {
+		v.message = fmt.Sprintf("register %c is empty", name)
+		return false
+	}
+	text := strings.Repeat(r.text, max(v.count, 1))
+
+	v.save()
+	if r.linewise {
+		var at int
+		switch {
+		case before:
+			at = v.lineStart(v.off)
+		case v.lineEnd(v.off) == len(v.text):
+			// The last line has no newline to insert after.
+			at = len(v.text)
+			text = "\n" + strings.TrimSuffix(text, "\n")
+		default:
+			at = v.lineEnd(v.off) + 1
+		}
+		v.replace(at, at, text)
+		if strings.HasPrefix(text, "\n") {
+			at++
+		}
+		v.off = v.firstNonBlank(at)
+		return true
+	}
+
+	at := v.off
+	if !before && v.off < v.lineEnd(v.off) {
+		at++
+	}
+	v.replace(at, at, text)
+	v.off = at + len([]rune(text)) - 1
+	return true
+}
+
+func validRegister(r rune) bool {
+	return r == '"' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+}
+
+// setRegister stores text in the register the command asked for, or the
+// unnamed one. Like in vim, yanks also go in register 0, and an uppercase
+// register name appends to the register.
+func (v *vim) setRegister(r register, yank bool) {
+	name := v.reg
+	switch {
+	case name >= 'A' && name <= 'Z':
+		name = unicode.ToLower(name)
+		if old, ok := v.registers[name]; ok {
+			if r.linewise && !old.linewise {
+				old.text += "\n"
+			}
+			r = register{text: old.text + r.text, linewise: old.linewise || r.linewise}
+		}
+		v.registers[name] = r
+	case name != 0 && name != '"':
+		v.registers[name] = r
+	}
+	v.registers['"'] = r
+	if yank {
+		v.registers['0'] = r
+	}
+}
+
+// insert enters insert mode the way i, a, I, A, o and O do.
+func (v *vim) insert(k string) {
+	switch k {
+	case "a":
+		v.off = min(v.off+1, v.lineEnd(v.off))
+	case "I":
+		v.off = v.firstNonBlank(v.off)
+	case "A":
+		v.off = v.lineEnd(v.off)
+	case "o", "O":
+		v.save()
+		at := v.lineEnd(v.off)
+		if k == "O" {
+			at = v.lineStart(v.off)
+		}
+		v.replace(at, at, "\n")
+		v.off = at
+		if k == "o" {
+			v.off++
+		}
+		v.enterInsert(false)
+		return
+	}
+	v.enterInsert(true)
+}
+
+// enterInsert switches to insert mode, handing the text to the textarea.
+// saveUndo is false when the command already saved an undo step.
+func (v *vim) enterInsert(saveUndo bool) {
+	if saveUndo {
+		v.save()
+	}
+	v.insertUndo = saveUndo
+	v.mode = insertMode
+	v.reset()
+	v.store(true)
+}
+
+// leaveInsert goes back to normal mode, taking the text back from the
+// textarea.
+func (v *vim) leaveInsert() {
+	v.load()
+	if v.insertUndo && len(v.undo) > 0 && v.undo[len(v.undo)-1].text == string(v.text) {
+		// Nothing was typed, so there's nothing to undo.
+		v.undo = v.undo[:len(v.undo)-1]
+	}
+	v.insertUndo = false
+	v.mode = normalMode
+	// Like vim, the cursor steps back onto the last character typed.
+	if v.off > v.lineStart(v.off) {
+		v.off--
+	}
+	_, v.want = v.pos(v.off)
+	v.store(false)
+}
+
+// save remembers the text for undo.
+func (v *vim) save() {
+	v.undo = append(v.undo, snapshot{string(v.text), v.off})
+	if len(v.undo) > maxUndo {
+		v.undo = v.undo[1:]
+	}
+	v.redo = nil
+}
+
+// undoChange moves a change from one stack to the other, for both undo and
+// redo.
+func (v *vim) undoChange(from, to *[]snapshot, what string) bool {
+	if len(*from) == 0 {
+		v.message = "nothing to " + what
+		return false
+	}
+	s := (*from)[len(*from)-1]
+	*from = (*from)[:len(*from)-1]
+	*to = append(*to, snapshot{string(v.text), v.off})
+	v.text, v.off = []rune(s.text), s.off
+	return true
+}
+
+func (v *vim) reset() {
+	v.keys = ""
+	v.count, v.opCount = 0, 0
+	v.op, v.reg = 0, 0
+	v.awaitReg, v.g = false, false
+}
+
+func (v *vim) replace(a, b int, s string) {
+	v.text = append(v.text[:a:a], append([]rune(s), v.text[b:]...)...)
+}
+
+// load takes the text and cursor from the textarea.
+func (v *vim) load() {
+	v.text = []rune(v.textarea.Value())
+	li := v.textarea.LineInfo()
+	v.off = min(v.rowStart(v.textarea.Line())+li.StartColumn+li.ColumnOffset, len(v.text))
+}
+
+// store hands the text, if it changed, and the cursor to the textarea.
+func (v *vim) store(changed bool) {
+	if changed {
+		v.textarea.SetValue(string(v.text))
+	}
+	row, col := v.pos(v.off)
+	for i := 0; v.textarea.Line() > row && i < len(v.text)+1; i++ {
+		v.textarea.CursorUp()
+	}
+	for i := 0; v.textarea.Line() < row && i < len(v.text)+1; i++ {
+		v.textarea.CursorDown()
+	}
+	v.textarea.SetCursor(col)
+	// Let the textarea scroll to the cursor.
+	v.textarea, _ = v.textarea.Update(nil)
+}
+
+// clampCursor keeps the cursor on a character in normal mode, rather than
+// after the last one.
+func (v *vim) clampCursor() {
+	v.off = max(0, min(v.off, len(v.text)))
+	if v.mode == normalMode {
+		v.off = min(v.off, v.lastCol(v.off))
+	}
+}
+
+// StatusView shows the mode, the command being typed and the cursor
+// position.
+func (v vim) StatusView() string {
+	mode := modeStyles[v.mode].Render(v.mode.String())
+	row, col := v.textarea.Line(), v.textarea.LineInfo().StartColumn+v.textarea.LineInfo().ColumnOffset
+	right := positionStyle.Render(fmt.Sprintf("%d:%d", row+1, col+1))
+
+	middle := v.message
+	if v.mode == visualMode || v.mode == visualLineMode {
+		// The textarea can't highlight a selection, so describe it.
+		a, b := min(v.anchor, v.off), max(v.anchor, v.off)
+		ar, _ := v.pos(a)
+		br, _ := v.pos(b)
+		if v.mode == visualLineMode || ar != br {
+			middle = fmt.Sprintf("%d lines selected (%d-%d)", br-ar+1, ar+1, br+1)
+		} else {
+			middle = fmt.Sprintf("%d chars selected", b-a+1)
+		}
+	}
+	if v.keys != "" {
+		middle = pendingStyle.Render(v.keys) + " " + middle
+	}
+	gap := max(1, v.textarea.Width()-lipgloss.Width(mode)-lipgloss.Width(middle)-lipgloss.Width(right)-1)
+	return mode + " " + middle + strings.Repeat(" ", gap) + right
+}
+
+// Text helpers. Offsets index into v.text, and a line's end is the offset
+// of its newline, or of the end of the text.
+
+func (v *vim) at(off int) rune {
+	if off >= len(v.text) {
+		return '\n'
+	}
+	return v.text[off]
+}
+
+func (v *vim) lineStart(off int) int {
+	for off > 0 && v.text[off-1] != '\n' {
+		off--
+	}
+	return off
+}
+
+func (v *vim) lineEnd(off int) int {
+	for off < len(v.text) && v.text[off] != '\n' {
+		off++
+	}
+	return off
+}
+
+// lastCol is the offset of the last character on the line, where the cursor
+// stops in normal mode.
+func (v *vim) lastCol(off int) int {
+	return max(v.lineStart(off), v.lineEnd(off)-1)
+}
+
+func (v *vim) firstNonBlank(off int) int {
+	off = v.lineStart(off)
+	for off < len(v.text) && v.text[off] != '\n' && isSpace(v.text[off]) {
+		off++
+	}
+	return min(off, v.lastCol(off))
+}
+
+func (v *vim) lineCount() int {
+	return strings.Count(string(v.text), "\n") + 1
+}
+
+func (v *vim) rowStart(row int) int {
+	off := 0
+	for ; row > 0 && off < len(v.text); off++ {
+		if v.text[off] == '\n' {
+			row--
+		}
+	}
+	return off
+}
+
+func (v *vim) pos(off int) (row, col int) {
+	for _, r := range v.text[:off] {
+		if r == '\n' {
+			row++
+		}
+	}
+	return row, off - v.lineStart(off)
+}
+
+func isSpace(r rune) bool { return unicode.IsSpace(r) }
+
+// class sorts characters the way vim's word motions see them: blanks,
+// word characters and punctuation.
+func class(r rune) int {
+	switch {
+	case isSpace(r):
+		return 0
+	case r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r):
+		return 1
+	}
+	return 2
+}
+
+// emptyLine reports whether off is on an empty line, which the word motions
+// stop at.
+func (v *vim) emptyLine(off int) bool {
+	return off < len(v.text) && v.text[off] == '\n' && (off == 0 || v.text[off-1] == '\n')
+}
+
+func (v *vim) wordForward(off int) int {
+	n := len(v.text)
+	if off >= n {
+		return off
+	}
+	if c := class(v.text[off]); c != 0 {
+		for off < n && class(v.text[off]) == c {
+			off++
+		}
+	} else if v.emptyLine(off) {
+		off++
+	}
+	for off < n && isSpace(v.text[off]) && !v.emptyLine(off) {
+		off++
+	}
+	return off
+}
+
+func (v *vim) wordBackward(off int) int {
+	if off == 0 {
+		return 0
+	}
+	off--
+	for off > 0 && isSpace(v.text[off]) && !v.emptyLine(off) {
+		off--
+	}
+	if c := class(v.text[off]); c != 0 {
+		for off > 0 && class(v.text[off-1]) == c {
+			off--
+		}
+	}
+	return off
+}
+
+func (v *vim) wordEnd(off int) int {
+	n := len(v.text)
+	off++
+	for off < n && isSpace(v.text[off]) {
+		off++
+	}
+	if off >= n {
+		return max(n-1, 0)
+	}
+	c := class(v.text[off])
+	for off+1 < n && class(v.text[off+1]) == c {
+		off++
+	}
+	return off
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// typeKeys sends keys to the editor. <esc> and <c-r> stand for escape and
+// ctrl+r.
+func typeKeys(v vim, keys string) vim {
+	for keys != "" {
+		var msg tea.KeyMsg
+		switch {
+		case strings.HasPrefix(keys, "<esc>"):
+			msg, keys = tea.KeyMsg{Type: tea.KeyEsc}, keys[5:]
+		case strings.HasPrefix(keys, "<c-r>"):
+			msg, keys = tea.KeyMsg{Type: tea.KeyCtrlR}, keys[5:]
+		case keys[0] == ' ':
+			msg, keys = tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}}, keys[1:]
+		default:
+			msg, keys = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{rune(keys[0])}}, keys[1:]
+		}
+		v, _ = v.Update(msg)
+	}
+	return v
+}
+
+func newTestVim(text string) vim {
+	ta := textarea.New()
+	ta.MaxHeight = 0
+	ta.SetWidth(80)
+	ta.Focus()
+	ta.SetValue(text)
+	return newVim(ta)
+}
+
+func TestVim(t *testing.T) {
+	tests := []struct {
+		name, text, keys string
+		want             string
+		row, col         int
+	}{
+		{"word motions", "one two three", "wwbe", "one two three", 0, 6},
+		{"punctuation is a word", "foo.bar baz", "ww", "foo.bar baz", 0, 4},
+		{"end of line", "hello world", "$", "hello world", 0, 10},
+		{"start of line", "  hello", "$0", "  hello", 0, 0},
+		{"last line", "a\nb\nc", "G", "a\nb\nc", 2, 0},
+		{"line by count", "a\nb\nc", "G2gg", "a\nb\nc", 1, 0},
+		{"first line", "a\nb\nc", "jjgg", "a\nb\nc", 0, 0},
+		{"j keeps the column", "hello\nhi\nhello", "$jj", "hello\nhi\nhello", 2, 4},
+		{"delete word", "one two three", "dw", "two three", 0, 0},
+		{"delete words with a count", "one two three", "2dw", "three", 0, 0},
+		{"dw stops at the end of the line", "one two\nthree", "wdw", "one \nthree", 0, 3},
+		{"delete to end of word", "one two", "de", " two", 0, 0},
+		{"delete to end of line", "one two", "wD", "one ", 0, 3},
+		{"delete line", "a\nb\nc", "jdd", "a\nc", 1, 0},
+		{"delete last line", "a\nb\nc", "Gdd", "a\nb", 1, 0},
+		{"delete lines with a count", "a\nb\nc\nd", "2dd", "c\nd", 0, 0},
+		{"delete down", "a\nb\nc", "dj", "c", 0, 0},
+		{"delete to the end", "a\nb\nc", "jdG", "a", 0, 0},
+		{"delete chars", "abcdef", "3x", "def", 0, 0},
+		{"delete char before", "abc", "$X", "ac", 0, 1},
+		{"change word", "one two", "cwsix<esc>", "six two", 0, 2},
+		{"change last char of a word", "one two", "llcwe<esc>", "one two", 0, 2},
+		{"change line", "  one\ntwo", "ccfour<esc>", "four\ntwo", 0, 3},
+		{"change to end of line", "one two", "wCsix<esc>", "one six", 0, 6},
+		{"yank and put line", "a\nb", "yyp", "a\na\nb", 1, 0},
+		{"put line above", "a\nb", "jyykP", "b\na\nb", 0, 0},
+		{"put at the end", "a\nb", "yyGp", "a\nb\na", 2, 0},
+		{"put chars", "abc", "xp", "bac", 0, 1},
+		{"put with a count", "ab", "yl3p", "aaaab", 0, 3},
+		{"named register", "one\ntwo", `"ayyjdd"ap`, "one\none", 1, 0},
+		{"appending register", "one\ntwo\nthree", `"ayyj"Ayy"aP`, "one\none\ntwo\ntwo\nthree", 1, 0},
+		{"yank register survives delete", "one\ntwo", `yyjdd"0p`, "one\none", 1, 0},
+		{"insert", "world", "ihello <esc>", "hello world", 0, 5},
+		{"append", "ab", "aX<esc>", "aXb", 0, 1},
+		{"append at end", "ab", "A!<esc>", "ab!", 0, 2},
+		{"insert at start", "  ab", "$I-<esc>", "  -ab", 0, 2},
+		{"open below", "a\nc", "ob<esc>", "a\nb\nc", 1, 0},
+		{"open above", "b", "Oa<esc>", "a\nb", 0, 0},
+		{"undo", "one two", "dwdwu", "two", 0, 0},
+		{"undo insert as one step", "a", "A bc<esc>u", "a", 0, 0},
+		{"redo", "one two", "dwu<c-r>", "two", 0, 0},
+		{"visual delete", "one two", "vlld", " two", 0, 0},
+		{"visual backwards", "one two", "$vbd", "one ", 0, 3},
+		{"visual swap ends", "one two", "wvlohd", "oneo", 0, 3},
+		{"visual line yank", "a\nb\nc", "Vjyjp", "a\nb\na\nb\nc", 2, 0},
+		{"visual change", "one two", "velcsix<esc>", "sixtwo", 0, 2},
+		{"escape cancels", "one two", "d<esc>w", "one two", 0, 4},
+		{"count before operator", "a b c d", "2d2w", "", 0, 0},
+		{"w stops on the last word", "one two", "www", "one two", 0, 6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := typeKeys(newTestVim(tt.text), tt.keys)
+			if got := v.Value(); got != tt.want {
+				t.Errorf("text = %q, want %q", got, tt.want)
+			}
+			if v.mode != normalMode {
+				t.Errorf("mode = %v, want NORMAL", v.mode)
+			}
+			row, col := v.textarea.Line(), v.textarea.LineInfo().StartColumn+v.textarea.LineInfo().ColumnOffset
+			if row != tt.row || col != tt.col {
+				t.Errorf("cursor at %d:%d, want %d:%d", row, col, tt.row, tt.col)
+			}
+		})
+	}
+}
+
+func TestVimStatus(t *testing.T) {
+	v := typeKeys(newTestVim("one\ntwo"), "i")
+	if s := v.StatusView(); !strings.Contains(s, "INSERT") {
+		t.Errorf("status = %q, want INSERT", s)
+	}
+	v = typeKeys(v, "<esc>Vj")
+	if s := v.StatusView(); !strings.Contains(s, "VISUAL LINE") || !strings.Contains(s, "2 lines selected") {
+		t.Errorf("status = %q, want two lines selected in VISUAL LINE", s)
+	}
+	v = typeKeys(v, `<esc>"a2d`)
+	if s := v.StatusView(); !strings.Contains(s, `"a2d`) {
+		t.Errorf("status = %q, want the pending command", s)
+	}
+}
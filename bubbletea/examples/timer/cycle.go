@@ -0,0 +1,219 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// clock tells the time. The pomodoro timer reads the time from a clock,
+// rather than counting ticks, so that it doesn't drift when ticks are late
+// and so tests can move time along.
+type clock interface {
+	Now() time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+type phase int
+
+const (
+	work phase = iota
+	shortBreak
+	longBreak
+)
+
+func (p phase) String() string {
+	return [...]string{"Work", "Short break", "Long break"}[p]
+}
+
+// config is how long each phase lasts, and how many work sessions there
+// are before a long break.
+type config struct {
+	Work, ShortBreak, LongBreak time.Duration
+	Rounds                      int
+}
+
+func (c config) validate() error {
+	if c.Work <= 0 || c.ShortBreak <= 0 || c.LongBreak <= 0 {
+		return errors.New("phases have to be longer than zero")
+	}
+	if c.Rounds < 1 {
+		return errors.New("there has to be at least one round")
+	}
+	return nil
+}
+
+func (c config) length(p phase) time.Duration {
+	switch p {
+	case shortBreak:
+		return c.ShortBreak
+	case longBreak:
+		return c.LongBreak
+	}
+	return c.Work
+}
+
+// cycle is where we are in the schedule.
+type cycle struct {
+	Phase phase `json:"phase"`
+	Round int   `json:"round"` // the work session we're on, from 1
+
+	// Started is when the timer was last started, and is zero while it's
+	// paused. Done is the time spent in this phase before that.
+	Started time.Time     `json:"started,omitzero"`
+	Done    time.Duration `json:"done"`
+}
+
+func newCycle(now time.Time) cycle {
+	return cycle{Phase: work, Round: 1, Started: now}
+}
+
+func (c cycle) running() bool {
+	return !c.Started.IsZero()
+}
+
+func (c cycle) elapsed(now time.Time) time.Duration {
+	if !c.running() {
+		return c.Done
+	}
+	return c.Done + now.Sub(c.Started)
+}
+
+func (c cycle) remaining(cfg config, now time.Time) time.Duration {
+	return max(0, cfg.length(c.Phase)-c.elapsed(now))
+}
+
+func (c *cycle) pause(now time.Time) {
+	if c.running() {
+		c.Done, c.Started = c.elapsed(now), time.Time{}
+	}
+}
+
+func (c *cycle) resume(now time.Time) {
+	if !c.running() {
+		c.Started = now
+	}
+}
+
+// next moves on to the phase after this one, starting at the given time.
+// Work is followed by a short break, or a long one after the last round.
+func (c *cycle) next(cfg config, at time.Time) {
+	switch {
+	case c.Phase != work:
+		c.Phase = work
+		if c.Round++; c.Round > cfg.Rounds {
+			c.Round = 1
+		}
+	case c.Round >= cfg.Rounds:
+		c.Phase = longBreak
+	default:
+		c.Phase = shortBreak
+	}
+	c.Done = 0
+	if c.running() {
+		c.Started = at
+	}
+}
+
+// finished is a phase that ran its course.
+type finished struct {
+	phase phase
+	at    time.Time
+}
+
+// advance moves past every phase that has run out by now. There can be more
+// than one when the program wasn't running for a while.
+func (c *cycle) advance(cfg config, now time.Time) []finished {
+	var done []finished
+	for c.running() && c.elapsed(now) >= cfg.length(c.Phase) {
+		end := c.Started.Add(cfg.length(c.Phase) - c.Done)
+		done = append(done, finished{c.Phase, end})
+		c.next(cfg, end)
+	}
+	return done
+}
+
+// day is what got done on one day.
+type day struct {
+	Pomodoros int           `json:"pomodoros"`
+	Focus     time.Duration `json:"focus"` // time spent working
+	Breaks    int           `json:"breaks"`
+}
+
+const dayFormat = "2006-01-02"
+
+// stats is what got done, by day.
+type stats map[string]day
+
+// record adds time spent in a phase. Only whole work phases count as
+// pomodoros, but time from skipped ones still counts as focus.
+func (s stats) record(p phase, spent time.Duration, whole bool, at time.Time) {
+	key := at.Local().Format(dayFormat)
+	d := s[key]
+	switch {
+	case p == work:
+		d.Focus += spent
+		if whole {
+			d.Pomodoros++
+		}
+	case whole:
+		d.Breaks++
+	}
+	s[key] = d
+}
+
+// state is what's saved between runs.
+type state struct {
+	Cycle cycle `json:"cycle"`
+	Stats stats `json:"stats"`
+}
+
+// defaultStatePath is where the state goes unless -state says otherwise.
+func defaultStatePath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "pomodoro.json"
+	}
+	return filepath.Join(dir, "bubbletea-pomodoro", "state.json")
+}
+
+// loadState reads the saved state. With nothing saved, a new cycle starts
+// now.
+func loadState(path string, now time.Time) (state, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state{Cycle: newCycle(now), Stats: stats{}}, nil
+	}
+	var s state
+	if err != nil {
+		return s, err
+	}
+	if err := json.Unmarshal(b, &s); err != nil {
+		return s, fmt.Errorf("%s: %w", path, err)
+	}
+	if s.Stats == nil {
+		s.Stats = stats{}
+	}
+	return s, nil
+}
+
+func saveState(path string, s state) error {
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil { //nolint:gosec
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(b, '\n'), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
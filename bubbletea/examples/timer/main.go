@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
 	"time"
@@ -91,6 +92,22 @@ func (m model) View() string {
 }
 
 func main() {
+	var (
+		pomodoroMode = flag.Bool("pomodoro", false, "run a pomodoro timer instead")
+		cfg          config
+		statePath    = flag.String("state", defaultStatePath(), "file to keep the pomodoro state in")
+		notify       = flag.String("notify", string(notifyBell), "how to notify when a phase ends: bell, osc9, osc777 or none")
+	)
+	flag.DurationVar(&cfg.Work, "work", 25*time.Minute, "length of a work session")
+	flag.DurationVar(&cfg.ShortBreak, "short", 5*time.Minute, "length of a short break")
+	flag.DurationVar(&cfg.LongBreak, "long", 15*time.Minute, "length of a long break")
+	flag.IntVar(&cfg.Rounds, "rounds", 4, "work sessions before a long break")
+	flag.Parse()
+
+	if *pomodoroMode {
+		os.Exit(runPomodoro(cfg, *statePath, notifyMode(*notify)))
+	}
+
 	m := model{
 		timer: timer.NewWithInterval(timeout, time.Millisecond),
 		keymap: keymap{
@@ -120,3 +137,28 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+func runPomodoro(cfg config, statePath string, notify notifyMode) int {
+	if err := cfg.validate(); err != nil {
+		fmt.Println("Error:", err)
+		return 2
+	}
+	switch notify {
+	case notifyNone, notifyBell, notifyOSC9, notifyOSC777:
+	default:
+		fmt.Printf("Error: unknown -notify %q\n", notify)
+		return 2
+	}
+
+	st, err := loadState(statePath, time.Now())
+	if err != nil {
+		fmt.Println("Error loading the pomodoro state:", err)
+		return 1
+	}
+	m := newPomodoro(cfg, realClock{}, st, statePath, notify)
+	if _, err := tea.NewProgram(m).Run(); err != nil {
+		fmt.Println("Uh oh, we encountered an error:", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/progress"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const statsDays = 7
+
+var (
+	phaseStyles = map[phase]lipgloss.Style{
+		work:       lipgloss.NewStyle().Foreground(lipgloss.Color("203")).Bold(true),
+		shortBreak: lipgloss.NewStyle().Foreground(lipgloss.Color("42")).Bold(true),
+		longBreak:  lipgloss.NewStyle().Foreground(lipgloss.Color("39")).Bold(true),
+	}
+	pausedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+	faintStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	barStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("203"))
+)
+
+// notifyMode is how we tell the user that a phase is over.
+type notifyMode string
+
+const (
+	notifyNone   notifyMode = "none"
+	notifyBell   notifyMode = "bell"
+	notifyOSC9   notifyMode = "osc9"   // iTerm2, Windows Terminal, and others
+	notifyOSC777 notifyMode = "osc777" // rxvt, foot, Ghostty, and others
+)
+
+// notification returns the escape sequence for a desktop notification. Not
+// every terminal shows them, so the OSC ones ring the bell too.
+func notification(mode notifyMode, title, body string) string {
+	const bell = "\a"
+	switch mode {
+	case notifyBell:
+		return bell
+	case notifyOSC9:
+		return "\x1b]9;" + title + ": " + body + bell + bell
+	case notifyOSC777:
+		return "\x1b]777;notify;" + title + ";" + body + bell + bell
+	}
+	return ""
+}
+
+type pomodoroTickMsg struct{}
+
+func pomodoroTick() tea.Cmd {
+	return tea.Tick(time.Second/4, func(time.Time) tea.Msg {
+		return pomodoroTickMsg{}
+	})
+}
+
+type pomodoroKeymap struct {
+	pause  key.Binding
+	resume key.Binding
+	skip   key.Binding
+	reset  key.Binding
+	stats  key.Binding
+	quit   key.Binding
+}
+
+type pomodoro struct {
+	cfg       config
+	clock     clock
+	state     state
+	statePath string // empty to not save anything
+
+	notify notifyMode
+
+	keymap    pomodoroKeymap
+	help      help.Model
+	progress  progress.Model
+	showStats bool
+	err       error
+	quitting  bool
+}
+
+func newPomodoro(cfg config, c clock, st state, statePath string, notify notifyMode) pomodoro {
+	// The number of rounds may have changed since the state was saved.
+	st.Cycle.Round = max(1, min(st.Cycle.Round, cfg.Rounds))
+
+	m := pomodoro{
+		cfg:       cfg,
+		clock:     c,
+		state:     st,
+		statePath: statePath,
+		notify:    notify,
+		keymap: pomodoroKeymap{
+			pause: key.NewBinding(
+				key.WithKeys(" ", "p"),
+				key.WithHelp("space", "pause"),
+			),
+			resume: key.NewBinding(
+				key.WithKeys(" ", "p"),
+				key.WithHelp("space", "resume"),
+			),
+			skip: key.NewBinding(
+				key.WithKeys("s"),
+				key.WithHelp("s", "skip"),
+			),
+			reset: key.NewBinding(
+				key.WithKeys("r"),
+				key.WithHelp("r", "restart cycle"),
+			),
+			stats: key.NewBinding(
+				key.WithKeys("t"),
+				key.WithHelp("t", "stats"),
+			),
+			quit: key.NewBinding(
+				key.WithKeys("q", "ctrl+c"),
+				key.WithHelp("q", "quit"),
+			),
+		},
+		help:     help.New(),
+		progress: progress.New(progress.WithDefaultGradient(), progress.WithoutPercentage(), progress.WithWidth(40)),
+	}
+	m.updateKeys()
+	return m
+}
+
+func (m pomodoro) Init() tea.Cmd {
+	// Catch up on anything that finished while we weren't running, which
+	// can happen if we were killed rather than quit.
+	return tea.Batch(pomodoroTick(), func() tea.Msg { return pomodoroTickMsg{} })
+}
+
+func (m pomodoro) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	now := m.clock.Now()
+	switch msg := msg.(type) {
+	case pomodoroTickMsg:
+		cmd := m.advance(now)
+		return m, tea.Batch(cmd, pomodoroTick())
+
+	case tea.KeyMsg:
+		m.err = nil
+		switch {
+		case key.Matches(msg, m.keymap.quit):
+			// Pause, so that phases don't carry on while we're not running
+			// and get counted as done next time.
+			m.quitting = true
+			m.state.Cycle.pause(now)
+			m.save()
+			return m, tea.Quit
+		case key.Matches(msg, m.keymap.pause, m.keymap.resume):
+			if m.state.Cycle.running() {
+				m.state.Cycle.pause(now)
+			} else {
+				m.state.Cycle.resume(now)
+			}
+			m.updateKeys()
+			m.save()
+		case key.Matches(msg, m.keymap.skip):
+			c := &m.state.Cycle
+			m.state.Stats.record(c.Phase, c.elapsed(now), false, now)
+			c.next(m.cfg, now)
+			m.save()
+		case key.Matches(msg, m.keymap.reset):
+			m.state.Cycle = newCycle(now)
+			m.updateKeys()
+			m.save()
+		case key.Matches(msg, m.keymap.stats):
+			m.showStats = !m.showStats
+		}
+	}
+	return m, nil
+}
+
+// advance moves on past phases that are over, recording them and letting
+// the user know.
+func (m *pomodoro) advance(now time.Time) tea.Cmd {
+	done := m.state.Cycle.advance(m.cfg, now)
+	if len(done) == 0 {
+		return nil
+	}
+	for _, f := range done {
+		m.state.Stats.record(f.phase, m.cfg.length(f.phase), true, f.at)
+	}
+	m.save()
+
+	// Only the last change is worth telling anyone about. The notification
+	// is printed above the timer, so it goes out between frames rather than
+	// in the middle of one.
+	last := done[len(done)-1]
+	title, body := last.phase.String()+" is over", "Time for: "+strings.ToLower(m.state.Cycle.Phase.String())
+	seq := notification(m.notify, title, body)
+	if seq == "" {
+		return nil
+	}
+	return tea.Println(seq + faintStyle.Render(last.at.Local().Format("15:04")+" "+title))
+}
+
+func (m *pomodoro) save() {
+	if m.statePath == "" {
+		return
+	}
+	if err := saveState(m.statePath, m.state); err != nil {
+		m.err = err
+	}
+}
+
+func (m *pomodoro) updateKeys() {
+	m.keymap.pause.SetEnabled(m.state.Cycle.running())
+	m.keymap.resume.SetEnabled(!m.state.Cycle.running())
+}
+
+func (m pomodoro) View() string {
+	if m.quitting {
+		return ""
+	}
+	var s string
+	if m.showStats {
+		s = m.statsView()
+	} else {
+		s = m.timerView()
+	}
+	if m.err != nil {
+		s += "\n" + pausedStyle.Render("Couldn't save: "+m.err.Error())
+	}
+	return s + "\n" + m.help.ShortHelpView([]key.Binding{
+		m.keymap.pause,
+		m.keymap.resume,
+		m.keymap.skip,
+		m.keymap.reset,
+		m.keymap.stats,
+		m.keymap.quit,
+	}) + "\n"
+}
+
+func (m pomodoro) timerView() string {
+	now := m.clock.Now()
+	c := m.state.Cycle
+	left := c.remaining(m.cfg, now)
+
+	title := phaseStyles[c.Phase].Render(c.Phase.String())
+	title += faintStyle.Render(fmt.Sprintf("  round %d of %d", c.Round, m.cfg.Rounds))
+	if !c.running() {
+		title += "  " + pausedStyle.Render("paused")
+	}
+
+	done := float64(c.elapsed(now)) / float64(m.cfg.length(c.Phase))
+	today := m.state.Stats[now.Local().Format(dayFormat)]
+	return fmt.Sprintf("\n%s\n\n%s  %s\n\n%s\n",
+		title,
+		formatClock(left),
+		m.progress.ViewAs(min(done, 1)),
+		faintStyle.Render(fmt.Sprintf("Today: %d %s, %s focused",
+			today.Pomodoros, plural(today.Pomodoros, "pomodoro"), formatClock(today.Focus))),
+	)
+}
+
+// statsView shows the last week, a bar per day.
+func (m pomodoro) statsView() string {
+	var b strings.Builder
+	b.WriteString("\n" + phaseStyles[work].Render("Last 7 days") + "\n\n")
+
+	now := m.clock.Now().Local()
+	most := 1
+	for i := range statsDays {
+		most = max(most, m.state.Stats[now.AddDate(0, 0, -i).Format(dayFormat)].Pomodoros)
+	}
+	var total day
+	for i := statsDays - 1; i >= 0; i-- {
+		date := now.AddDate(0, 0, -i)
+		d := m.state.Stats[date.Format(dayFormat)]
+		total.Pomodoros += d.Pomodoros
+		total.Focus += d.Focus
+		bar := barStyle.Render(strings.Repeat("█", d.Pomodoros*20/most))
+		fmt.Fprintf(&b, "%s  %3d  %8s  %s\n", date.Format("Mon 01-02"), d.Pomodoros, formatClock(d.Focus), bar)
+	}
+	b.WriteString(faintStyle.Render(fmt.Sprintf("\nTotal: %d %s, %s focused",
+		total.Pomodoros, plural(total.Pomodoros, "pomodoro"), formatClock(total.Focus))) + "\n")
+	return b.String()
+}
+
+// formatClock shows a duration as minutes and seconds, with hours if there
+// are any.
+func formatClock(d time.Duration) string {
+	d = d.Round(time.Second)
+	h, m, s := int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	}
+	return fmt.Sprintf("%02d:%02d", m, s)
+}
+
+func plural(n int, s string) string {
+	if n == 1 {
+		return s
+	}
+	return s + "s"
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// fakeClock only moves when told to.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) advance(d time.Duration) { c.now = c.now.Add(d) }
+
+var testConfig = config{
+	Work:       25 * time.Minute,
+	ShortBreak: 5 * time.Minute,
+	LongBreak:  15 * time.Minute,
+	Rounds:     2,
+}
+
+func TestCycle(t *testing.T) {
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.Local)
+	c := newCycle(start)
+
+	// Work, short break, work, long break, then work again in round 1.
+	done := c.advance(testConfig, start.Add(24*time.Minute))
+	if len(done) != 0 || c.remaining(testConfig, start.Add(24*time.Minute)) != time.Minute {
+		t.Fatalf("advanced early: %v", done)
+	}
+	now := start.Add(25*time.Minute + 5*time.Minute + 25*time.Minute + 15*time.Minute + time.Minute)
+	done = c.advance(testConfig, now)
+	want := []phase{work, shortBreak, work, longBreak}
+	if len(done) != len(want) {
+		t.Fatalf("finished %d phases, want %d", len(done), len(want))
+	}
+	for i, f := range done {
+		if f.phase != want[i] {
+			t.Errorf("phase %d was %v, want %v", i, f.phase, want[i])
+		}
+	}
+	if done[3].at != now.Add(-time.Minute) {
+		t.Errorf("long break ended at %v, want %v", done[3].at, now.Add(-time.Minute))
+	}
+	if c.Phase != work || c.Round != 1 || c.elapsed(now) != time.Minute {
+		t.Errorf("cycle = %+v, want a minute into round 1", c)
+	}
+
+	// Paused time doesn't count.
+	c.pause(now)
+	now = now.Add(time.Hour)
+	if done := c.advance(testConfig, now); len(done) != 0 || c.elapsed(now) != time.Minute {
+		t.Errorf("time passed while paused: %v, %v", done, c.elapsed(now))
+	}
+	c.resume(now)
+	if got := c.remaining(testConfig, now.Add(4*time.Minute)); got != 20*time.Minute {
+		t.Errorf("remaining after resuming = %v, want 20m", got)
+	}
+}
+
+func TestPomodoro(t *testing.T) {
+	clk := &fakeClock{now: time.Date(2024, 3, 1, 9, 0, 0, 0, time.Local)}
+	path := filepath.Join(t.TempDir(), "state.json")
+	st, err := loadState(path, clk.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m tea.Model = newPomodoro(testConfig, clk, st, path, notifyOSC777)
+
+	// Ticks schedule the next tick, which would wait, so call advance
+	// directly and keep what the notification it returns would print.
+	var out string
+	tick := func() {
+		p := m.(pomodoro)
+		if cmd := p.advance(clk.Now()); cmd != nil {
+			out += fmt.Sprint(cmd())
+		}
+		m = p
+	}
+
+	clk.advance(10 * time.Minute)
+	tick()
+	if v := m.View(); !strings.Contains(v, "Work") || !strings.Contains(v, "15:00") {
+		t.Errorf("view doesn't show 15 minutes of work left:\n%s", v)
+	}
+	if out != "" {
+		t.Errorf("notified early: %q", out)
+	}
+
+	clk.advance(15 * time.Minute)
+	tick()
+	if !strings.Contains(out, "\x1b]777;notify;Work is over;Time for: short break\a\a") || !strings.Contains(out, "09:25 Work is over") {
+		t.Errorf("notification = %q", out)
+	}
+	if v := m.View(); !strings.Contains(v, "Short break") || !strings.Contains(v, "Today: 1 pomodoro, 25:00 focused") {
+		t.Errorf("view after work:\n%s", v)
+	}
+
+	// Pause, then pick up where we left off after a restart.
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+	clk.advance(time.Hour)
+	st, err = loadState(path, clk.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	m = newPomodoro(testConfig, clk, st, path, notifyNone)
+	if v := m.View(); !strings.Contains(v, "paused") || !strings.Contains(v, "05:00") {
+		t.Errorf("view after a restart:\n%s", v)
+	}
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'t'}})
+	if v := m.View(); !strings.Contains(v, "Fri 03-01    1     25:00") {
+		t.Errorf("stats don't show today's pomodoro:\n%s", v)
+	}
+}
+
+func TestQuitPauses(t *testing.T) {
+	clk := &fakeClock{now: time.Date(2024, 3, 1, 9, 0, 0, 0, time.Local)}
+	path := filepath.Join(t.TempDir(), "state.json")
+	st, err := loadState(path, clk.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m tea.Model = newPomodoro(testConfig, clk, st, path, notifyNone)
+	clk.advance(10 * time.Minute)
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'q'}})
+
+	// A weekend away doesn't count as a weekend of pomodoros.
+	clk.advance(48 * time.Hour)
+	if st, err = loadState(path, clk.Now()); err != nil {
+		t.Fatal(err)
+	}
+	p := newPomodoro(testConfig, clk, st, path, notifyNone)
+	p.advance(clk.Now())
+	if v := p.View(); !strings.Contains(v, "paused") || !strings.Contains(v, "15:00") || !strings.Contains(v, "Today: 0 pomodoros") {
+		t.Errorf("view after a weekend away:\n%s", v)
+	}
+}
+
+func TestNotification(t *testing.T) {
+	if got := notification(notifyOSC9, "Work is over", "Time for: short break"); got != "\x1b]9;Work is over: Time for: short break\a\a" {
+		t.Errorf("osc9 = %q", got)
+	}
+	if got := notification(notifyNone, "a", "b"); got != "" {
+		t.Errorf("none = %q", got)
+	}
+}
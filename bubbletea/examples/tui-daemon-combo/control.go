@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"time"
+)
+
+// The control socket speaks one JSON request and one JSON response per
+// connection.
+
+type request struct {
+	Op string `json:"op"` // list, output, cancel or requeue
+	ID int    `json:"id,omitempty"`
+}
+
+type response struct {
+	Jobs   []jobInfo `json:"jobs,omitempty"`
+	Output string    `json:"output,omitempty"`
+	Error  string    `json:"error,omitempty"`
+}
+
+var errNoDaemon = errors.New("no daemon running")
+
+// listen opens the control socket, cleaning up after a daemon that didn't
+// exit cleanly. Anything at path that isn't a socket is left alone.
+func listen(path string) (net.Listener, error) {
+	if conn, err := net.Dial("unix", path); err == nil {
+		conn.Close() //nolint:errcheck
+		return nil, fmt.Errorf("a daemon is already listening on %s", path)
+	}
+	fi, err := os.Lstat(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, err
+	case fi.Mode().Type() != os.ModeSocket:
+		return nil, fmt.Errorf("%s already exists and isn't a socket", path)
+	default:
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+	return net.Listen("unix", path)
+}
+
+// serve answers control requests until the listener is closed.
+func serve(l net.Listener, q *queue) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close() //nolint:errcheck
+			_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+			var req request
+			if err := json.NewDecoder(conn).Decode(&req); err != nil {
+				log.Printf("Bad control request: %v", err)
+				return
+			}
+			if err := json.NewEncoder(conn).Encode(handle(q, req)); err != nil {
+				log.Printf("Could not write control response: %v", err)
+			}
+		}()
+	}
+}
+
+func handle(q *queue, req request) response {
+	var (
+		res response
+		err error
+	)
+	switch req.Op {
+	case "list":
+		res.Jobs = q.list()
+	case "output":
+		res.Output, err = q.output(req.ID)
+	case "cancel":
+		err = q.cancel(req.ID)
+	case "requeue":
+		err = q.requeue(req.ID)
+	default:
+		err = fmt.Errorf("unknown op %q", req.Op)
+	}
+	if err != nil {
+		res.Error = err.Error()
+	}
+	return res
+}
+
+// call sends a request to the daemon listening on path.
+func call(path string, req request) (response, error) {
+	conn, err := net.DialTimeout("unix", path, time.Second)
+	if err != nil {
+		return response{}, errNoDaemon
+	}
+	defer conn.Close() //nolint:errcheck
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return response{}, err
+	}
+	var res response
+	if err := json.NewDecoder(conn).Decode(&res); err != nil {
+		return response{}, err
+	}
+	if res.Error != "" {
+		return res, errors.New(res.Error)
+	}
+	return res, nil
+}
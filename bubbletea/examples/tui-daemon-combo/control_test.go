@@ -0,0 +1,97 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// socketPath returns a path for a socket. Socket paths have to be short, so
+// it doesn't use t.TempDir.
+func socketPath(t *testing.T) string {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "daemon")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) }) //nolint:errcheck
+	return filepath.Join(dir, "sock")
+}
+
+func TestControl(t *testing.T) {
+	path := socketPath(t)
+	if _, err := call(path, request{Op: "list"}); !errors.Is(err, errNoDaemon) {
+		t.Errorf("calling with no daemon: %v", err)
+	}
+
+	l, err := listen(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close() //nolint:errcheck
+	if _, err := listen(path); err == nil || !strings.Contains(err.Error(), "already listening") {
+		t.Errorf("listening twice: %v", err)
+	}
+
+	q := newQueue(1)
+	defer q.close()
+	id := q.add("echo hi")
+	q.start()
+	go serve(l, q)
+	waitFor(t, q, id, stateDone)
+
+	res, err := call(path, request{Op: "list"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Jobs) != 1 || res.Jobs[0].Command != "echo hi" || res.Jobs[0].State != stateDone {
+		t.Errorf("list = %+v", res.Jobs)
+	}
+	if res, err = call(path, request{Op: "output", ID: id}); err != nil || res.Output != "hi\n" {
+		t.Errorf("output = %q, %v", res.Output, err)
+	}
+	if _, err := call(path, request{Op: "requeue", ID: id}); err != nil {
+		t.Errorf("requeue: %v", err)
+	}
+	waitFor(t, q, id, stateDone)
+
+	// Errors come back as errors.
+	if _, err := call(path, request{Op: "cancel", ID: id}); err == nil || !strings.Contains(err.Error(), "already done") {
+		t.Errorf("cancel: %v", err)
+	}
+	if _, err := call(path, request{Op: "output", ID: 9}); err == nil || !strings.Contains(err.Error(), "no such job") {
+		t.Errorf("output of a missing job: %v", err)
+	}
+	if _, err := call(path, request{Op: "explode"}); err == nil || !strings.Contains(err.Error(), "unknown op") {
+		t.Errorf("unknown op: %v", err)
+	}
+}
+
+func TestListenCleansUp(t *testing.T) {
+	// A socket left behind by a daemon that crashed is replaced.
+	path := socketPath(t)
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.(*net.UnixListener).SetUnlinkOnClose(false)
+	l.Close() //nolint:errcheck
+	if l, err = listen(path); err != nil {
+		t.Fatalf("listening over a stale socket: %v", err)
+	}
+	l.Close() //nolint:errcheck
+
+	// Anything else is left alone.
+	if err := os.WriteFile(path, []byte("precious"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := listen(path); err == nil || !strings.Contains(err.Error(), "isn't a socket") {
+		t.Errorf("listening over a file: %v", err)
+	}
+	if b, err := os.ReadFile(path); err != nil || string(b) != "precious" {
+		t.Errorf("file is now %q, %v", b, err)
+	}
+}
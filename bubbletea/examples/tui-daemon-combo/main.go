@@ -1,12 +1,14 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"log"
-	"math/rand"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/charmbracelet/bubbles/spinner"
@@ -16,19 +18,42 @@ import (
 )
 
 var (
-	helpStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render
-	mainStyle = lipgloss.NewStyle().MarginLeft(1)
+	helpStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render
+	mainStyle   = lipgloss.NewStyle().MarginLeft(1)
+	cursorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("206")).Bold(true)
+	errorStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	outputStyle = lipgloss.NewStyle().
+			Border(lipgloss.NormalBorder(), false, false, false, true).
+			BorderForeground(lipgloss.Color("241")).
+			PaddingLeft(1)
+	stateStyles = map[jobState]lipgloss.Style{
+		stateQueued:   lipgloss.NewStyle().Foreground(lipgloss.Color("241")),
+		stateRunning:  lipgloss.NewStyle().Foreground(lipgloss.Color("206")),
+		stateDone:     lipgloss.NewStyle().Foreground(lipgloss.Color("42")),
+		stateFailed:   lipgloss.NewStyle().Foreground(lipgloss.Color("9")),
+		stateCanceled: lipgloss.NewStyle().Foreground(lipgloss.Color("214")),
+	}
+)
+
+const (
+	pollInterval = 500 * time.Millisecond
+	outputLines  = 10
 )
 
 func main() {
 	var (
 		daemonMode bool
 		showHelp   bool
-		opts       []tea.ProgramOption
+		jobsFile   string
+		socketPath string
+		workers    int
 	)
 
 	flag.BoolVar(&daemonMode, "d", false, "run as a daemon")
 	flag.BoolVar(&showHelp, "h", false, "show help")
+	flag.StringVar(&jobsFile, "jobs", "jobs.txt", "file with one shell command per line (daemon mode)")
+	flag.StringVar(&socketPath, "socket", filepath.Join(os.TempDir(), "tui-daemon-combo.sock"), "control socket path")
+	flag.IntVar(&workers, "workers", 2, "number of jobs to run at once (daemon mode)")
 	flag.Parse()
 
 	if showHelp {
@@ -36,104 +61,270 @@ func main() {
 		os.Exit(0)
 	}
 
+	var err error
 	if daemonMode || !isatty.IsTerminal(os.Stdout.Fd()) {
-		// If we're in daemon mode don't render the TUI
-		opts = []tea.ProgramOption{tea.WithoutRenderer()}
+		err = runDaemon(jobsFile, socketPath, workers)
 	} else {
-		// If we're in TUI mode, discard log output
-		log.SetOutput(io.Discard)
+		err = runTUI(socketPath)
 	}
-
-	p := tea.NewProgram(newModel(), opts...)
-	if _, err := p.Run(); err != nil {
-		fmt.Println("Error starting Bubble Tea program:", err)
+	if err != nil {
+		fmt.Println("Error:", err)
 		os.Exit(1)
 	}
 }
 
-type result struct {
-	duration time.Duration
-	emoji    string
+// runDaemon runs the job queue and control socket. The daemon is still a
+// Bubble Tea program, it just doesn't render anything.
+func runDaemon(jobsFile, socketPath string, workers int) error {
+	cmds, err := readJobs(jobsFile)
+	if err != nil {
+		return err
+	}
+
+	l, err := listen(socketPath)
+	if err != nil {
+		return err
+	}
+	defer l.Close() //nolint:errcheck
+
+	q := newQueue(workers)
+	for _, c := range cmds {
+		q.add(c)
+	}
+
+	// Send blocks until the program is running, so only hook this up after
+	// queueing the initial jobs.
+	p := tea.NewProgram(daemonModel{}, tea.WithoutRenderer(), tea.WithInput(nil))
+	q.notify = func(info jobInfo) { p.Send(jobChangedMsg(info)) }
+
+	log.Printf("Queued %d jobs, listening on %s with %d workers", len(cmds), socketPath, workers)
+	go serve(l, q)
+	q.start()
+
+	_, err = p.Run()
+	log.Println("Shutting down...")
+	q.close()
+	if errors.Is(err, tea.ErrInterrupted) {
+		return nil
+	}
+	return err
 }
 
+// runTUI attaches a dashboard to a running daemon.
+func runTUI(socketPath string) error {
+	if _, err := call(socketPath, request{Op: "list"}); err != nil {
+		return fmt.Errorf("%w on %s, start one with -d", err, socketPath)
+	}
+
+	// If we're in TUI mode, discard log output
+	log.SetOutput(io.Discard)
+
+	_, err := tea.NewProgram(newModel(socketPath)).Run()
+	return err
+}
+
+// jobChangedMsg is sent to the daemon when a job changes state.
+type jobChangedMsg jobInfo
+
+type daemonModel struct{}
+
+func (m daemonModel) Init() tea.Cmd {
+	log.Println("Starting work...")
+	return nil
+}
+
+func (m daemonModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(jobChangedMsg); ok {
+		switch msg.State {
+		case stateQueued:
+			log.Printf("Job %d queued: %s", msg.ID, msg.Command)
+		case stateRunning:
+			log.Printf("Job %d started", msg.ID)
+		default:
+			log.Printf("Job %d %s in %s (exit %d)", msg.ID, msg.State, msg.Finished.Sub(msg.Started).Round(time.Millisecond), msg.ExitCode)
+		}
+	}
+	return m, nil
+}
+
+func (m daemonModel) View() string { return "" }
+
+type (
+	statusMsg struct {
+		jobs   []jobInfo
+		output string
+	}
+	errMsg  struct{ err error }
+	tickMsg time.Time
+)
+
 type model struct {
+	socket   string
 	spinner  spinner.Model
-	results  []result
+	jobs     []jobInfo
+	cursor   int
+	output   string
+	err      error
 	quitting bool
 }
 
-func newModel() model {
-	const showLastResults = 5
-
+func newModel(socket string) model {
 	sp := spinner.New()
 	sp.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("206"))
 
 	return model{
+		socket:  socket,
 		spinner: sp,
-		results: make([]result, showLastResults),
 	}
 }
 
 func (m model) Init() tea.Cmd {
-	log.Println("Starting work...")
 	return tea.Batch(
 		m.spinner.Tick,
-		runPretendProcess,
+		m.fetch(),
+		tick(),
 	)
 }
 
+func (m model) selected() int {
+	if m.cursor < len(m.jobs) {
+		return m.jobs[m.cursor].ID
+	}
+	return 0
+}
+
+// fetch asks the daemon for the job list and the selected job's output.
+func (m model) fetch() tea.Cmd {
+	id := m.selected()
+	return func() tea.Msg {
+		res, err := call(m.socket, request{Op: "list"})
+		if err != nil {
+			return errMsg{err}
+		}
+		status := statusMsg{jobs: res.Jobs}
+		if id > 0 {
+			res, err := call(m.socket, request{Op: "output", ID: id})
+			if err != nil {
+				return errMsg{err}
+			}
+			status.output = res.Output
+		}
+		return status
+	}
+}
+
+func (m model) control(op string) tea.Cmd {
+	id := m.selected()
+	if id == 0 {
+		return nil
+	}
+	return func() tea.Msg {
+		if _, err := call(m.socket, request{Op: op, ID: id}); err != nil {
+			return errMsg{err}
+		}
+		// Refresh right away rather than waiting for the next poll.
+		return m.fetch()()
+	}
+}
+
+func tick() tea.Cmd {
+	return tea.Tick(pollInterval, func(t time.Time) tea.Msg {
+		return tickMsg(t)
+	})
+}
+
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		m.quitting = true
-		return m, tea.Quit
+		switch msg.String() {
+		case "q", "esc", "ctrl+c":
+			m.quitting = true
+			return m, tea.Quit
+		case "up", "k":
+			if m.cursor > 0 {
+				m.cursor--
+				m.output = ""
+			}
+			return m, nil
+		case "down", "j":
+			if m.cursor < len(m.jobs)-1 {
+				m.cursor++
+				m.output = ""
+			}
+			return m, nil
+		case "c":
+			return m, m.control("cancel")
+		case "r":
+			return m, m.control("requeue")
+		}
+		return m, nil
 	case spinner.TickMsg:
 		var cmd tea.Cmd
 		m.spinner, cmd = m.spinner.Update(msg)
 		return m, cmd
-	case processFinishedMsg:
-		d := time.Duration(msg)
-		res := result{emoji: randomEmoji(), duration: d}
-		log.Printf("%s Job finished in %s", res.emoji, res.duration)
-		m.results = append(m.results[1:], res)
-		return m, runPretendProcess
+	case tickMsg:
+		return m, tea.Batch(m.fetch(), tick())
+	case statusMsg:
+		m.err = nil
+		m.jobs = msg.jobs
+		m.cursor = min(m.cursor, max(len(m.jobs)-1, 0))
+		m.output = msg.output
+		return m, nil
+	case errMsg:
+		// Keep polling, the daemon might come back.
+		m.err = msg.err
+		return m, nil
 	default:
 		return m, nil
 	}
 }
 
 func (m model) View() string {
-	s := "\n" +
-		m.spinner.View() + " Doing some work...\n\n"
-
-	for _, res := range m.results {
-		if res.duration == 0 {
-			s += "........................\n"
-		} else {
-			s += fmt.Sprintf("%s Job finished in %s\n", res.emoji, res.duration)
+	var b strings.Builder
+	b.WriteString("\n")
+
+	if len(m.jobs) == 0 {
+		b.WriteString(m.spinner.View() + " Waiting for jobs...\n")
+	}
+	for i, j := range m.jobs {
+		cursor := "  "
+		if i == m.cursor {
+			cursor = cursorStyle.Render("> ")
 		}
+		icon := " "
+		if j.State == stateRunning {
+			icon = m.spinner.View()
+		}
+		fmt.Fprintf(&b, "%s%s %3d %s %s\n",
+			cursor,
+			icon,
+			j.ID,
+			stateStyles[j.State].Width(9).Render(j.State.String()),
+			j.Command,
+		)
 	}
 
-	s += helpStyle("\nPress any key to exit\n")
+	if out := lastLines(m.output, outputLines); out != "" {
+		b.WriteString("\n" + outputStyle.Render(out) + "\n")
+	}
 
-	if m.quitting {
-		s += "\n"
+	if m.err != nil {
+		b.WriteString("\n" + errorStyle.Render(m.err.Error()) + "\n")
 	}
 
-	return mainStyle.Render(s)
-}
+	b.WriteString(helpStyle("\n↑/↓: select • c: cancel • r: re-queue • q: detach\n"))
 
-// processFinishedMsg is sent when a pretend process completes.
-type processFinishedMsg time.Duration
+	if m.quitting {
+		b.WriteString("\n")
+	}
 
-// pretendProcess simulates a long-running process.
-func runPretendProcess() tea.Msg {
-	pause := time.Duration(rand.Int63n(899)+100) * time.Millisecond // nolint:gosec
-	time.Sleep(pause)
-	return processFinishedMsg(pause)
+	return mainStyle.Render(b.String())
 }
 
-func randomEmoji() string {
-	emojis := []rune("🍦🧋🍡🤠👾😭🦊🐯🦆🥨🎏🍔🍒🍥🎮📦🦁🐶🐸🍕🥐🧲🚒🥇🏆🌽")
-	return string(emojis[rand.Intn(len(emojis))]) // nolint:gosec
+func lastLines(s string, n int) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
 }
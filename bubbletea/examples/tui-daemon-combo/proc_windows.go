@@ -0,0 +1,7 @@
+//go:build windows
+
+package main
+
+import "os/exec"
+
+func killGroup(*exec.Cmd) {}
@@ -0,0 +1,343 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxOutput is how many bytes of output we keep for each job.
+const maxOutput = 64 * 1024
+
+type jobState int
+
+const (
+	stateQueued jobState = iota
+	stateRunning
+	stateDone
+	stateFailed
+	stateCanceled
+)
+
+func (s jobState) String() string {
+	switch s {
+	case stateQueued:
+		return "queued"
+	case stateRunning:
+		return "running"
+	case stateDone:
+		return "done"
+	case stateFailed:
+		return "failed"
+	case stateCanceled:
+		return "canceled"
+	default:
+		return "unknown"
+	}
+}
+
+// finished reports whether a job in this state can be re-queued.
+func (s jobState) finished() bool {
+	return s == stateDone || s == stateFailed || s == stateCanceled
+}
+
+// jobInfo is a snapshot of a job. It's what we send over the control socket.
+type jobInfo struct {
+	ID       int       `json:"id"`
+	Command  string    `json:"command"`
+	State    jobState  `json:"state"`
+	ExitCode int       `json:"exit_code"`
+	Started  time.Time `json:"started"`
+	Finished time.Time `json:"finished"`
+}
+
+type job struct {
+	jobInfo
+	output   *tailBuffer
+	cancel   context.CancelFunc
+	canceled bool
+}
+
+// queue runs shell commands on a fixed number of workers.
+type queue struct {
+	ctx     context.Context
+	stop    context.CancelFunc
+	workers int
+	wake    chan struct{}
+	wg      sync.WaitGroup
+
+	// notify, if set, is called whenever a job changes state.
+	notify func(jobInfo)
+
+	mu   sync.Mutex
+	jobs []*job
+}
+
+func newQueue(workers int) *queue {
+	ctx, stop := context.WithCancel(context.Background())
+	return &queue{
+		ctx:     ctx,
+		stop:    stop,
+		workers: max(workers, 1),
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+// readJobs reads one shell command per line from a file. Blank lines and
+// lines starting with # are ignored.
+func readJobs(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() //nolint:errcheck
+
+	var cmds []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		cmds = append(cmds, line)
+	}
+	return cmds, scanner.Err()
+}
+
+// add queues a command and returns its job ID.
+func (q *queue) add(command string) int {
+	q.mu.Lock()
+	j := &job{
+		jobInfo: jobInfo{ID: len(q.jobs) + 1, Command: command},
+		output:  &tailBuffer{max: maxOutput},
+	}
+	q.jobs = append(q.jobs, j)
+	info := j.jobInfo
+	q.mu.Unlock()
+
+	q.changed(info)
+	q.signal()
+	return info.ID
+}
+
+// start starts the workers.
+func (q *queue) start() {
+	for range q.workers {
+		q.wg.Add(1)
+		go func() {
+			defer q.wg.Done()
+			for {
+				j, ctx := q.next()
+				if j == nil {
+					return
+				}
+				q.run(ctx, j)
+			}
+		}()
+	}
+}
+
+// close cancels all running jobs and waits for the workers to exit.
+func (q *queue) close() {
+	q.stop()
+	q.wg.Wait()
+}
+
+func (q *queue) signal() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// next blocks until there's a queued job and marks it as running. It returns
+// nil once the queue is closed.
+func (q *queue) next() (*job, context.Context) {
+	for {
+		q.mu.Lock()
+		var (
+			picked *job
+			more   bool
+		)
+		for _, j := range q.jobs {
+			if j.State != stateQueued {
+				continue
+			}
+			if picked != nil {
+				more = true
+				break
+			}
+			picked = j
+		}
+		if picked != nil {
+			ctx, cancel := context.WithCancel(q.ctx)
+			picked.State = stateRunning
+			picked.Started = time.Now()
+			picked.cancel = cancel
+			info := picked.jobInfo
+			q.mu.Unlock()
+
+			// Pass the wakeup on so another idle worker can pick up the rest.
+			if more {
+				q.signal()
+			}
+			q.changed(info)
+			return picked, ctx
+		}
+		q.mu.Unlock()
+
+		select {
+		case <-q.ctx.Done():
+			return nil, nil
+		case <-q.wake:
+		}
+	}
+}
+
+func (q *queue) run(ctx context.Context, j *job) {
+	cmd := exec.CommandContext(ctx, "sh", "-c", j.Command) //nolint:gosec
+	cmd.Stdout = j.output
+	cmd.Stderr = j.output
+	cmd.WaitDelay = time.Second
+	killGroup(cmd)
+	err := cmd.Run()
+
+	q.mu.Lock()
+	j.cancel()
+	j.cancel = nil
+	j.Finished = time.Now()
+	j.ExitCode = cmd.ProcessState.ExitCode()
+	switch {
+	case j.canceled || q.ctx.Err() != nil:
+		j.State = stateCanceled
+	case err != nil:
+		j.State = stateFailed
+		fmt.Fprintf(j.output, "\n%s\n", err)
+	default:
+		j.State = stateDone
+	}
+	info := j.jobInfo
+	q.mu.Unlock()
+
+	q.changed(info)
+}
+
+func (q *queue) changed(info jobInfo) {
+	if q.notify != nil {
+		q.notify(info)
+	}
+}
+
+func (q *queue) list() []jobInfo {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	infos := make([]jobInfo, len(q.jobs))
+	for i, j := range q.jobs {
+		infos[i] = j.jobInfo
+	}
+	return infos
+}
+
+// get must be called with q.mu held.
+func (q *queue) get(id int) (*job, error) {
+	if id < 1 || id > len(q.jobs) {
+		return nil, fmt.Errorf("no such job: %d", id)
+	}
+	return q.jobs[id-1], nil
+}
+
+func (q *queue) output(id int) (string, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	j, err := q.get(id)
+	if err != nil {
+		return "", err
+	}
+	return j.output.String(), nil
+}
+
+// cancel cancels a queued or running job.
+func (q *queue) cancel(id int) error {
+	q.mu.Lock()
+	j, err := q.get(id)
+	if err != nil {
+		q.mu.Unlock()
+		return err
+	}
+	switch j.State {
+	case stateQueued:
+		j.State = stateCanceled
+		j.Finished = time.Now()
+	case stateRunning:
+		// The worker will notice the job exiting and update its state.
+		j.canceled = true
+		j.cancel()
+		q.mu.Unlock()
+		return nil
+	default:
+		q.mu.Unlock()
+		return fmt.Errorf("job %d is already %s", id, j.State)
+	}
+	info := j.jobInfo
+	q.mu.Unlock()
+
+	q.changed(info)
+	return nil
+}
+
+// requeue puts a finished job back in the queue.
+func (q *queue) requeue(id int) error {
+	q.mu.Lock()
+	j, err := q.get(id)
+	if err != nil {
+		q.mu.Unlock()
+		return err
+	}
+	if !j.State.finished() {
+		q.mu.Unlock()
+		return fmt.Errorf("job %d is still %s", id, j.State)
+	}
+	j.jobInfo = jobInfo{ID: j.ID, Command: j.Command}
+	j.canceled = false
+	j.output.Reset()
+	info := j.jobInfo
+	q.mu.Unlock()
+
+	q.changed(info)
+	q.signal()
+	return nil
+}
+
+// tailBuffer is an io.Writer that keeps the last max bytes written to it.
+type tailBuffer struct {
+	mu  sync.Mutex
+	max int
+	buf []byte
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf = append(b.buf, p...)
+	if over := len(b.buf) - b.max; over > 0 {
+		b.buf = b.buf[over:]
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return string(b.buf)
+}
+
+func (b *tailBuffer) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf = nil
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// waitFor waits for a job to get to a state.
+func waitFor(t *testing.T, q *queue, id int, state jobState) jobInfo {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		info := q.list()[id-1]
+		if info.State == state {
+			return info
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job %d is %s, want %s", id, info.State, state)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestQueue(t *testing.T) {
+	q := newQueue(2)
+	defer q.close()
+
+	changes := make(chan jobInfo, 100)
+	q.notify = func(info jobInfo) { changes <- info }
+
+	ok := q.add("echo hello; echo oops >&2")
+	bad := q.add("exit 3")
+	q.start()
+
+	waitFor(t, q, ok, stateDone)
+	if out, err := q.output(ok); err != nil || out != "hello\noops\n" {
+		t.Errorf("output = %q, %v", out, err)
+	}
+	if info := waitFor(t, q, bad, stateFailed); info.ExitCode != 3 || info.Finished.Before(info.Started) {
+		t.Errorf("failed job = %+v", info)
+	}
+	if out, _ := q.output(bad); !strings.Contains(out, "exit status 3") {
+		t.Errorf("failed job's output = %q", out)
+	}
+
+	// Every job went through queued, running and finished, in order.
+	var states []string
+	for range 6 {
+		info := <-changes
+		if info.ID == ok {
+			states = append(states, info.State.String())
+		}
+	}
+	if got := strings.Join(states, " "); got != "queued running done" {
+		t.Errorf("job %d went %s", ok, got)
+	}
+
+	// A finished job can run again, from scratch.
+	if err := q.requeue(bad); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, q, bad, stateFailed)
+	if out, _ := q.output(bad); strings.Count(out, "exit status 3") != 1 {
+		t.Errorf("output after re-queueing = %q", out)
+	}
+
+	if err := q.cancel(ok); err == nil || !strings.Contains(err.Error(), "already done") {
+		t.Errorf("canceling a finished job: %v", err)
+	}
+	if _, err := q.output(42); err == nil {
+		t.Error("got the output of a job that doesn't exist")
+	}
+}
+
+func TestCancel(t *testing.T) {
+	q := newQueue(1)
+	defer q.close()
+
+	// The child of the shell goes along with it.
+	marker := filepath.Join(t.TempDir(), "marker")
+	slow := q.add("(sleep 1; touch " + marker + ") & wait")
+	next := q.add("echo next")
+	q.start()
+	waitFor(t, q, slow, stateRunning)
+
+	if err := q.requeue(slow); err == nil || !strings.Contains(err.Error(), "still running") {
+		t.Errorf("re-queueing a running job: %v", err)
+	}
+
+	// With one worker the second job waits, and can be canceled before it
+	// starts.
+	if err := q.cancel(next); err != nil {
+		t.Fatal(err)
+	}
+	if info := q.list()[next-1]; info.State != stateCanceled || !info.Started.IsZero() {
+		t.Errorf("canceled queued job = %+v", info)
+	}
+
+	start := time.Now()
+	if err := q.cancel(slow); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, q, slow, stateCanceled)
+	if d := time.Since(start); d > 500*time.Millisecond {
+		t.Errorf("canceling took %v", d)
+	}
+	time.Sleep(1500 * time.Millisecond)
+	if _, err := os.Stat(marker); err == nil {
+		t.Error("the job's child outlived it")
+	}
+	if out, _ := q.output(next); out != "" {
+		t.Errorf("canceled job ran: %q", out)
+	}
+}
+
+func TestClose(t *testing.T) {
+	q := newQueue(1)
+	id := q.add("sleep 10")
+	q.start()
+	waitFor(t, q, id, stateRunning)
+
+	done := make(chan struct{})
+	go func() {
+		q.close()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("close didn't stop the running job")
+	}
+	if info := q.list()[id-1]; info.State != stateCanceled {
+		t.Errorf("job is %s after closing", info.State)
+	}
+}
+
+func TestTailBuffer(t *testing.T) {
+	b := &tailBuffer{max: 5}
+	for _, s := range []string{"abc", "defg", "h"} {
+		if n, err := b.Write([]byte(s)); n != len(s) || err != nil {
+			t.Fatalf("Write(%q) = %d, %v", s, n, err)
+		}
+	}
+	if got := b.String(); got != "defgh" {
+		t.Errorf("kept %q, want the last 5 bytes", got)
+	}
+	b.Reset()
+	if got := b.String(); got != "" {
+		t.Errorf("after Reset: %q", got)
+	}
+}
+
+func TestReadJobs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.txt")
+	if err := os.WriteFile(path, []byte("# build\n  make  \n\necho hi\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cmds, err := readJobs(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(cmds, "|") != "make|echo hi" {
+		t.Errorf("jobs = %q", cmds)
+	}
+}
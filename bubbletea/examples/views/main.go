@@ -1,18 +1,23 @@
 package main
 
-// An example demonstrating an application with multiple views.
+// An example demonstrating an application with multiple views. Each view is
+// a screen of its own, and a router keeps them in a stack: choosing a task
+// pushes a screen, and esc pops it again.
 //
 // Note that this example was produced before the Bubbles progress component
 // was available (github.com/charmbracelet/bubbles/progress) and thus, we're
 // implementing a progress bar from scratch here.
 
 import (
+	"flag"
 	"fmt"
 	"math"
+	"os"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/fogleman/ease"
@@ -33,24 +38,42 @@ var (
 	ticksStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("79"))
 	checkboxStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("212"))
 	progressEmpty = subtleStyle.Render(progressEmptyChar)
-	dotStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("236")).Render(dotChar)
+	dotStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("236"))
 	mainStyle     = lipgloss.NewStyle().MarginLeft(2)
 
 	// Gradient colors we'll use for the progress bar
 	ramp = makeRampStyles("#B14FFF", "#00FFA3", progressBarWidth)
 )
 
+// The screens you can start on with -route. They can be put together, like
+// choices/market, to start with more than one screen on the stack.
+var routes = map[string]route{
+	"choices": func() tea.Model { return newChoices() },
+	"carrots": func() tea.Model { return newChosen(0) },
+	"market":  func() tea.Model { return newChosen(1) },
+	"reading": func() tea.Model { return newChosen(2) },
+	"friends": func() tea.Model { return newChosen(3) },
+}
+
 func main() {
-	initialModel := model{0, false, 10, 0, 0, false, false}
-	p := tea.NewProgram(initialModel)
+	route := flag.String("route", "choices", "the screens to start on, like choices/market")
+	flag.Parse()
+
+	screens, err := parseRoute(routes, *route)
+	if err != nil {
+		fmt.Println("could not start program:", err)
+		os.Exit(1)
+	}
+	p := tea.NewProgram(newRouter(screens...))
 	if _, err := p.Run(); err != nil {
 		fmt.Println("could not start program:", err)
 	}
 }
 
 type (
-	tickMsg  struct{}
-	frameMsg struct{}
+	tickMsg      struct{}
+	frameMsg     struct{}
+	countdownMsg struct{ id int }
 )
 
 func tick() tea.Cmd {
@@ -65,90 +88,130 @@ func frame() tea.Cmd {
 	})
 }
 
-type model struct {
-	Choice   int
-	Chosen   bool
-	Ticks    int
-	Frames   int
-	Progress float64
-	Loaded   bool
-	Quitting bool
+// countdown ticks for the choices screen. Each time the screen is shown it
+// starts counting again, so they have ids to tell the latest count from any
+// still going from before.
+func countdown(id int) tea.Cmd {
+	return tea.Tick(time.Second, func(time.Time) tea.Msg {
+		return countdownMsg{id}
+	})
 }
 
-func (m model) Init() tea.Cmd {
-	return tick()
-}
+// Screens
 
-// Main update function.
-func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	// Make sure these keys always quit
-	if msg, ok := msg.(tea.KeyMsg); ok {
-		k := msg.String()
-		if k == "q" || k == "esc" || k == "ctrl+c" {
-			m.Quitting = true
-			return m, tea.Quit
-		}
-	}
+// The first screen, where you're choosing a task.
+type choices struct {
+	Choice  int
+	Ticks   int
+	countID int
+}
 
-	// Hand off the message and model to the appropriate update function for the
-	// appropriate view based on the current state.
-	if !m.Chosen {
-		return updateChoices(msg, m)
-	}
-	return updateChosen(msg, m)
+var choicesKeys = struct {
+	up, down, choose key.Binding
+}{
+	up:     key.NewBinding(key.WithKeys("k", "up"), key.WithHelp("k/↑", "up")),
+	down:   key.NewBinding(key.WithKeys("j", "down"), key.WithHelp("j/↓", "down")),
+	choose: key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "choose")),
 }
 
-// The main view, which just calls the appropriate sub-view
-func (m model) View() string {
-	var s string
-	if m.Quitting {
-		return "\n  See you later!\n\n"
-	}
-	if !m.Chosen {
-		s = choicesView(m)
-	} else {
-		s = chosenView(m)
-	}
-	return mainStyle.Render("\n" + s + "\n\n")
+func newChoices() choices {
+	return choices{Ticks: 10}
 }
 
-// Sub-update functions
+func (m choices) Init() tea.Cmd {
+	return countdown(m.countID)
+}
 
-// Update loop for the first view where you're choosing a task.
-func updateChoices(msg tea.Msg, m model) (tea.Model, tea.Cmd) {
+func (m choices) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "j", "down":
+		switch {
+		case key.Matches(msg, choicesKeys.down):
 			m.Choice++
 			if m.Choice > 3 {
 				m.Choice = 3
 			}
-		case "k", "up":
+		case key.Matches(msg, choicesKeys.up):
 			m.Choice--
 			if m.Choice < 0 {
 				m.Choice = 0
 			}
-		case "enter":
-			m.Chosen = true
-			return m, frame()
+		case key.Matches(msg, choicesKeys.choose):
+			return m, push(newChosen(m.Choice))
 		}
 
-	case tickMsg:
+	case shownMsg:
+		m.countID++
+		return m, countdown(m.countID)
+
+	case countdownMsg:
+		if msg.id != m.countID {
+			return m, nil
+		}
 		if m.Ticks == 0 {
-			m.Quitting = true
-			return m, tea.Quit
+			return m, exit
 		}
 		m.Ticks--
-		return m, tick()
+		return m, countdown(m.countID)
 	}
 
 	return m, nil
 }
 
-// Update loop for the second view after a choice has been made
-func updateChosen(msg tea.Msg, m model) (tea.Model, tea.Cmd) {
-	switch msg.(type) {
+func (m choices) keys() []key.Binding {
+	return []key.Binding{choicesKeys.up, choicesKeys.down, choicesKeys.choose}
+}
+
+func (m choices) View() string {
+	c := m.Choice
+
+	tpl := "What to do today?\n\n"
+	tpl += "%s\n\n"
+	tpl += "Program quits in %s seconds"
+
+	choices := fmt.Sprintf(
+		"%s\n%s\n%s\n%s",
+		checkbox("Plant carrots", c == 0),
+		checkbox("Go to the market", c == 1),
+		checkbox("Read something", c == 2),
+		checkbox("See friends", c == 3),
+	)
+
+	return fmt.Sprintf(tpl, choices, ticksStyle.Render(strconv.Itoa(m.Ticks)))
+}
+
+// The second screen, after a task has been chosen.
+type chosen struct {
+	Choice   int
+	Ticks    int
+	Frames   int
+	Progress float64
+	Loaded   bool
+}
+
+var chosenKeys = struct {
+	next key.Binding
+}{
+	next: key.NewBinding(key.WithKeys("n"), key.WithHelp("n", "next task")),
+}
+
+func newChosen(choice int) chosen {
+	return chosen{Choice: choice}
+}
+
+func (m chosen) Init() tea.Cmd {
+	return frame()
+}
+
+func (m chosen) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		// Once we're done downloading, move on to the next task in place of
+		// this one.
+		if m.Loaded && key.Matches(msg, chosenKeys.next) {
+			return m, replace(newChosen((m.Choice + 1) % 4))
+		}
+
 	case frameMsg:
 		if !m.Loaded {
 			m.Frames++
@@ -165,8 +228,7 @@ func updateChosen(msg tea.Msg, m model) (tea.Model, tea.Cmd) {
 	case tickMsg:
 		if m.Loaded {
 			if m.Ticks == 0 {
-				m.Quitting = true
-				return m, tea.Quit
+				return m, exit
 			}
 			m.Ticks--
 			return m, tick()
@@ -176,32 +238,14 @@ func updateChosen(msg tea.Msg, m model) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
-// Sub-views
-
-// The first view, where you're choosing a task
-func choicesView(m model) string {
-	c := m.Choice
-
-	tpl := "What to do today?\n\n"
-	tpl += "%s\n\n"
-	tpl += "Program quits in %s seconds\n\n"
-	tpl += subtleStyle.Render("j/k, up/down: select") + dotStyle +
-		subtleStyle.Render("enter: choose") + dotStyle +
-		subtleStyle.Render("q, esc: quit")
-
-	choices := fmt.Sprintf(
-		"%s\n%s\n%s\n%s",
-		checkbox("Plant carrots", c == 0),
-		checkbox("Go to the market", c == 1),
-		checkbox("Read something", c == 2),
-		checkbox("See friends", c == 3),
-	)
-
-	return fmt.Sprintf(tpl, choices, ticksStyle.Render(strconv.Itoa(m.Ticks)))
+func (m chosen) keys() []key.Binding {
+	if !m.Loaded {
+		return nil
+	}
+	return []key.Binding{chosenKeys.next}
 }
 
-// The second view, after a task has been chosen
-func chosenView(m model) string {
+func (m chosen) View() string {
 	var msg string
 
 	switch m.Choice {
@@ -0,0 +1,271 @@
+package main
+
+// The router keeps a stack of screens, each of them a tea.Model. Only the
+// screen on top sees messages. Screens move around the stack by returning the
+// commands below, and esc always goes back a screen.
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/fogleman/ease"
+)
+
+type (
+	pushMsg    struct{ screen tea.Model }
+	replaceMsg struct{ screen tea.Model }
+	popMsg     struct{}
+	exitMsg    struct{}
+
+	// shownMsg is sent to a screen when it's back on top, after the one above
+	// it goes.
+	shownMsg struct{}
+
+	slideMsg struct{ id int }
+)
+
+// push puts a screen on top of the current one.
+func push(s tea.Model) tea.Cmd {
+	return func() tea.Msg { return pushMsg{s} }
+}
+
+// replace swaps the current screen for another.
+func replace(s tea.Model) tea.Cmd {
+	return func() tea.Msg { return replaceMsg{s} }
+}
+
+// pop goes back to the screen before. Popping the last screen quits.
+func pop() tea.Msg {
+	return popMsg{}
+}
+
+// exit quits from any screen.
+func exit() tea.Msg {
+	return exitMsg{}
+}
+
+// keyMapper is a screen with keys of its own to put in the help bar.
+type keyMapper interface {
+	keys() []key.Binding
+}
+
+type routerKeyMap struct {
+	back key.Binding
+	quit key.Binding
+}
+
+var routerKeys = routerKeyMap{
+	back: key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "back")),
+	quit: key.NewBinding(key.WithKeys("q", "ctrl+c"), key.WithHelp("q", "quit")),
+}
+
+const (
+	slideFrames = 15
+	slideFPS    = 60
+)
+
+// slide is a transition between two screens. The old screen slides out to the
+// left when going forward, and to the right when going back.
+type slide struct {
+	id    int
+	from  string
+	back  bool
+	frame int
+}
+
+type router struct {
+	stack    []tea.Model
+	pending  int // screens at the bottom of the stack that haven't started yet
+	slide    *slide
+	slides   int // how many slides there have been, to tell them apart
+	width    int
+	help     help.Model
+	quitting bool
+}
+
+func newRouter(screens ...tea.Model) router {
+	h := help.New()
+	h.ShortSeparator = dotChar
+	h.Styles.ShortKey = subtleStyle
+	h.Styles.ShortDesc = subtleStyle
+	h.Styles.ShortSeparator = dotStyle
+	// Only the top screen starts now. The ones underneath start when
+	// they're first shown, since they wouldn't get their messages before.
+	return router{stack: screens, pending: len(screens) - 1, help: h}
+}
+
+// route is a way to make a screen, by name.
+type route func() tea.Model
+
+// parseRoute makes the screens for a path of route names, like "a/b/c", with
+// c on top.
+func parseRoute(routes map[string]route, path string) ([]tea.Model, error) {
+	var screens []tea.Model
+	for _, name := range strings.Split(strings.Trim(path, "/"), "/") {
+		r, ok := routes[name]
+		if !ok {
+			return nil, fmt.Errorf("no route called %q", name)
+		}
+		screens = append(screens, r())
+	}
+	return screens, nil
+}
+
+func (r router) top() tea.Model {
+	return r.stack[len(r.stack)-1]
+}
+
+func (r router) Init() tea.Cmd {
+	return r.top().Init()
+}
+
+func (r router) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, routerKeys.quit):
+			r.quitting = true
+			return r, tea.Quit
+		case key.Matches(msg, routerKeys.back):
+			return r.Update(popMsg{})
+		}
+
+	case tea.WindowSizeMsg:
+		// Every screen is kept sized, so they're ready when they're shown.
+		r.width = msg.Width
+		r.help.Width = msg.Width
+		var cmds []tea.Cmd
+		for i, s := range r.stack {
+			var cmd tea.Cmd
+			r.stack[i], cmd = s.Update(msg)
+			cmds = append(cmds, cmd)
+		}
+		return r, tea.Batch(cmds...)
+
+	case pushMsg:
+		r = r.startSlide(false)
+		r.stack = append(r.stack, msg.screen)
+		return r, tea.Batch(msg.screen.Init(), r.nextSlide())
+
+	case replaceMsg:
+		r = r.startSlide(false)
+		r.stack[len(r.stack)-1] = msg.screen
+		return r, tea.Batch(msg.screen.Init(), r.nextSlide())
+
+	case popMsg:
+		if len(r.stack) == 1 {
+			r.quitting = true
+			return r, tea.Quit
+		}
+		r = r.startSlide(true)
+		r.stack = r.stack[:len(r.stack)-1]
+		var start tea.Cmd
+		if top := len(r.stack) - 1; top < r.pending {
+			r.pending = top
+			start = r.top().Init()
+		}
+		next, cmd := r.top().Update(shownMsg{})
+		r.stack[len(r.stack)-1] = next
+		return r, tea.Batch(start, cmd, r.nextSlide())
+
+	case exitMsg:
+		r.quitting = true
+		return r, tea.Quit
+
+	case slideMsg:
+		if r.slide == nil || msg.id != r.slide.id {
+			return r, nil
+		}
+		s := *r.slide
+		s.frame++
+		r.slide = &s
+		if s.frame >= slideFrames {
+			r.slide = nil
+			return r, nil
+		}
+		return r, r.nextSlide()
+	}
+
+	next, cmd := r.top().Update(msg)
+	r.stack[len(r.stack)-1] = next
+	return r, cmd
+}
+
+// startSlide remembers how the screen looks before it changes. Without a
+// width we don't know how far to slide, so we don't.
+func (r router) startSlide(back bool) router {
+	r.slide = nil
+	if r.width > 0 {
+		r.slides++
+		r.slide = &slide{id: r.slides, from: r.screenView(), back: back}
+	}
+	return r
+}
+
+func (r router) nextSlide() tea.Cmd {
+	if r.slide == nil {
+		return nil
+	}
+	id := r.slide.id
+	return tea.Tick(time.Second/slideFPS, func(time.Time) tea.Msg {
+		return slideMsg{id}
+	})
+}
+
+// helpView is the keys for the screen on top, then the router's own.
+func (r router) helpView() string {
+	var keys []key.Binding
+	if km, ok := r.top().(keyMapper); ok {
+		keys = append(keys, km.keys()...)
+	}
+	back := routerKeys.back
+	if len(r.stack) == 1 {
+		back.SetHelp("esc", "quit")
+	}
+	keys = append(keys, back, routerKeys.quit)
+	return r.help.ShortHelpView(keys)
+}
+
+func (r router) screenView() string {
+	return mainStyle.Render("\n" + r.top().View() + "\n\n" + r.helpView() + "\n\n")
+}
+
+func (r router) View() string {
+	if r.quitting {
+		return "\n  See you later!\n\n"
+	}
+	if r.slide == nil {
+		return r.screenView()
+	}
+	p := ease.OutCubic(float64(r.slide.frame) / slideFrames)
+	if r.slide.back {
+		return slideView(r.screenView(), r.slide.from, r.width, 1-p)
+	}
+	return slideView(r.slide.from, r.screenView(), r.width, p)
+}
+
+// slideView puts two screens side by side, and shows a screen's width of them,
+// offset by p.
+func slideView(left, right string, width int, p float64) string {
+	offset := int(p * float64(width))
+	l, rl := strings.Split(left, "\n"), strings.Split(right, "\n")
+	lines := make([]string, max(len(l), len(rl)))
+	for i := range lines {
+		var a, b string
+		if i < len(l) {
+			a = l[i]
+		}
+		if i < len(rl) {
+			b = rl[i]
+		}
+		a = ansi.Cut(a, offset, width)
+		a += strings.Repeat(" ", max(0, width-offset-ansi.StringWidth(a)))
+		lines[i] = a + ansi.Cut(b, 0, offset)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// page is a screen that remembers what it was sent.
+type page struct {
+	name  string
+	shown int
+	last  tea.Msg
+}
+
+// startedMsg is what a page's Init sends.
+type startedMsg string
+
+func (p page) Init() tea.Cmd {
+	return func() tea.Msg { return startedMsg(p.name) }
+}
+
+func (p page) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if _, ok := msg.(shownMsg); ok {
+		p.shown++
+	}
+	p.last = msg
+	return p, nil
+}
+
+func (p page) View() string { return "page " + p.name }
+
+func (p page) keys() []key.Binding {
+	return []key.Binding{key.NewBinding(key.WithKeys("x"), key.WithHelp("x", p.name+" thing"))}
+}
+
+// send updates the router with a message, and then with any navigation
+// messages it leads to.
+func send(t *testing.T, r router, msg tea.Msg) router {
+	t.Helper()
+	next, cmd := r.Update(msg)
+	r = next.(router)
+	if cmd == nil {
+		return r
+	}
+	switch msg := cmd().(type) {
+	case pushMsg, replaceMsg, popMsg, exitMsg:
+		return send(t, r, msg)
+	}
+	return r
+}
+
+func names(r router) string {
+	var s []string
+	for _, p := range r.stack {
+		s = append(s, p.(page).name)
+	}
+	return strings.Join(s, "/")
+}
+
+func TestParseRoute(t *testing.T) {
+	screens, err := parseRoute(routes, "/choices/market")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(screens) != 2 || screens[1].(chosen).Choice != 1 {
+		t.Errorf("got %#v", screens)
+	}
+	if _, err := parseRoute(routes, "choices/nowhere"); err == nil || !strings.Contains(err.Error(), `"nowhere"`) {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestNavigation(t *testing.T) {
+	r := newRouter(page{name: "a"})
+
+	r = send(t, r, pushMsg{page{name: "b"}})
+	r = send(t, r, pushMsg{page{name: "c"}})
+	if got := names(r); got != "a/b/c" {
+		t.Fatalf("stack = %s", got)
+	}
+	r = send(t, r, replaceMsg{page{name: "d"}})
+	if got := names(r); got != "a/b/d" {
+		t.Fatalf("after replacing: %s", got)
+	}
+
+	// Only the top screen hears about things.
+	r = send(t, r, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'x'}})
+	if r.stack[1].(page).last != nil {
+		t.Error("a screen underneath got a key")
+	}
+
+	r = send(t, r, tea.KeyMsg{Type: tea.KeyEsc})
+	if got := names(r); got != "a/b" || r.top().(page).shown != 1 {
+		t.Fatalf("after going back: %s, shown %d times", got, r.top().(page).shown)
+	}
+	r = send(t, r, tea.KeyMsg{Type: tea.KeyEsc})
+	r = send(t, r, tea.KeyMsg{Type: tea.KeyEsc})
+	if !r.quitting || names(r) != "a" {
+		t.Errorf("esc on the last screen didn't quit: %s", names(r))
+	}
+}
+
+// started runs a command and returns the pages it started.
+func started(cmd tea.Cmd) []string {
+	if cmd == nil {
+		return nil
+	}
+	switch msg := cmd().(type) {
+	case startedMsg:
+		return []string{string(msg)}
+	case tea.BatchMsg:
+		var names []string
+		for _, c := range msg {
+			names = append(names, started(c)...)
+		}
+		return names
+	}
+	return nil
+}
+
+func TestDeepLink(t *testing.T) {
+	r := newRouter(page{name: "a"}, page{name: "b"}, page{name: "c"})
+	if got := started(r.Init()); strings.Join(got, " ") != "c" {
+		t.Fatalf("started %q, want just c", got)
+	}
+
+	// Screens underneath start the first time they're shown, and only then.
+	update := func(msg tea.Msg) []string {
+		next, cmd := r.Update(msg)
+		r = next.(router)
+		return started(cmd)
+	}
+	if got := update(popMsg{}); strings.Join(got, " ") != "b" {
+		t.Errorf("going back to b started %q", got)
+	}
+	update(pushMsg{page{name: "d"}})
+	if got := update(popMsg{}); len(got) != 0 {
+		t.Errorf("going back to b again started %q", got)
+	}
+	if got := update(popMsg{}); strings.Join(got, " ") != "a" {
+		t.Errorf("going back to a started %q", got)
+	}
+}
+
+func TestHelp(t *testing.T) {
+	r := newRouter(page{name: "a"})
+	if got := ansi.Strip(r.helpView()); got != "x a thing • esc quit • q quit" {
+		t.Errorf("help = %q", got)
+	}
+	r = send(t, r, pushMsg{page{name: "b"}})
+	if got := ansi.Strip(r.helpView()); got != "x b thing • esc back • q quit" {
+		t.Errorf("help = %q", got)
+	}
+}
+
+func TestSlide(t *testing.T) {
+	left, right := "abcd\nef", "1234\n5678"
+	for _, tt := range []struct {
+		p    float64
+		want string
+	}{
+		{0, "abcd\nef  "},
+		{0.5, "cd12\n  56"},
+		{1, "1234\n5678"},
+	} {
+		if got := slideView(left, right, 4, tt.p); got != tt.want {
+			t.Errorf("slideView at %v = %q, want %q", tt.p, got, tt.want)
+		}
+	}
+
+	r := newRouter(page{name: "a"})
+	r = send(t, r, tea.WindowSizeMsg{Width: 40, Height: 10})
+	r = send(t, r, pushMsg{page{name: "b"}})
+	if r.slide == nil || !strings.Contains(r.View(), "page a") {
+		t.Fatal("pushing didn't start a slide")
+	}
+	old := r.slide.id
+	for range slideFrames {
+		r = send(t, r, slideMsg{old})
+	}
+	if r.slide != nil || !strings.Contains(r.View(), "page b") {
+		t.Errorf("the slide didn't finish:\n%s", r.View())
+	}
+
+	// A slide that's been overtaken by another stops.
+	r = send(t, r, pushMsg{page{name: "c"}})
+	r = send(t, r, slideMsg{old})
+	if r.slide.frame != 0 {
+		t.Error("an old slide moved the new one on")
+	}
+}
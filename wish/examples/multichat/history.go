@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// entry is a single line of a room's history log.
+type entry struct {
+	Time time.Time `json:"time"`
+	ID   string    `json:"id"`
+	Text string    `json:"text"`
+}
+
+// store persists room history as one append-only JSON lines file per room.
+type store struct {
+	dir   string
+	mu    sync.Mutex
+	files map[string]*os.File
+}
+
+func newStore(dir string) (*store, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &store{dir: dir, files: map[string]*os.File{}}, nil
+}
+
+func (s *store) path(room string) string {
+	return filepath.Join(s.dir, room+".log")
+}
+
+// append writes an entry to the end of the room's log.
+func (s *store) append(room string, e entry) error {
+	bts, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, ok := s.files[room]
+	if !ok {
+		f, err = openLog(s.path(room))
+		if err != nil {
+			return err
+		}
+		s.files[room] = f
+	}
+	_, err = f.Write(append(bts, '\n'))
+	return err
+}
+
+// openLog opens a log for appending. If the last line was cut short by a
+// crash it's ended first, so the next entry doesn't get glued onto it.
+func openLog(path string) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	if fi.Size() == 0 {
+		return f, nil
+	}
+
+	last := make([]byte, 1)
+	if _, err := f.ReadAt(last, fi.Size()-1); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	if last[0] != '\n' {
+		if _, err := f.Write([]byte{'\n'}); err != nil {
+			_ = f.Close()
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// history returns at most the last n entries of the room's log. Lines that
+// can't be decoded, such as a partial write from a crash, are skipped.
+func (s *store) history(room string, n int) ([]entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.Open(s.path(room))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() //nolint:errcheck
+
+	var entries []entry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		entries = append(entries, e)
+		if len(entries) > n {
+			entries = entries[1:]
+		}
+	}
+	return entries, scanner.Err()
+}
+
+// Close closes all open log files.
+func (s *store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var errs []error
+	for room, f := range s.files {
+		errs = append(errs, f.Close())
+		delete(s.files, room)
+	}
+	return errors.Join(errs...)
+}
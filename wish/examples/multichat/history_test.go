@@ -0,0 +1,76 @@
+package main
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestHistory(t *testing.T) {
+	dir := t.TempDir()
+	st, err := newStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if h, err := st.history("lobby", 10); err != nil || len(h) != 0 {
+		t.Fatalf("history of an empty room = %v, %v", h, err)
+	}
+
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	for i, text := range []string{"one", "two", "three"} {
+		e := entry{Time: start.Add(time.Duration(i) * time.Minute), ID: "ann", Text: text}
+		if err := st.append("lobby", e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := st.append("other", entry{ID: "bob", Text: "elsewhere"}); err != nil {
+		t.Fatal(err)
+	}
+
+	h, err := st.history("lobby", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(h) != 2 || h[0].Text != "two" || h[1].Text != "three" {
+		t.Fatalf("last two entries = %+v", h)
+	}
+	if !h[1].Time.Equal(start.Add(2 * time.Minute)) {
+		t.Errorf("time = %v, want %v", h[1].Time, start.Add(2*time.Minute))
+	}
+
+	// A line cut short by a crash is skipped, and what's said after a
+	// restart isn't lost along with it.
+	if err := st.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.OpenFile(st.path("lobby"), os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString(`{"time": "2024-03-01T09:0`); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if st, err = newStore(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.append("lobby", entry{ID: "ann", Text: "four"}); err != nil {
+		t.Fatal(err)
+	}
+	if h, err = st.history("lobby", 10); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.Close(); err != nil {
+		t.Fatal(err)
+	}
+	var texts []string
+	for _, e := range h {
+		texts = append(texts, e.Text)
+	}
+	if len(texts) != 4 || texts[3] != "four" {
+		t.Errorf("history after a torn write = %q", texts)
+	}
+}
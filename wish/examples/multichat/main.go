@@ -7,6 +7,7 @@ import (
 	"os"
 	"os/signal"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -24,25 +25,32 @@ import (
 )
 
 const (
-	host = "localhost"
-	port = "23234"
+	host       = "localhost"
+	port       = "23234"
+	historyDir = ".multichat"
 )
 
-// app contains a wish server and the list of running programs.
+// app contains a wish server, the connected clients and the rooms they're in.
 type app struct {
 	*ssh.Server
-	progs []*tea.Program
-}
+	store *store
 
-// send dispatches a message to all running programs.
-func (a *app) send(msg tea.Msg) {
-	for _, p := range a.progs {
-		go p.Send(msg)
-	}
+	mu      sync.Mutex
+	clients map[*client]struct{}
+	rooms   map[string]map[*client]struct{}
 }
 
 func newApp() *app {
-	a := new(app)
+	st, err := newStore(historyDir)
+	if err != nil {
+		log.Fatal("Could not open history", "error", err)
+	}
+
+	a := &app{
+		store:   st,
+		clients: map[*client]struct{}{},
+		rooms:   map[string]map[*client]struct{}{},
+	}
 	s, err := wish.NewServer(
 		wish.WithAddress(net.JoinHostPort(host, port)),
 		wish.WithHostKeyPath(".ssh/id_ed25519"),
@@ -79,15 +87,28 @@ func (a *app) Start() {
 	if err := a.Shutdown(ctx); err != nil {
 		log.Error("Could not stop server", "error", err)
 	}
+	if err := a.store.Close(); err != nil {
+		log.Error("Could not close history", "error", err)
+	}
 }
 
 func (a *app) ProgramHandler(s ssh.Session) *tea.Program {
+	c := newClient(s.User())
+
 	model := initialModel()
 	model.app = a
-	model.id = s.User()
+	model.client = c
 
 	p := tea.NewProgram(model, bubbletea.MakeOptions(s)...)
-	a.progs = append(a.progs, p)
+	a.register(c)
+	go c.deliver(p.Send)
+
+	// Forget about the program once the session goes away so we stop
+	// sending messages to it.
+	go func() {
+		<-s.Context().Done()
+		a.unregister(c)
+	}()
 
 	return p
 }
@@ -100,18 +121,30 @@ func main() {
 type (
 	errMsg  error
 	chatMsg struct {
-		id   string
+		room string
+		entry
+	}
+	noticeMsg struct {
+		room string
 		text string
 	}
+	joinedMsg struct {
+		room    string
+		history []entry
+	}
+	// infoMsg is a message shown only to the session that asked for it.
+	infoMsg string
 )
 
 type model struct {
 	*app
+	client      *client
+	room        string
 	viewport    viewport.Model
 	messages    []string
-	id          string
 	textarea    textarea.Model
 	senderStyle lipgloss.Style
+	noticeStyle lipgloss.Style
 	err         error
 }
 
@@ -142,12 +175,72 @@ Type a message and press Enter to send.`)
 		messages:    []string{},
 		viewport:    vp,
 		senderStyle: lipgloss.NewStyle().Foreground(lipgloss.Color("5")),
+		noticeStyle: lipgloss.NewStyle().Foreground(lipgloss.Color("8")),
 		err:         nil,
 	}
 }
 
 func (m model) Init() tea.Cmd {
-	return textarea.Blink
+	return tea.Batch(textarea.Blink, m.joinRoom(defaultRoom))
+}
+
+func (m model) joinRoom(room string) tea.Cmd {
+	return func() tea.Msg {
+		// The joinedMsg comes from the room, ahead of its other messages.
+		if err := m.app.join(m.client, room); err != nil {
+			return errMsg(err)
+		}
+		return nil
+	}
+}
+
+func (m model) sayCmd(text string) tea.Cmd {
+	return func() tea.Msg {
+		if err := m.app.say(m.client, text); err != nil {
+			return errMsg(err)
+		}
+		return nil
+	}
+}
+
+// command handles a slash command typed into the textarea.
+func (m model) command(input string) tea.Cmd {
+	fields := strings.Fields(input)
+	switch fields[0] {
+	case "/join":
+		if len(fields) != 2 {
+			return infoCmd("usage: /join <room>")
+		}
+		return m.joinRoom(fields[1])
+	case "/leave":
+		if m.room == defaultRoom {
+			return infoCmd("you're already in #" + defaultRoom)
+		}
+		return m.joinRoom(defaultRoom)
+	case "/rooms":
+		var b strings.Builder
+		b.WriteString("rooms:")
+		for _, r := range m.app.listRooms() {
+			fmt.Fprintf(&b, "\n  #%s (%d)", r.name, r.members)
+		}
+		return infoCmd(b.String())
+	default:
+		return infoCmd("unknown command " + fields[0] + ", try /join, /leave or /rooms")
+	}
+}
+
+func infoCmd(text string) tea.Cmd {
+	return func() tea.Msg { return infoMsg(text) }
+}
+
+func (m *model) appendLine(line string) {
+	m.messages = append(m.messages, line)
+	m.viewport.SetContent(strings.Join(m.messages, "\n"))
+	m.viewport.GotoBottom()
+}
+
+func (m model) formatEntry(e entry) string {
+	return m.senderStyle.Render(e.ID) + ": " + e.Text
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -165,17 +258,39 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case tea.KeyCtrlC, tea.KeyEsc:
 			return m, tea.Quit
 		case tea.KeyEnter:
-			m.app.send(chatMsg{
-				id:   m.id,
-				text: m.textarea.Value(),
-			})
+			text := strings.TrimSpace(m.textarea.Value())
 			m.textarea.Reset()
+			if text == "" {
+				break
+			}
+			if strings.HasPrefix(text, "/") {
+				return m, tea.Batch(tiCmd, vpCmd, m.command(text))
+			}
+			return m, tea.Batch(tiCmd, vpCmd, m.sayCmd(text))
+		}
+
+	case joinedMsg:
+		m.room = msg.room
+		m.err = nil
+		m.messages = nil
+		for _, e := range msg.history {
+			m.messages = append(m.messages, m.formatEntry(e))
 		}
+		m.appendLine(m.noticeStyle.Render("you're in #" + msg.room))
 
 	case chatMsg:
-		m.messages = append(m.messages, m.senderStyle.Render(msg.id)+": "+msg.text)
-		m.viewport.SetContent(strings.Join(m.messages, "\n"))
-		m.viewport.GotoBottom()
+		// Drop messages that raced with a room change.
+		if msg.room == m.room {
+			m.appendLine(m.formatEntry(msg.entry))
+		}
+
+	case noticeMsg:
+		if msg.room == m.room {
+			m.appendLine(m.noticeStyle.Render(msg.text))
+		}
+
+	case infoMsg:
+		m.appendLine(m.noticeStyle.Render(string(msg)))
 
 	// We handle errors just like any other message
 	case errMsg:
@@ -187,9 +302,14 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m model) View() string {
+	status := m.noticeStyle.Render("#" + m.room)
+	if m.err != nil {
+		status = m.noticeStyle.Render("error: " + m.err.Error())
+	}
 	return fmt.Sprintf(
-		"%s\n\n%s",
+		"%s\n\n%s\n%s",
 		m.viewport.View(),
 		m.textarea.View(),
+		status,
 	) + "\n\n"
 }
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	defaultRoom = "lobby"
+	historySize = 100
+)
+
+var roomNameRe = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+
+// client is a connected session and the room it's currently in.
+type client struct {
+	id   string
+	room string // guarded by app.mu
+
+	// Messages for the session wait in a queue until its program takes
+	// them, so that they arrive in the order they were sent and nobody
+	// waits on a slow session.
+	mu    sync.Mutex
+	queue []tea.Msg
+	wake  chan struct{}
+}
+
+func newClient(id string) *client {
+	return &client{id: id, wake: make(chan struct{}, 1)}
+}
+
+// post queues a message for the session. It must be called with app.mu
+// held, and not after the client is unregistered.
+func (c *client) post(msg tea.Msg) {
+	c.mu.Lock()
+	c.queue = append(c.queue, msg)
+	c.mu.Unlock()
+
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+}
+
+// deliver hands queued messages to send, one at a time, until the client is
+// unregistered.
+func (c *client) deliver(send func(tea.Msg)) {
+	for range c.wake {
+		for {
+			c.mu.Lock()
+			if len(c.queue) == 0 {
+				c.mu.Unlock()
+				break
+			}
+			msg := c.queue[0]
+			c.queue = c.queue[1:]
+			c.mu.Unlock()
+			send(msg)
+		}
+	}
+}
+
+type roomInfo struct {
+	name    string
+	members int
+}
+
+// register adds a client to the app. It doesn't belong to any room until it
+// joins one.
+func (a *app) register(c *client) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.clients[c] = struct{}{}
+}
+
+// unregister removes a client from the app and its room, and tells the room
+// it left.
+func (a *app) unregister(c *client) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if _, ok := a.clients[c]; !ok {
+		return
+	}
+	room := c.room
+	delete(a.clients, c)
+	a.removeFromRoom(c)
+	close(c.wake)
+
+	if room != "" {
+		a.notify(room, fmt.Sprintf("%s left", c.id))
+	}
+}
+
+// join moves a client into the given room. The client is sent a joinedMsg
+// with the room's history before anything else from the room.
+func (a *app) join(c *client, room string) error {
+	if !roomNameRe.MatchString(room) {
+		return fmt.Errorf("invalid room name %q", room)
+	}
+
+	// Messages are stored and sent with the lock held, so reading the
+	// history and joining with it held too means every message ends up
+	// either in the history or in the client's queue, and never both.
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if _, ok := a.clients[c]; !ok {
+		return errors.New("session closed")
+	}
+	history, err := a.store.history(room, historySize)
+	if err != nil {
+		return err
+	}
+
+	if prev := c.room; prev != "" {
+		a.removeFromRoom(c)
+		a.notify(prev, fmt.Sprintf("%s left for #%s", c.id, room))
+	}
+	if a.rooms[room] == nil {
+		a.rooms[room] = map[*client]struct{}{}
+	}
+	a.rooms[room][c] = struct{}{}
+	c.room = room
+
+	c.post(joinedMsg{room: room, history: history})
+	a.notify(room, fmt.Sprintf("%s joined", c.id))
+	return nil
+}
+
+// removeFromRoom must be called with a.mu held.
+func (a *app) removeFromRoom(c *client) {
+	members, ok := a.rooms[c.room]
+	if !ok {
+		return
+	}
+	delete(members, c)
+	if len(members) == 0 && c.room != defaultRoom {
+		delete(a.rooms, c.room)
+	}
+	c.room = ""
+}
+
+// listRooms returns the rooms that currently have members, sorted by name.
+func (a *app) listRooms() []roomInfo {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	rooms := make([]roomInfo, 0, len(a.rooms))
+	for name, members := range a.rooms {
+		rooms = append(rooms, roomInfo{name: name, members: len(members)})
+	}
+	sort.Slice(rooms, func(i, j int) bool { return rooms[i].name < rooms[j].name })
+	return rooms
+}
+
+// say records a message in the room's history and dispatches it to
+// everyone in the room.
+func (a *app) say(c *client, text string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	room := c.room
+	if room == "" {
+		return errors.New("not in a room")
+	}
+
+	e := entry{Time: time.Now(), ID: c.id, Text: text}
+	if err := a.store.append(room, e); err != nil {
+		return err
+	}
+	a.send(room, chatMsg{room: room, entry: e})
+	return nil
+}
+
+// notify dispatches a system message to everyone in the room. Notices are
+// not persisted. It must be called with a.mu held.
+func (a *app) notify(room, text string) {
+	a.send(room, noticeMsg{room: room, text: text})
+}
+
+// send dispatches a message to everyone in the room. It must be called with
+// a.mu held.
+func (a *app) send(room string, msg tea.Msg) {
+	for c := range a.rooms[room] {
+		c.post(msg)
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func newTestApp(t *testing.T) *app {
+	t.Helper()
+	st, err := newStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { st.Close() }) //nolint:errcheck
+	return &app{
+		store:   st,
+		clients: map[*client]struct{}{},
+		rooms:   map[string]map[*client]struct{}{},
+	}
+}
+
+// connect registers a client whose messages are collected in a channel, as
+// the program would get them.
+func connect(t *testing.T, a *app, id string) (*client, <-chan tea.Msg) {
+	t.Helper()
+	c := newClient(id)
+	a.register(c)
+	msgs := make(chan tea.Msg, 100)
+	go c.deliver(func(msg tea.Msg) { msgs <- msg })
+	t.Cleanup(func() { a.unregister(c) })
+	return c, msgs
+}
+
+// receive returns what the client is sent, as text, until nothing more
+// arrives for a little while.
+func receive(msgs <-chan tea.Msg) []string {
+	var got []string
+	for {
+		select {
+		case msg := <-msgs:
+			switch msg := msg.(type) {
+			case joinedMsg:
+				s := "joined #" + msg.room
+				for _, e := range msg.history {
+					s += " " + e.Text
+				}
+				got = append(got, s)
+			case chatMsg:
+				got = append(got, fmt.Sprintf("#%s %s: %s", msg.room, msg.ID, msg.Text))
+			case noticeMsg:
+				got = append(got, fmt.Sprintf("#%s %s", msg.room, msg.text))
+			}
+		case <-time.After(50 * time.Millisecond):
+			return got
+		}
+	}
+}
+
+func TestRooms(t *testing.T) {
+	a := newTestApp(t)
+	ann, annMsgs := connect(t, a, "ann")
+	bob, bobMsgs := connect(t, a, "bob")
+
+	if err := a.join(ann, defaultRoom); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.say(ann, "hi"); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.join(bob, defaultRoom); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.say(bob, "hello"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The history comes first, and the messages that follow aren't in it.
+	if got, want := receive(bobMsgs), []string{"joined #lobby hi", "#lobby bob joined", "#lobby bob: hello"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("bob got %q, want %q", got, want)
+	}
+
+	if err := a.join(bob, "kitchen"); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.say(bob, "anyone?"); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"joined #lobby", "#lobby ann joined", "#lobby ann: hi",
+		"#lobby bob joined", "#lobby bob: hello", "#lobby bob left for #kitchen",
+	}
+	if got := receive(annMsgs); !reflect.DeepEqual(got, want) {
+		t.Errorf("ann got %q, want %q", got, want)
+	}
+
+	if got, want := a.listRooms(), []roomInfo{{"kitchen", 1}, {"lobby", 1}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("rooms = %v, want %v", got, want)
+	}
+	a.unregister(bob)
+	if got, want := a.listRooms(), []roomInfo{{"lobby", 1}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("rooms after bob left = %v, want %v", got, want)
+	}
+	if err := a.join(bob, defaultRoom); err == nil {
+		t.Error("joining after the session closed succeeded")
+	}
+
+	if err := a.join(ann, "Not A Room"); err == nil || !strings.Contains(err.Error(), "invalid room name") {
+		t.Errorf("joining a bad room name: %v", err)
+	}
+}
+
+// Whatever is said while someone joins reaches them exactly once, either in
+// the history or after it.
+func TestJoinWhileTalking(t *testing.T) {
+	a := newTestApp(t)
+	ann, _ := connect(t, a, "ann")
+	if err := a.join(ann, defaultRoom); err != nil {
+		t.Fatal(err)
+	}
+
+	const n = 200
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := range n {
+			if err := a.say(ann, fmt.Sprint(i)); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+
+	bob := newClient("bob")
+	a.register(bob)
+	msgs := make(chan tea.Msg, 2*n)
+	go bob.deliver(func(msg tea.Msg) { msgs <- msg })
+	defer a.unregister(bob)
+	if err := a.join(bob, defaultRoom); err != nil {
+		t.Fatal(err)
+	}
+	<-done
+
+	var texts []string
+	for len(texts) < n {
+		switch msg := (<-msgs).(type) {
+		case joinedMsg:
+			if len(texts) != 0 {
+				t.Fatalf("joined after %d messages", len(texts))
+			}
+			for _, e := range msg.history {
+				texts = append(texts, e.Text)
+			}
+		case chatMsg:
+			if len(texts) == 0 && msg.Text != "0" {
+				t.Fatalf("got %q before joining", msg.Text)
+			}
+			texts = append(texts, msg.Text)
+		}
+	}
+	for i, text := range texts {
+		if text != fmt.Sprint(i) {
+			t.Fatalf("message %d is %q, want every message once, in order", i, text)
+		}
+	}
+}